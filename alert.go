@@ -0,0 +1,34 @@
+package main
+
+// Poziomy ważności alertu o bańce
+const (
+	severityHigh   = "wysoki"
+	severityMedium = "średni"
+	severityLow    = "niski"
+)
+
+// Progi iloczynu pewności bańki i udziału aktywa w portfelu
+const (
+	severityHighScore   = 0.25
+	severityMediumScore = 0.05
+)
+
+// alertSeverity ocenia ważność alertu dla bańki o danej pewności (0-1). Bez portfela
+// ważność zależy tylko od pewności; z portfelem pewność jest mnożona przez udział
+// aktywa, więc bańka na aktywie, którego użytkownik nie ma, nie wywołuje alertu.
+// Pusty napis oznacza brak alertu.
+func alertSeverity(confidence float64, p portfolio, symbol string) (string, float64) {
+	score := confidence
+	if p != nil {
+		score *= p.Weight(symbol)
+	}
+	switch {
+	case score >= severityHighScore:
+		return severityHigh, score
+	case score >= severityMediumScore:
+		return severityMedium, score
+	case score > 0:
+		return severityLow, score
+	}
+	return "", score
+}
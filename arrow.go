@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+
+	"cw3/data"
+	"cw3/internal/atomicfile"
+	"cw3/lppl"
+)
+
+// writeArrow zapisuje dane, wartości modelu i reszty jako plik Arrow IPC;
+// dopasowane parametry i pochodzenie wyniku trafiają do metadanych schematu
+func writeArrow(path string, points []data.Point, best lppl.Result, prov provenance) error {
+	names := []string{"tc", "m", "omega", "A", "B", "C", "phi"}
+	keys := append([]string{"cost", "qualified"}, names...)
+	values := []string{fmt.Sprint(best.Cost), fmt.Sprint(best.Qualified())}
+	for _, v := range best.Params {
+		values = append(values, fmt.Sprint(v))
+	}
+	keys = append(keys, "version", "revision", "config_hash", "source", "data_hash")
+	values = append(values, prov.Version, prov.Revision, prov.ConfigHash, prov.Source, prov.DataHash)
+	if len(prov.Pipeline) > 0 {
+		pipeline, err := json.Marshal(prov.Pipeline)
+		if err != nil {
+			return err
+		}
+		keys, values = append(keys, "pipeline"), append(values, string(pipeline))
+	}
+	meta := arrow.NewMetadata(keys, values)
+
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "date", Type: &arrow.TimestampType{Unit: arrow.Millisecond, TimeZone: "UTC"}},
+		{Name: "price", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "model", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "residual", Type: arrow.PrimitiveTypes.Float64},
+	}, &meta)
+
+	b := array.NewRecordBuilder(memory.NewGoAllocator(), schema)
+	defer b.Release()
+
+	p := best.Params
+	timeIndex := data.TimeIndex(points)
+	for i, point := range points {
+		model := math.Exp(lppl.Model(timeIndex[i], p[0], p[1], p[2], p[3], p[4], p[5], p[6]))
+		b.Field(0).(*array.TimestampBuilder).Append(arrow.Timestamp(point.Date.UnixMilli()))
+		b.Field(1).(*array.Float64Builder).Append(point.Price)
+		b.Field(2).(*array.Float64Builder).Append(model)
+		b.Field(3).(*array.Float64Builder).Append(math.Log(point.Price) - math.Log(model))
+	}
+	rec := b.NewRecord()
+	defer rec.Release()
+
+	file, err := atomicfile.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+
+	w, err := ipc.NewFileWriter(file, ipc.WithSchema(schema))
+	if err != nil {
+		return err
+	}
+	if err := w.Write(rec); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return file.Commit()
+}
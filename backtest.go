@@ -0,0 +1,78 @@
+package main
+
+import (
+	"math"
+
+	"cw3/data"
+	"cw3/internal/stats"
+)
+
+// periodsPerYear zwraca liczbę zwrotów na rok do annualizacji wskaźnika Sharpe'a: liczbę
+// odstępów między notowaniami podzieloną przez ich łączny czas w latach. Daje 365 dla
+// kryptowalut notowanych codziennie, 8760 dla świec godzinowych i około 252 dla akcji;
+// mediana odstępów (1 dzień) nie odróżniłaby akcji od kryptowalut, bo pomija weekendy.
+func periodsPerYear(points []data.Point) float64 {
+	if len(points) < 2 {
+		return 365
+	}
+	years := points[len(points)-1].Date.Sub(points[0].Date).Hours() / 24 / 365
+	if years <= 0 {
+		return 365
+	}
+	return float64(len(points)-1) / years
+}
+
+// backtestResult porównuje strategię opartą na sygnałach z kupnem i trzymaniem
+type backtestResult struct {
+	Return, BuyHoldReturn     float64
+	Sharpe, BuyHoldSharpe     float64
+	MaxDrawdown, BuyHoldMaxDD float64
+	Trades                    int
+}
+
+// backtest symuluje portfel o ekspozycji wyznaczanej przez sygnały. Sygnał z dnia d jest
+// znany dopiero po zamknięciu, więc nowa ekspozycja obowiązuje od zwrotu z d na d+1.
+// fee to koszt transakcji jako ułamek zmiany ekspozycji.
+func backtest(points []data.Point, signals []signal, fee float64) backtestResult {
+	var res backtestResult
+	if len(points) < 2 {
+		return res
+	}
+	exposure := 1.0
+	next := 0
+	strategy := make([]float64, 0, len(points)-1)
+	buyHold := make([]float64, 0, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		cost := 0.0
+		for next < len(signals) && !signals[next].Date.After(points[i-1].Date) {
+			cost += fee * math.Abs(signals[next].Exposure-exposure)
+			exposure = signals[next].Exposure
+			res.Trades++
+			next++
+		}
+		r := points[i].Price/points[i-1].Price - 1
+		strategy = append(strategy, exposure*r-cost)
+		buyHold = append(buyHold, r)
+	}
+
+	periods := periodsPerYear(points)
+	res.Return, res.Sharpe, res.MaxDrawdown = performance(strategy, periods)
+	res.BuyHoldReturn, res.BuyHoldSharpe, res.BuyHoldMaxDD = performance(buyHold, periods)
+	return res
+}
+
+// performance liczy łączny zwrot, annualizowany wskaźnik Sharpe'a (bez stopy wolnej od
+// ryzyka) i maksymalne obsunięcie kapitału z szeregu prostych zwrotów okresowych;
+// periods to liczba okresów w roku
+func performance(returns []float64, periods float64) (total, sharpe, maxDD float64) {
+	equity, peak := 1.0, 1.0
+	for _, r := range returns {
+		equity *= 1 + r
+		peak = math.Max(peak, equity)
+		maxDD = math.Max(maxDD, 1-equity/peak)
+	}
+	if sd := math.Sqrt(stats.Variance(returns)); sd > 0 {
+		sharpe = stats.Mean(returns) / sd * math.Sqrt(periods)
+	}
+	return equity - 1, sharpe, maxDD
+}
@@ -0,0 +1,125 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"cw3/data"
+)
+
+// pricesEvery zwraca ceny notowane co step od 2024-01-01
+func pricesEvery(step time.Duration, prices ...float64) []data.Point {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := make([]data.Point, len(prices))
+	for i, p := range prices {
+		points[i] = data.Point{Date: start.Add(time.Duration(i) * step), Price: p}
+	}
+	return points
+}
+
+func TestBacktest(t *testing.T) {
+	day := 24 * time.Hour
+	// Zwroty +10%, -10%, +20%; wyjście ogłoszone po zamknięciu drugiego dnia omija spadek
+	points := pricesEvery(day, 100, 110, 99, 118.8)
+	exit := []signal{{Date: points[1].Date, Kind: signalExit, Exposure: 0}}
+	tests := []struct {
+		name    string
+		signals []signal
+		fee     float64
+		want    backtestResult
+	}{
+		{
+			name: "bez sygnałów", fee: 0.01,
+			want: backtestResult{Return: 0.188, BuyHoldReturn: 0.188, MaxDrawdown: 0.1, BuyHoldMaxDD: 0.1},
+		},
+		{
+			// 1.1 · (1 - 0.01) · 1 = 1.089; obsunięcie to sama opłata: 1 - 1.089/1.1
+			name: "wyjście z opłatą", signals: exit, fee: 0.01,
+			want: backtestResult{Return: 0.089, BuyHoldReturn: 0.188, MaxDrawdown: 0.01, BuyHoldMaxDD: 0.1, Trades: 1},
+		},
+		{
+			// Sygnał z ostatniego dnia nie ma już zwrotu, na którym mógłby zadziałać
+			name:    "sygnał ostatniego dnia",
+			signals: []signal{{Date: points[3].Date, Kind: signalExit, Exposure: 0}}, fee: 0.01,
+			want: backtestResult{Return: 0.188, BuyHoldReturn: 0.188, MaxDrawdown: 0.1, BuyHoldMaxDD: 0.1},
+		},
+		{
+			name: "ograniczenie bez opłaty", signals: []signal{{Date: points[0].Date, Kind: signalReduce, Exposure: 0.5}},
+			// 1.05 · 0.95 · 1.1
+			want: backtestResult{Return: 1.05*0.95*1.1 - 1, BuyHoldReturn: 0.188, MaxDrawdown: 0.05, BuyHoldMaxDD: 0.1, Trades: 1},
+		},
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := backtest(points, tt.signals, tt.fee)
+			if !near(got.Return, tt.want.Return) || !near(got.BuyHoldReturn, tt.want.BuyHoldReturn) ||
+				!near(got.MaxDrawdown, tt.want.MaxDrawdown) || !near(got.BuyHoldMaxDD, tt.want.BuyHoldMaxDD) || got.Trades != tt.want.Trades {
+				t.Errorf("backtest = %+v, oczekiwano %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeriodsPerYear(t *testing.T) {
+	year := func(step time.Duration) []data.Point {
+		n := int(365*24*time.Hour/step) + 1
+		return pricesEvery(step, make([]float64, n)...)
+	}
+	var weekdays []data.Point
+	for _, p := range year(24 * time.Hour) {
+		if wd := p.Date.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			weekdays = append(weekdays, p)
+		}
+	}
+	tests := []struct {
+		name   string
+		points []data.Point
+		lo, hi float64
+	}{
+		{"dzienne", year(24 * time.Hour), 364.9, 365.1},
+		{"godzinowe", year(time.Hour), 8759, 8761},
+		{"dni robocze", weekdays, 255, 265},
+		{"jedna obserwacja", pricesEvery(time.Hour, 1), 365, 365},
+	}
+	for _, tt := range tests {
+		if got := periodsPerYear(tt.points); got < tt.lo || got > tt.hi {
+			t.Errorf("%s: %.1f okresów w roku, oczekiwano [%g, %g]", tt.name, got, tt.lo, tt.hi)
+		}
+	}
+
+	// Te same zwroty co godzinę dają Sharpe'a większego o pierwiastek z 24
+	prices := []float64{100, 103, 101, 106, 104, 110}
+	daily := backtest(pricesEvery(24*time.Hour, prices...), nil, 0)
+	hourly := backtest(pricesEvery(time.Hour, prices...), nil, 0)
+	if r := hourly.BuyHoldSharpe / daily.BuyHoldSharpe; math.Abs(r-math.Sqrt(24)) > 1e-9 {
+		t.Errorf("stosunek wskaźników Sharpe'a %.4f, oczekiwano %.4f", r, math.Sqrt(24))
+	}
+}
+
+func TestLookAhead(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  cliConfig
+		want int
+	}{
+		{"bez przekształceń", cliConfig{}, 0},
+		{"średnia krocząca", cliConfig{smooth: "ma:5"}, 0},
+		{"kalman", cliConfig{smooth: "kalman"}, 1},
+		{"sezonowość", cliConfig{deseason: []int{7}}, 1},
+		{"etapy potoku", cliConfig{pipeline: []data.StageSpec{
+			{Name: "smooth", Params: map[string]string{"method": "kalman"}},
+			{Name: "smooth", Params: map[string]string{"window": "5"}},
+			{Name: "deseason", Params: map[string]string{"periods": "7"}},
+			{Name: "trim", Params: map[string]string{"from": "2024-01-01"}},
+		}}, 2},
+	}
+	for _, tt := range tests {
+		if got := tt.cfg.lookAhead(); len(got) != tt.want {
+			t.Errorf("%s: lookAhead = %v, oczekiwano %d pozycji", tt.name, got, tt.want)
+		}
+	}
+}
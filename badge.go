@@ -0,0 +1,79 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// badgeCharWidth to przybliżona szerokość znaku fontu 11px Verdana, z której liczona
+// jest szerokość pól odznaki
+const badgeCharWidth = 7
+
+var badgeTemplate = template.Must(template.New("badge").Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="20" role="img" aria-label="{{.Label}}: {{.Value}}">
+<title>{{.Label}}: {{.Value}}</title>
+<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="{{.Width}}" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="{{.LabelWidth}}" height="20" fill="#555"/><rect x="{{.LabelWidth}}" width="{{.ValueWidth}}" height="20" fill="{{.Color}}"/><rect width="{{.Width}}" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="{{.LabelX}}" y="15" fill="#010101" fill-opacity=".3">{{.Label}}</text><text x="{{.LabelX}}" y="14">{{.Label}}</text>
+<text x="{{.ValueX}}" y="15" fill="#010101" fill-opacity=".3">{{.Value}}</text><text x="{{.ValueX}}" y="14">{{.Value}}</text>
+</g></svg>
+`))
+
+// badge to odznaka w stylu shields.io z pewnością bańki ostatniej analizy symbolu
+type badge struct {
+	Label, Value, Color    string
+	LabelWidth, ValueWidth int
+	Width, LabelX, ValueX  float64
+}
+
+// newBadge buduje odznakę "<symbol> LPPLS: <pewność>"; kolor przechodzi od zielonego
+// (brak bańki) do czerwonego (pewność co najmniej 0.5)
+func newBadge(rec runRecord) badge {
+	b := badge{Label: rec.Symbol + " LPPLS", Value: fmt.Sprintf("%.2f", rec.Confidence)}
+	switch {
+	case rec.Confidence >= 0.5:
+		b.Color = "#e05d44"
+	case rec.Confidence >= 0.2:
+		b.Color = "#fe7d37"
+	case rec.Confidence > 0:
+		b.Color = "#dfb317"
+	default:
+		b.Color = "#4c1"
+	}
+	b.LabelWidth = badgeCharWidth*utf8.RuneCountInString(b.Label) + 10
+	b.ValueWidth = badgeCharWidth*utf8.RuneCountInString(b.Value) + 10
+	b.Width = float64(b.LabelWidth + b.ValueWidth)
+	b.LabelX = float64(b.LabelWidth) / 2
+	b.ValueX = float64(b.LabelWidth) + float64(b.ValueWidth)/2
+	return b
+}
+
+// handleBadge zwraca odznakę z pewnością bańki ostatniej analizy symbolu (GET /badge/{symbol}.svg);
+// odpowiedź nie jest buforowana, żeby odznaka w README zmieniała się po każdej analizie
+func (s *apiServer) handleBadge(w http.ResponseWriter, r *http.Request) {
+	symbol, ok := strings.CutSuffix(r.PathValue("file"), ".svg")
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("odznaka jest dostępna jako /badge/{symbol}.svg"))
+		return
+	}
+	history, ok, err := s.history.symbol(symbol)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("brak analiz symbolu %s", symbol))
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "no-cache, max-age=0")
+	w.Header().Set("Last-Modified", history[0].Time.UTC().Format(http.TimeFormat))
+	badgeTemplate.Execute(w, newBadge(history[0]))
+}
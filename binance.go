@@ -0,0 +1,98 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"cw3/data"
+)
+
+// binanceAPI to publiczne API rynku kasowego Binance
+const binanceAPI = "https://api.binance.com"
+
+// binanceKlinesLimit to największa liczba świec zwracana w jednym zapytaniu
+const binanceKlinesLimit = 1000
+
+// binanceIntervals to obsługiwane interwały świec od minutowych do dziennych
+var binanceIntervals = map[string]time.Duration{
+	"1m": time.Minute, "3m": 3 * time.Minute, "5m": 5 * time.Minute, "15m": 15 * time.Minute, "30m": 30 * time.Minute,
+	"1h": time.Hour, "2h": 2 * time.Hour, "4h": 4 * time.Hour, "6h": 6 * time.Hour, "8h": 8 * time.Hour, "12h": 12 * time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+// binanceSource pobiera świece (klines) pary z Binance w zakresie [from, to)
+type binanceSource struct {
+	interval string
+	from, to time.Time
+}
+
+// load zwraca ceny zamknięcia zakończonych świec z datą otwarcia świecy; zakres dłuższy
+// niż binanceKlinesLimit świec jest pobierany w kolejnych zapytaniach
+func (s binanceSource) load(symbol string) ([]data.Point, error) {
+	step, ok := binanceIntervals[s.interval]
+	if !ok {
+		return nil, fmt.Errorf("Binance: nieobsługiwany interwał %q", s.interval)
+	}
+	now := time.Now()
+	var points []data.Point
+	for start := s.from; start.Before(s.to); {
+		var rows [][]json.RawMessage
+		q := url.Values{
+			"symbol":    {symbol},
+			"interval":  {s.interval},
+			"startTime": {strconv.FormatInt(start.UnixMilli(), 10)},
+			"endTime":   {strconv.FormatInt(s.to.UnixMilli()-1, 10)},
+			"limit":     {strconv.Itoa(binanceKlinesLimit)},
+		}
+		if err := getJSON(binanceAPI+"/api/v3/klines?"+q.Encode(), &rows); err != nil {
+			return nil, fmt.Errorf("Binance %s: %w", symbol, err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			p, closed, err := parseKline(row, now)
+			if err != nil {
+				return nil, fmt.Errorf("Binance %s: %w", symbol, err)
+			}
+			if closed && data.ValidPrice(p.Price) {
+				points = append(points, p)
+			}
+		}
+		last, _ := strconv.ParseInt(string(rows[len(rows)-1][0]), 10, 64)
+		start = time.UnixMilli(last).Add(step)
+		if len(rows) < binanceKlinesLimit {
+			break
+		}
+	}
+	return points, nil
+}
+
+// parseKline odczytuje świecę [czas otwarcia, otwarcie, maksimum, minimum, zamknięcie,
+// wolumen, czas zamknięcia, ...]; closed mówi, czy świeca zamknęła się przed now
+func parseKline(row []json.RawMessage, now time.Time) (p data.Point, closed bool, err error) {
+	if len(row) < 7 {
+		return p, false, fmt.Errorf("świeca ma %d pól zamiast co najmniej 7", len(row))
+	}
+	var openTime, closeTime int64
+	var price string
+	if err := json.Unmarshal(row[0], &openTime); err != nil {
+		return p, false, err
+	}
+	if err := json.Unmarshal(row[4], &price); err != nil {
+		return p, false, err
+	}
+	if err := json.Unmarshal(row[6], &closeTime); err != nil {
+		return p, false, err
+	}
+	p.Date = time.UnixMilli(openTime).UTC()
+	if p.Price, err = strconv.ParseFloat(price, 64); err != nil {
+		return p, false, err
+	}
+	return p, time.UnixMilli(closeTime).Before(now), nil
+}
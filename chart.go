@@ -0,0 +1,205 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"cw3/data"
+	"cw3/lppl"
+	"cw3/plot"
+)
+
+// chartCacheSize to liczba wykresów GET /chart zapamiętywanych przez serwer
+const chartCacheSize = 32
+
+// minChartWindow to najmniejsze okno wykresu na żądanie (w obserwacjach)
+const minChartWindow = 10
+
+// chartCache zapamiętuje narysowane wykresy; klucz obejmuje ostatnią analizę symbolu
+// i stan pliku wejściowego, więc nowa analiza lub nowe dane rysują wykres od nowa
+type chartCache struct {
+	mu    sync.Mutex
+	order []string
+	png   map[string][]byte
+}
+
+func (c *chartCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	png, ok := c.png[key]
+	return png, ok
+}
+
+func (c *chartCache) put(key string, png []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.png == nil {
+		c.png = map[string][]byte{}
+	}
+	if _, ok := c.png[key]; ok {
+		return
+	}
+	if len(c.order) == chartCacheSize {
+		delete(c.png, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.order = append(c.order, key)
+	c.png[key] = png
+}
+
+// parseChartWindow czyta długość okna: liczbę dni z przyrostkiem d (np. 120d) albo liczbę
+// obserwacji; pusta wartość oznacza całą historię
+func parseChartWindow(v string) (days, count int, err error) {
+	if v == "" {
+		return 0, 0, nil
+	}
+	if n, ok := strings.CutSuffix(v, "d"); ok {
+		days, err = strconv.Atoi(n)
+		if err != nil || days < 1 {
+			return 0, 0, fmt.Errorf("window %q: oczekiwano dodatniej liczby dni, np. 120d", v)
+		}
+		return days, 0, nil
+	}
+	count, err = strconv.Atoi(v)
+	if err != nil || count < minChartWindow {
+		return 0, 0, fmt.Errorf("window %q: oczekiwano liczby dni (np. 120d) albo co najmniej %d obserwacji", v, minChartWindow)
+	}
+	return 0, count, nil
+}
+
+// windowPoints zwraca końcowe okno szeregu
+func windowPoints(points []data.Point, days, count int) []data.Point {
+	switch {
+	case days > 0:
+		from := points[len(points)-1].Date.AddDate(0, 0, -days)
+		for i, p := range points {
+			if !p.Date.Before(from) {
+				return points[i:]
+			}
+		}
+	case count > 0 && count < len(points):
+		return points[len(points)-count:]
+	}
+	return points
+}
+
+// recordInput wczytuje dane wejścia analizy rec: plik CSV lub Arrow albo wbudowany zbiór.
+// Serwer nie pobiera danych z giełd i serwisów (coingecko:, binance:).
+func (s *apiServer) recordInput(rec runRecord) ([]data.Point, string, error) {
+	if name, ok := strings.CutPrefix(rec.Input, "sample:"); ok {
+		in, err := sampleInput(name)
+		if err != nil {
+			return nil, "", err
+		}
+		points, err := in.load()
+		return points, rec.Input, err
+	}
+	if strings.HasPrefix(rec.Input, "coingecko:") || strings.HasPrefix(rec.Input, "binance:") {
+		return nil, "", fmt.Errorf("%s: serwer nie pobiera danych ze źródeł sieciowych", rec.Input)
+	}
+	info, err := os.Stat(rec.Input)
+	if err != nil {
+		return nil, "", err
+	}
+	// Stan pliku w kluczu pamięci podręcznej, żeby dopisane notowania rysowały nowy wykres
+	state := fmt.Sprintf("%s@%d/%d", rec.Input, info.ModTime().UnixNano(), info.Size())
+	if strings.EqualFold(filepath.Ext(rec.Input), ".arrow") {
+		points, err := data.LoadArrow(rec.Input)
+		return points, state, err
+	}
+	points, _, err := data.Load(rec.Input, s.parse)
+	return points, state, err
+}
+
+// latestRecord zwraca ostatnią analizę symbolu z rejestru albo kod odpowiedzi i błąd
+func (s *apiServer) latestRecord(symbol string) (runRecord, int, error) {
+	history, ok, err := s.history.symbol(symbol)
+	if err != nil {
+		return runRecord{}, http.StatusInternalServerError, err
+	}
+	if !ok {
+		return runRecord{}, http.StatusNotFound, fmt.Errorf("brak analiz symbolu %s", symbol)
+	}
+	return history[0], http.StatusOK, nil
+}
+
+// handleChart rysuje na żądanie wykres dopasowania ostatnich danych symbolu, opcjonalnie
+// w oknie ?window= (np. 120d albo 250 obserwacji). Gdy danych nie da się wczytać, a okna
+// nie podano, zwraca wykres zapisany przez ostatnią analizę.
+func (s *apiServer) handleChart(w http.ResponseWriter, r *http.Request) {
+	symbol, ok := strings.CutSuffix(r.PathValue("file"), ".png")
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("wykres jest dostępny jako /chart/{symbol}.png"))
+		return
+	}
+	window := r.URL.Query().Get("window")
+	days, count, err := parseChartWindow(window)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	rec, code, err := s.latestRecord(symbol)
+	if err != nil {
+		writeError(w, code, err)
+		return
+	}
+	points, state, err := s.recordInput(rec)
+	if err != nil {
+		if window == "" && rec.Chart != "" {
+			if _, serr := os.Stat(rec.Chart); serr == nil {
+				http.ServeFile(w, r, rec.Chart)
+				return
+			}
+		}
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	points = windowPoints(points, days, count)
+	if len(points) < minChartWindow {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Errorf("za mało obserwacji w oknie: %d", len(points)))
+		return
+	}
+
+	key := fmt.Sprintf("%s|%s|%s|%d", rec.Symbol, window, state, rec.Time.UnixNano())
+	png, ok := s.charts.get(key)
+	if !ok {
+		s.renders <- struct{}{}
+		png, err = s.renderChart(rec.Symbol, points, s.opts)
+		<-s.renders
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		s.charts.put(key, png)
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(png)
+}
+
+// renderChart dopasowuje model i zwraca wykres PNG
+func (s *apiServer) renderChart(symbol string, points []data.Point, opts lppl.FitOptions) ([]byte, error) {
+	reply, err := fitReplyFor(points, opts, symbol)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "lppl-chart-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "chart.png")
+	meta := plot.Meta{Symbol: symbol, Start: points[0].Date, End: points[len(points)-1].Date}
+	if err := plot.Results(points, reply.best.Params, plot.Extras{Meta: meta, Footer: reply.Provenance.String()}, path); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(plot.Path(path))
+}
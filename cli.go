@@ -0,0 +1,1249 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"cw3/data"
+	"cw3/datasource"
+	"cw3/fit"
+	"cw3/internal/atomicfile"
+	"cw3/lppl"
+	"cw3/plot"
+)
+
+const defaultInput = "Bitcoin_11.03.2025-10.04.2025_historical_data_coinmarketcap.csv"
+
+type cliConfig struct {
+	opts            lppl.FitOptions
+	minWindow       int
+	maxWindow       int
+	confidencePanel bool
+
+	lagrangeOut   string
+	clusterEps    float64
+	clusterMin    int
+	heatmapPrefix string
+	fractionsOut  string
+	windowStep    int
+	lpplsOut      string
+	lpplsIn       string
+	arrowOut      string
+	curveOut      string
+	htmlOut       string
+	icsOut        string
+	// Etapy potoku przygotowania danych z pliku konfiguracji, wykonywane po wczytaniu
+	pipeline []data.StageSpec
+	// Format wyniku na standardowym wyjściu: text (tylko dziennik) lub json
+	format      string
+	plotOut     string
+	smooth      string
+	deseason    []int
+	hq          bool
+	spectrumOut string
+	diagOut     string
+	regimes     bool
+	prescreen   bool
+	drawups     bool
+	onchain     []string
+	onchainPlot string
+	derivatives string
+	crash       lppl.CrashCalibration
+	bootstrap   lppl.BootstrapOptions
+	signalsOut  string
+	signalRules signalRules
+	backtest    bool
+	fee         float64
+	sizing      sizingRule
+	paper       *paperTrader
+	portfolio   portfolio
+	asset       string
+	events      []plot.Event
+	parse       data.ParseOptions
+	lang        string
+	currency    string
+	dividends   string
+	convertTo   string
+	fxSource    string
+	deflate     string
+	cpiSeries   string
+	// Data odczytu CPI, w którego pieniądzu wyrażone są ceny po -deflate (ustawiana w run)
+	realBase time.Time
+
+	// Przy wielu wejściach nazwy plików wynikowych dostają przyrostek z nazwą wejścia
+	multi bool
+	// Katalog główny wyników (-out-dir) i katalog bieżącego przebiegu (ustawiany w run)
+	outDir string
+	runDir string
+	// Wysyłka plików wynikowych do zasobnika S3/GCS (-upload)
+	upload *uploader
+
+	force    bool
+	registry *runRegistry
+	alerts   *webhookAlerter
+	// Komunikatory, do których trafia podsumowanie dopasowania z wykresem
+	notifiers []notifier
+}
+
+// input to jeden szereg do przetworzenia: plik CSV, plik Arrow albo symbol z wtyczki
+type input struct {
+	name string
+	// Symbol aktywa, jeśli znany ze źródła danych
+	symbol string
+	load   func() ([]data.Point, error)
+}
+
+// stageError opisuje błąd jednego etapu przetwarzania wejścia
+type stageError struct {
+	input string
+	stage string
+	err   error
+}
+
+func (e *stageError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", e.input, e.stage, e.err)
+}
+
+func (e *stageError) Unwrap() error {
+	return e.err
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "compare" {
+		if err := runCompare(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "backtest" {
+		if err := runBacktest(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		if err := runServe(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "stream" {
+		if err := runStream(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "grid" {
+		if err := runGrid(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "coordinator" {
+		if err := runCoordinator(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "worker" {
+		if err := runWorker(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "site" {
+		if err := runSite(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	cfg := cliConfig{opts: lppl.DefaultFitOptions(), parse: data.DefaultParseOptions(), crash: lppl.DefaultCrashCalibration(), bootstrap: lppl.DefaultBootstrapOptions(), signalRules: defaultSignalRules()}
+	opts := &cfg.opts
+	fitFlags(flag.CommandLine, opts)
+	flag.StringVar(&cfg.lagrangeOut, "lagrange", "", "wyznacz początek okna t1 regularyzacją Lagrange'a i zapisz profil kosztu do pliku CSV")
+	flag.IntVar(&cfg.minWindow, "min-window", 15, "minimalna liczba obserwacji w oknie przy wyborze t1")
+	flag.IntVar(&cfg.maxWindow, "max-window", 0, "maksymalna liczba obserwacji w oknie wskaźnika pewności bańki (0 - pełna historia)")
+	flag.BoolVar(&cfg.confidencePanel, "confidence-panel", false, "dodaj pod wykresem dopasowania panele wskaźnika pewności bańki dodatniej i ujemnej")
+	flag.Float64Var(&cfg.clusterEps, "cluster-eps", 0, "pogrupuj estymaty tc z wielu okien algorytmem DBSCAN o promieniu eps (w dniach)")
+	flag.IntVar(&cfg.clusterMin, "cluster-min", 3, "minimalna liczba estymat tworząca klaster tc")
+	flag.StringVar(&cfg.heatmapPrefix, "heatmap", "", "zapisz mapy stabilności m i omega po siatce (t1, t2) jako <prefiks>_m.png i <prefiks>_omega.png")
+	flag.StringVar(&cfg.fractionsOut, "fractions", "", "zapisz dzienny udział kwalifikowanych dopasowań do pliku CSV lub JSON (wg rozszerzenia)")
+	flag.IntVar(&cfg.windowStep, "window-step", 1, "krok długości okna (w obserwacjach) przy liczeniu udziału kwalifikowanych dopasowań")
+	flag.IntVar(&cfg.bootstrap.Samples, "bootstrap", 0, "wyznacz przedziały ufności parametrów z podanej liczby dopasowań blokowego bootstrapu reszt")
+	flag.IntVar(&cfg.bootstrap.BlockLength, "bootstrap-block", 0, "długość bloku reszt w bootstrapie (0 - pierwiastek sześcienny z liczby obserwacji)")
+	flag.Float64Var(&cfg.bootstrap.Level, "bootstrap-level", cfg.bootstrap.Level, "poziom ufności przedziałów z bootstrapu")
+	flag.StringVar(&cfg.lpplsOut, "lppls-out", "", "zapisz dopasowania w kurczących się oknach w formacie pakietu lppls (JSON)")
+	flag.StringVar(&cfg.lpplsIn, "lppls-in", "", "wczytaj wyniki pakietu lppls (JSON) i oceń je na bieżących danych")
+	sampleList := flag.String("sample", "", "analizuj wbudowane zbiory danych (oddzielone przecinkami): "+sampleNames())
+	arrowIn := flag.String("arrow-in", "", "wczytaj szereg z pliku Arrow IPC/Feather zamiast z CSV")
+	flag.StringVar(&cfg.icsOut, "ics", "", "zapisz okno krytyczne (tc z przedziałem ufności -bootstrap) jako wydarzenie w pliku iCalendar")
+	flag.StringVar(&cfg.format, "format", formatText, "format wyniku na standardowym wyjściu: text (tylko dziennik na stderr) lub json (dokument z parametrami i diagnostyką w jednym wierszu na szereg)")
+	flag.StringVar(&cfg.htmlOut, "html", "", "zapisz interaktywny wykres danych i modelu (przybliżanie, podpowiedzi, przełącznik krzywej) jako stronę HTML")
+	flag.StringVar(&cfg.curveOut, "curve-out", "", "zapisz CSV z ceną, wartością modelu i resztą dla każdej obserwacji oraz modelem ekstrapolowanym do tc")
+	flag.StringVar(&cfg.arrowOut, "arrow-out", "", "zapisz dane, wartości modelu i reszty do pliku Arrow IPC/Feather")
+	grpcAddr := flag.String("grpc", "", "uruchom serwer gRPC (usługa lppl.LPPL) pod wskazanym adresem, np. :50051")
+	pluginPath := flag.String("plugin", "", "pobierz dane z zewnętrznej wtyczki źródła danych (plik wykonywalny go-plugin)")
+	coingeckoIDs := flag.String("coingecko", "", "pobierz historię cen monet o podanych identyfikatorach CoinGecko (np. bitcoin,ethereum) zamiast czytać CSV")
+	var cg coingeckoSource
+	binanceSymbols := flag.String("binance", "", "pobierz świece par Binance (np. BTCUSDT,ETHUSDT) zamiast czytać CSV")
+	var bn binanceSource
+	flag.StringVar(&bn.interval, "binance-interval", "1d", "interwał świec z Binance: 1m, 3m, 5m, 15m, 30m, 1h, 2h, 4h, 6h, 8h, 12h lub 1d")
+	flag.Func("from", "początek zakresu dat dla -coingecko i -binance (RRRR-MM-DD; domyślnie rok przed -to, dla -binance 1000 świec)", func(v string) error {
+		t, err := time.Parse(time.DateOnly, v)
+		cg.from = t
+		return err
+	})
+	flag.Func("to", "koniec zakresu dat dla -coingecko i -binance, włącznie (RRRR-MM-DD; domyślnie dziś)", func(v string) error {
+		t, err := time.Parse(time.DateOnly, v)
+		cg.to = t
+		return err
+	})
+	flag.StringVar(&cg.interval, "cg-interval", "daily", "interwał danych z CoinGecko: daily lub pusty (godzinowy dla zakresów do 90 dni)")
+	flag.StringVar(&cg.cacheDir, "cache-dir", filepath.Join(defaultCacheDir(), "coingecko"), "katalog pamięci podręcznej odpowiedzi CoinGecko (pusty wyłącza)")
+	flag.Func("rate-limit", "limity zapytań do dostawców danych: dostawca.klucz=wartość rozdzielone przecinkami, np. coingecko.rpm=10,binance.concurrency=2 (klucze: concurrency, rpm, retries, backoff)", parseRateLimits)
+	symbols := flag.String("symbol", "BTC", "symbole (oddzielone przecinkami) przekazywane do wtyczki źródła danych")
+	inputPath := flag.String("input", "", "plik CSV z notowaniami (równoważne podaniu ścieżki jako argumentu)")
+	flag.StringVar(&cfg.plotOut, "output", "bitcoin_lppl.png", "plik wykresu dopasowania")
+	flag.StringVar(&plot.Output.Format, "plot-format", "", "format wszystkich wykresów: png, svg, pdf, jpg, tif lub eps; zmienia rozszerzenie plików (domyślnie format z rozszerzenia)")
+	flag.Float64Var(&plot.Output.Width, "plot-width", 0, "szerokość wykresów w calach (0 - wymiar domyślny danego wykresu)")
+	flag.Float64Var(&plot.Output.Height, "plot-height", 0, "wysokość wykresów w calach, bez stopki (0 - wymiar domyślny danego wykresu)")
+	flag.IntVar(&plot.Output.DPI, "dpi", 0, "rozdzielczość wykresów PNG, JPG i TIF (0 - 96 DPI)")
+	flag.BoolVar(&plot.Output.LogScale, "log-scale", false, "logarytmiczna oś cen na wykresach dopasowania i porównania (model jest dopasowywany do ln(ceny))")
+	flag.StringVar(&cfg.outDir, "out-dir", "", "zapisuj wyniki o względnych ścieżkach w <katalog>/<symbol>/<czas UTC>/ i ustawiaj dowiązanie <katalog>/<symbol>/"+latestLink+" na ostatni udany przebieg")
+	csvFlags(flag.CommandLine, &cfg.parse)
+	chartFlags(flag.CommandLine, &cfg.lang, &cfg.currency)
+	flag.StringVar(&cfg.dividends, "dividends", "", "przed analizą zbuduj szereg całkowitej stopy zwrotu z dywidendami z pliku CSV data,dywidenda na akcję (w walucie notowań)")
+	flag.StringVar(&cfg.convertTo, "convert-to", "", "przelicz ceny z waluty -currency na podaną (np. EUR, PLN) przed analizą")
+	flag.StringVar(&cfg.fxSource, "fx", fxSourceAPI, "źródło kursów dla -convert-to: "+fxSourceAPI+" (kursy EBC z api.frankfurter.app) lub plik CSV data,kurs")
+	flag.StringVar(&cfg.deflate, "deflate", "", "przed analizą zamień ceny na realne (w pieniądzu z okresu ostatniej obserwacji) wskaźnikiem CPI: "+cpiSourceFRED+" (pobierz szereg -cpi-series z FRED) lub plik CSV data,CPI")
+	flag.StringVar(&cfg.cpiSeries, "cpi-series", "CPIAUCSL", "identyfikator szeregu CPI w FRED dla -deflate "+cpiSourceFRED)
+	flag.Func("deseason", "usuń przed dopasowaniem sezonowość o podanych okresach w dniach (np. 7,365) dekompozycją w stylu STL z całej historii (nie łączy się z -backtest, -signals i -paper)", func(v string) error {
+		periods, err := data.ParsePeriods(v)
+		cfg.deseason = periods
+		return err
+	})
+	flag.StringVar(&cfg.smooth, "smooth", "", "wygładź ceny przed dopasowaniem: ma:N (średnia krocząca z N poprzednich obserwacji) lub kalman (dwustronny wygładzacz modelu lokalnego poziomu; nie łączy się z -backtest, -signals i -paper)")
+	flag.BoolVar(&cfg.hq, "hq", false, "potwierdź omega nieparametryczną analizą (H,q) oscylacji log-periodycznych")
+	flag.StringVar(&cfg.diagOut, "diagnostics", "", "zapisz wykres diagnostyczny reszt: reszty w czasie, histogram i wykres kwantyl-kwantyl względem rozkładu normalnego")
+	flag.StringVar(&cfg.spectrumOut, "spectrum", "", "zapisz periodogramy reszt w czasie liniowym i ln(tc-t) jako <prefiks>_time.png i <prefiks>_logtime.png")
+	flag.BoolVar(&cfg.regimes, "regimes", false, "oszacuj dwustanowy model przełączania reżimów na stopach zwrotu i podaj prawdopodobieństwo reżimu ponadwykładniczego")
+	flag.BoolVar(&cfg.prescreen, "prescreen", false, "przed dopasowaniem LPPL sprawdź kroczącym testem (okna -min-window co -window-step), czy wzrost jest ponadwykładniczy; bez tego pomiń wejście")
+	flag.BoolVar(&cfg.drawups, "drawups", false, "wykryj nietypowe wzrosty (drawupy) i wybierz początek okna spośród ich początków")
+	flag.Func("onchain", "metryki sieci z api.blockchain.info (np. n-unique-addresses,hash-rate) korelowane z ceną w oknie dopasowania", func(v string) error {
+		cfg.onchain = strings.Split(v, ",")
+		return nil
+	})
+	flag.StringVar(&cfg.onchainPlot, "onchain-plot", "", "zapisz wykresy metryk sieci jako <prefiks>_<metryka>.png")
+	flag.StringVar(&cfg.derivatives, "derivatives", "", "dołącz do wykresu panele stóp finansowania i otwartych pozycji kontraktu wieczystego Binance, np. BTCUSDT")
+	flag.Float64Var(&cfg.crash.Kappa, "crash-kappa", cfg.crash.Kappa, "ułamek wzrostu bańki znoszony przez krach (kalibracja historyczna)")
+	flag.Float64Var(&cfg.crash.KappaSD, "crash-kappa-sd", cfg.crash.KappaSD, "odchylenie standardowe -crash-kappa, wyznacza przedział niepewności")
+	flag.StringVar(&cfg.signalsOut, "signals", "", "zapisz sygnały reduce/exit/reenter wyznaczone z udziału kwalifikowanych dopasowań do pliku CSV")
+	flag.Float64Var(&cfg.signalRules.Reduce, "signal-reduce", cfg.signalRules.Reduce, "udział dopasowań bańki dodatniej, od którego ograniczana jest ekspozycja")
+	flag.Float64Var(&cfg.signalRules.Exit, "signal-exit", cfg.signalRules.Exit, "udział dopasowań bańki dodatniej, od którego pozycja jest zamykana")
+	flag.Float64Var(&cfg.signalRules.Reenter, "signal-reenter", cfg.signalRules.Reenter, "udział dopasowań bańki dodatniej, do którego musi spaść, by wrócić do pełnej pozycji")
+	flag.BoolVar(&cfg.backtest, "backtest", false, "przetestuj strategię opartą na sygnałach na historii i porównaj z kupnem i trzymaniem")
+	flag.Float64Var(&cfg.fee, "fee", 0.001, "koszt transakcji w backteście jako ułamek zmiany ekspozycji")
+	sizingMin := flag.Float64("sizing-min", 0, "minimalna ekspozycja zwracana przez -sizing")
+	flag.Func("sizing", "podaj sugerowaną ekspozycję na podstawie wskaźnika pewności bańki: linear:K, exp:K lub step:próg", func(v string) error {
+		rule, err := parseSizing(v)
+		cfg.sizing = rule
+		return err
+	})
+	paperSymbol := flag.String("paper", "", "przekaż sygnały z ostatniego dnia jako zlecenia w sieci testowej Binance dla podanej pary, np. BTCUSDT")
+	paperNotional := flag.Float64("paper-notional", 1000, "wartość pozycji przy pełnej ekspozycji w handlu testowym (w walucie kwotowanej)")
+	paperLog := flag.String("paper-log", "paper_trades.jsonl", "dziennik wykonań zleceń testowych (wiersze JSON)")
+	portfolioPath := flag.String("portfolio", "", "plik z pozycjami portfela (CSV symbol,value lub JSON); ważność alertów skalowana jest udziałem aktywa")
+	flag.StringVar(&cfg.asset, "asset", "", "symbol aktywa w portfelu dla wejść z plików (domyślnie nazwa wejścia)")
+	eventsPath := flag.String("events", "", "plik CSV z wydarzeniami (data,opis) zaznaczanymi na wykresie i wypisywanymi w raporcie")
+	runsFile := flag.String("runs-file", ".lppl_runs.json", "plik z rejestrem wykonanych analiz (pusty wyłącza ochronę przed powtórzeniami)")
+	uploadTarget := flag.String("upload", "", "wyślij pliki wynikowe do s3://zasobnik/szablon lub gs://zasobnik/szablon; szablon klucza może zawierać {symbol}, {input}, {date}, {time} i {name} (domyślnie "+defaultUploadKey+")")
+	uploadEndpoint := flag.String("upload-endpoint", "", "własny punkt dostępu zgodny z S3 dla -upload (np. MinIO); domyślnie AWS S3 lub "+gcsEndpoint+" dla gs://")
+	flag.BoolVar(&atomicfile.Overwrite, "overwrite", false, "zastępuj istniejące pliki wynikowe (domyślnie zapis do istniejącego pliku kończy się błędem)")
+	var network netConfig
+	netFlags(flag.CommandLine, &network)
+	var alerter webhookAlerter
+	alertFlags(flag.CommandLine, &alerter)
+	var notify notifyConfig
+	notifyFlags(flag.CommandLine, &notify)
+	budget := flag.Duration("budget", 0, "łączny czas przebiegu dla wszystkich wejść (np. 2h); dzieli go według -priority, dobierając liczbę startów i gęstość okien, a po jego wyczerpaniu pomija pozostałe wejścia (0 wyłącza)")
+	refitEvery := flag.String("refit-every", "", "odstępy ponownych analiz symboli przy częstszych przebiegach z crona: symbol=czas oddzielone przecinkami, * dla pozostałych, np. BTC=1h,*=24h (wymaga -runs-file)")
+	stagger := flag.Duration("stagger", 0, "przerwa przed każdym kolejnym wejściem, rozkładająca zapytania do dostawców danych w czasie (np. 5s)")
+	priorities := flag.String("priority", "", "priorytety wejść dla -budget: symbol=waga oddzielone przecinkami, np. BTC=3,ETH=2 (pozostałe mają wagę 1)")
+	flag.BoolVar(&cfg.force, "force", false, "wykonaj analizę nawet wtedy, gdy identyczne dane i konfiguracja były już przetworzone")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Użycie: %s [flagi] [plik.csv ...]\n       %s compare -a a.json -b b.json [flagi] [plik.csv]\n", os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.String("config", "", "plik YAML z ustawieniami (klucze jak nazwy flag); flagi z wiersza poleceń mają pierwszeństwo")
+	var configured []string
+	if path := configPath(os.Args[1:]); path != "" {
+		cf, err := applyConfig(flag.CommandLine, path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		configured, cfg.pipeline = cf.inputs, cf.pipeline
+	}
+	flag.Parse()
+	if err := network.apply(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := checkFitOptions(*opts); err != nil {
+		log.Fatal(err)
+	}
+	if cfg.bootstrap.Level <= 0 || cfg.bootstrap.Level >= 1 {
+		log.Fatalf("poziom ufności -bootstrap-level musi należeć do (0, 1), podano %g", cfg.bootstrap.Level)
+	}
+
+	if _, err := data.Smooth(nil, cfg.smooth); err != nil {
+		log.Fatal(err)
+	}
+	if err := cfg.signalRules.validate(); err != nil {
+		log.Fatal(err)
+	}
+	// Sygnał z dnia d nie może zależeć od cen po d, a wygładzacz dwustronny i sezonowość
+	// z całej historii wnoszą je do każdego okna
+	if ahead := cfg.lookAhead(); len(ahead) > 0 && (cfg.backtest || cfg.signalsOut != "" || *paperSymbol != "") {
+		log.Fatalf("-backtest, -signals i -paper nie łączą się z przekształceniami korzystającymi z późniejszych notowań: %s (użyj -smooth ma:N)", strings.Join(ahead, ", "))
+	}
+	cfg.sizing.Min = *sizingMin
+	if *eventsPath != "" {
+		events, err := plot.LoadEvents(*eventsPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.events = events
+	}
+	if *portfolioPath != "" {
+		p, err := loadPortfolio(*portfolioPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.portfolio = p
+	}
+	if *paperSymbol != "" {
+		trader, err := newPaperTrader(*paperSymbol, *paperNotional, *paperLog)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.paper = trader
+	}
+
+	if *grpcAddr != "" {
+		log.Fatal(serveGRPC(*grpcAddr, cfg.opts, cfg.minWindow))
+	}
+
+	var inputs []input
+	if *pluginPath != "" {
+		for _, s := range strings.Split(*symbols, ",") {
+			req := datasource.Request{Symbol: strings.TrimSpace(s)}
+			inputs = append(inputs, input{name: req.Symbol, symbol: req.Symbol, load: func() ([]data.Point, error) {
+				return loadPlugin(*pluginPath, req)
+			}})
+		}
+	}
+	if *coingeckoIDs != "" {
+		if cg.to.IsZero() {
+			cg.to = time.Now().UTC().Truncate(24 * time.Hour)
+		}
+		if cg.from.IsZero() {
+			cg.from = cg.to.AddDate(-1, 0, 0)
+		}
+		if !cg.from.Before(cg.to) {
+			log.Fatalf("nieprawidłowy zakres dat: %s - %s", cg.from.Format(time.DateOnly), cg.to.Format(time.DateOnly))
+		}
+		cg.vs = strings.ToLower(cfg.currency)
+		for _, id := range strings.Split(*coingeckoIDs, ",") {
+			id = strings.TrimSpace(id)
+			inputs = append(inputs, input{name: "coingecko:" + id, symbol: strings.ToUpper(id), load: func() ([]data.Point, error) {
+				points, err := cg.load(id)
+				if err == nil && len(points) > 0 {
+					log.Printf("CoinGecko %s: %d notowań (%s - %s)", id, len(points),
+						points[0].Date.Format(time.DateOnly), points[len(points)-1].Date.Format(time.DateOnly))
+				}
+				return points, err
+			}})
+		}
+	}
+	if *binanceSymbols != "" {
+		step, ok := binanceIntervals[bn.interval]
+		if !ok {
+			log.Fatalf("nieobsługiwany interwał Binance: %s", bn.interval)
+		}
+		bn.to = time.Now().UTC()
+		if !cg.to.IsZero() {
+			bn.to = cg.to.Add(24 * time.Hour)
+		}
+		bn.from = cg.from
+		if bn.from.IsZero() {
+			bn.from = bn.to.Add(-binanceKlinesLimit * step)
+		}
+		if !bn.from.Before(bn.to) {
+			log.Fatalf("nieprawidłowy zakres dat: %s - %s", bn.from.Format(time.DateOnly), bn.to.Format(time.DateOnly))
+		}
+		for _, symbol := range strings.Split(*binanceSymbols, ",") {
+			symbol = strings.ToUpper(strings.TrimSpace(symbol))
+			inputs = append(inputs, input{name: "binance:" + symbol, symbol: symbol, load: func() ([]data.Point, error) {
+				points, err := bn.load(symbol)
+				if err == nil && len(points) > 0 {
+					log.Printf("Binance %s: %d świec %s (%s - %s)", symbol, len(points), bn.interval,
+						points[0].Date.Format(time.DateTime), points[len(points)-1].Date.Format(time.DateTime))
+				}
+				return points, err
+			}})
+		}
+	}
+	if *sampleList != "" {
+		for _, name := range strings.Split(*sampleList, ",") {
+			in, err := sampleInput(strings.TrimSpace(name))
+			if err != nil {
+				log.Fatal(err)
+			}
+			inputs = append(inputs, in)
+		}
+	}
+	if *arrowIn != "" {
+		inputs = append(inputs, input{name: *arrowIn, load: func() ([]data.Point, error) {
+			return data.LoadArrow(*arrowIn)
+		}})
+	}
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = configured
+	}
+	if *inputPath != "" {
+		paths = append([]string{*inputPath}, paths...)
+	}
+	if len(inputs) == 0 && len(paths) == 0 {
+		if network.offline {
+			in, _ := sampleInput(samples[0].name)
+			log.Printf("Tryb offline: analiza wbudowanego zbioru %s", samples[0].name)
+			inputs = append(inputs, in)
+		} else {
+			paths = []string{defaultInput}
+		}
+	}
+	for _, path := range paths {
+		inputs = append(inputs, input{name: path, load: func() ([]data.Point, error) {
+			points, report, err := data.Load(path, cfg.parse)
+			if err == nil {
+				log.Printf("%s: %s", path, report)
+			}
+			return points, err
+		}})
+	}
+	cfg.multi = len(inputs) > 1
+
+	if *uploadTarget != "" {
+		u, err := newUploader(*uploadTarget, *uploadEndpoint)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.upload = u
+	}
+
+	if err := checkFormat(cfg.format); err != nil {
+		log.Fatal(err)
+	}
+	if plot.Output.Format != "" {
+		if err := plot.CheckFormat(plot.Output.Format); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if alerter.url != "" {
+		if err := alerter.load(); err != nil {
+			log.Fatal(err)
+		}
+		cfg.alerts = &alerter
+	}
+	if n, err := notify.notifiers(); err != nil {
+		log.Fatal(err)
+	} else {
+		cfg.notifiers = n
+	}
+	if *runsFile != "" {
+		registry, err := openRegistry(*runsFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.registry = registry
+	}
+
+	var notDue int
+	if *refitEvery != "" {
+		every, err := parseIntervals(*refitEvery)
+		if err != nil {
+			log.Fatalf("-refit-every: %v", err)
+		}
+		if cfg.registry == nil {
+			log.Fatal("-refit-every wymaga rejestru analiz -runs-file")
+		}
+		plan, now := refitPlan{every: every}, time.Now()
+		due := inputs[:0]
+		for _, in := range inputs {
+			if ok, next := plan.due(cfg.registry, in, cfg.runName(in), now); !ok {
+				log.Printf("Harmonogram: pominięto %s - następna analiza po %s", in.name, next.Format(time.DateTime))
+				notDue++
+				continue
+			}
+			due = append(due, in)
+		}
+		inputs = due
+	}
+
+	var sched *budgetScheduler
+	if *budget > 0 {
+		priority, err := parsePriorities(*priorities)
+		if err != nil {
+			log.Fatalf("-priority: %v", err)
+		}
+		sched = &budgetScheduler{budget: *budget, priority: priority}
+		sched.order(inputs)
+	}
+
+	var failed, skipped int
+	var ran bool
+	for i, in := range inputs {
+		if ran && *stagger > 0 {
+			time.Sleep(*stagger)
+		}
+		c, scale := cfg, 1.0
+		if sched != nil {
+			var ok bool
+			if c, scale, ok = sched.plan(cfg, inputs[i:]); !ok {
+				log.Printf("Budżet: pominięto %s - wyczerpany czas przebiegu", in.name)
+				skipped++
+				continue
+			}
+		}
+		started := time.Now()
+		ran = true
+		errs := c.run(in)
+		if sched != nil {
+			sched.done(scale, time.Since(started))
+		}
+		for _, err := range errs {
+			log.Printf("Błąd: %v", err)
+		}
+		if len(errs) > 0 {
+			failed++
+		}
+	}
+	if cfg.multi {
+		log.Printf("Zakończono: %d z %d wejść bez błędów", len(inputs)-failed-skipped, len(inputs))
+	}
+	if notDue > 0 {
+		log.Printf("Pominięto %d wejść przed upływem -refit-every", notDue)
+	}
+	if skipped > 0 {
+		log.Printf("Pominięto %d wejść po przekroczeniu -budget", skipped)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// chartFlags rejestruje flagi opisów wykresów
+func chartFlags(fs *flag.FlagSet, lang, currency *string) {
+	*lang = "pl"
+	fs.Func("lang", "język tytułów i opisów wykresów ("+plot.LanguageNames()+"; domyślnie pl)", func(v string) error {
+		if _, ok := plot.Languages[v]; !ok {
+			return fmt.Errorf("nieznany język %q", v)
+		}
+		*lang = v
+		return nil
+	})
+	fs.StringVar(currency, "currency", "USD", "waluta notowań w pliku wejściowym, podawana w opisie osi wykresu")
+}
+
+// fitFlags rejestruje flagi ustawień dopasowania lppl.FitOptions
+func fitFlags(fs *flag.FlagSet, opts *lppl.FitOptions) {
+	fs.Float64Var(&opts.TcMinFrac, "tc-min", opts.TcMinFrac, "dolna granica tc jako ułamek długości okna za ostatnią obserwacją")
+	fs.Float64Var(&opts.TcMaxFrac, "tc-max", opts.TcMaxFrac, "górna granica tc jako ułamek długości okna za ostatnią obserwacją")
+	fs.Float64Var(&opts.Bounds.M[0], "m-min", opts.Bounds.M[0], "dolna granica m w optymalizacji")
+	fs.Float64Var(&opts.Bounds.M[1], "m-max", opts.Bounds.M[1], "górna granica m w optymalizacji")
+	fs.Float64Var(&opts.Bounds.Omega[0], "omega-min", opts.Bounds.Omega[0], "dolna granica omega w optymalizacji")
+	fs.Float64Var(&opts.Bounds.Omega[1], "omega-max", opts.Bounds.Omega[1], "górna granica omega w optymalizacji")
+	fs.Float64Var(&opts.PenaltyC, "penalty-c", opts.PenaltyC, "waga kary za |C| powyżej -c-limit")
+	fs.Float64Var(&opts.CLimit, "c-limit", opts.CLimit, "próg |C|, powyżej którego naliczana jest kara")
+	fs.Float64Var(&opts.PenaltyOmega, "penalty-omega", opts.PenaltyOmega, "waga kary za odchylenie omega od -prior-omega")
+	fs.Float64Var(&opts.PriorOmega, "prior-omega", opts.PriorOmega, "omega z poprzedniego dopasowania (0 wyłącza karę)")
+	fs.Func("filters", "zestaw progów kwalifikacji ("+lppl.FilterPresetNames()+"); flagi -filter-* podane po nim nadpisują progi", func(name string) error {
+		fc, ok := lppl.FilterPresets[name]
+		if !ok {
+			return fmt.Errorf("nieznany zestaw filtrów %q", name)
+		}
+		opts.Filters = fc
+		return nil
+	})
+	fs.Float64Var(&opts.Filters.MinM, "filter-m-min", opts.Filters.MinM, "minimalne m dopasowania kwalifikowanego")
+	fs.Float64Var(&opts.Filters.MaxM, "filter-m-max", opts.Filters.MaxM, "maksymalne m dopasowania kwalifikowanego")
+	fs.Float64Var(&opts.Filters.MinOmega, "filter-omega-min", opts.Filters.MinOmega, "minimalne omega dopasowania kwalifikowanego")
+	fs.Float64Var(&opts.Filters.MaxOmega, "filter-omega-max", opts.Filters.MaxOmega, "maksymalne omega dopasowania kwalifikowanego")
+	fs.Float64Var(&opts.Filters.MinDamping, "filter-damping", opts.Filters.MinDamping, "minimalne tłumienie m|B|/(omega|C|) (0 wyłącza)")
+	fs.Float64Var(&opts.Filters.MinOscillations, "filter-oscillations", opts.Filters.MinOscillations, "minimalna liczba oscylacji w oknie (0 wyłącza)")
+	fs.Float64Var(&opts.Filters.MaxRelError, "filter-rel-error", opts.Filters.MaxRelError, "maksymalny względny błąd dopasowania ceny (0 wyłącza)")
+	fs.IntVar(&opts.MinDOF, "min-dof", opts.MinDOF, "minimalna liczba stopni swobody (obserwacje minus 7 parametrów) w dopasowywanym oknie")
+	fs.StringVar(&opts.BeyondTc, "beyond-tc", opts.BeyondTc, "obserwacje z t >= tc: exclude (pomiń), penalty (kara kwadratowa) lub clamp (model = A)")
+	fs.Float64Var(&opts.BeyondTcWeight, "beyond-tc-weight", opts.BeyondTcWeight, "waga kary dla -beyond-tc penalty, mnożona przez sumę kwadratów odległości obserwacji za tc od tc w długościach okna (porównywalna z kwadratami reszt log-ceny)")
+	fs.IntVar(&opts.VolWindow, "vol-window", opts.VolWindow, "waż reszty odwrotnością lokalnej wariancji z tylu ostatnich stóp zwrotu (0 wyłącza)")
+	fs.IntVar(&opts.MaxIterations, "max-iter", opts.MaxIterations, "limit iteracji pojedynczego przebiegu optymalizatora")
+	fs.StringVar(&opts.Method, "method", opts.Method, "metoda optymalizacji: "+lppl.MethodNelderMead+" (lokalna z każdego startu) lub "+lppl.MethodCMAES+" (globalna CMA-ES dopracowana metodą Nelder-Mead)")
+	fs.IntVar(&opts.RandomStarts, "random-starts", opts.RandomStarts, "liczba dodatkowych startów optymalizacji z losowych punktów (tc, m, omega)")
+	fs.Int64Var(&opts.Seed, "seed", opts.Seed, "ziarno losowania punktów startowych i próbek bootstrapu")
+	fs.IntVar(&opts.Workers, "workers", opts.Workers, "liczba równoległych optymalizacji (0 - liczba procesorów)")
+	fs.Func("start-m", "wartości startowe m oddzielone przecinkami (domyślnie 0.3,0.7)", func(v string) error {
+		values, err := parseFloats(v)
+		opts.StartM = values
+		return err
+	})
+	fs.Func("start-omega", "wartości startowe omega oddzielone przecinkami (domyślnie 6,8,10,12)", func(v string) error {
+		values, err := parseFloats(v)
+		opts.StartOmega = values
+		return err
+	})
+}
+
+// parseFloats odczytuje listę liczb oddzielonych przecinkami
+func parseFloats(s string) ([]float64, error) {
+	var values []float64
+	for _, f := range strings.Split(s, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+		if err != nil {
+			return nil, fmt.Errorf("nieprawidłowa liczba %q", f)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// csvFlags rejestruje flagi formatu wejściowego pliku CSV
+func csvFlags(fs *flag.FlagSet, popts *data.ParseOptions) {
+	fs.IntVar(&popts.DateColumn, "date-col", popts.DateColumn, "numer kolumny z datą w pliku CSV (od 0)")
+	fs.IntVar(&popts.PriceColumn, "price-col", popts.PriceColumn, "numer kolumny z ceną w pliku CSV (od 0)")
+	fs.Func("delimiter", "separator pól w pliku CSV: pojedynczy znak lub \\t (domyślnie ;)", func(v string) (err error) {
+		popts.Delimiter, err = parseDelimiter(v)
+		return err
+	})
+	fs.BoolVar(&popts.Strict, "strict", false, "przerwij wczytywanie CSV na pierwszym błędnym wierszu (domyślnie wiersze są pomijane i podsumowywane)")
+	fs.Func("date-formats", "lista formatów daty (układ Go, unix, unixms) oddzielonych przecinkami, próbowanych po kolei", func(v string) error {
+		popts.DateLayouts = strings.Split(v, ",")
+		return nil
+	})
+}
+
+// parseDelimiter odczytuje separator pól CSV: pojedynczy znak lub \t
+func parseDelimiter(v string) (rune, error) {
+	if v == `\t` {
+		v = "\t"
+	}
+	r := []rune(v)
+	if len(r) != 1 || r[0] == '"' || r[0] == '\r' || r[0] == '\n' {
+		return 0, fmt.Errorf("separator musi być pojedynczym znakiem, podano %q", v)
+	}
+	return r[0], nil
+}
+
+// outputFor zwraca ścieżkę pliku wynikowego. Względne ścieżki trafiają do katalogu
+// przebiegu, jeśli podano -out-dir; bez niego przy wielu wejściach dokleja nazwę wejścia.
+func (c cliConfig) outputFor(path, inputName string) string {
+	if c.runDir != "" && path != "" && !filepath.IsAbs(path) {
+		return filepath.Join(c.runDir, path)
+	}
+	if !c.multi || path == "" {
+		return path
+	}
+	base := strings.TrimSuffix(filepath.Base(inputName), filepath.Ext(inputName))
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "_" + base + ext
+}
+
+// quoteCurrency zwraca walutę cen po ewentualnym przeliczeniu
+func (c cliConfig) quoteCurrency() string {
+	if c.convertTo != "" {
+		return c.convertTo
+	}
+	return c.currency
+}
+
+// chartMeta opisuje okno points wejścia in na potrzeby tytułów i osi wykresów
+func (c cliConfig) chartMeta(in input, points []data.Point) plot.Meta {
+	meta := plot.Meta{
+		Symbol:   in.symbol,
+		Source:   filepath.Base(in.name),
+		Currency: c.quoteCurrency(),
+		Lang:     c.lang,
+		RealBase: c.realBase,
+	}
+	if c.asset != "" {
+		meta.Symbol = c.asset
+	}
+	if len(points) > 0 {
+		meta.Start, meta.End = points[0].Date, points[len(points)-1].Date
+	}
+	return meta
+}
+
+// run przetwarza jedno wejście. Błąd wczytania lub dopasowania przerywa pracę nad tym
+// wejściem, błędy pozostałych etapów są zbierane, a kolejne etapy wykonywane dalej.
+// lookAhead opisuje przekształcenia danych, które korzystają z notowań późniejszych niż
+// przekształcana obserwacja; pusty wynik oznacza przekształcenia przyczynowe
+func (c cliConfig) lookAhead() []string {
+	var out []string
+	if c.deseason != nil {
+		out = append(out, "-deseason")
+	}
+	if data.TwoSided(c.smooth) {
+		out = append(out, "-smooth "+c.smooth)
+	}
+	for _, stage := range c.pipeline {
+		if stage.LooksAhead() {
+			out = append(out, "etap potoku "+stage.String())
+		}
+	}
+	return out
+}
+
+func (c cliConfig) run(in input) (errs []error) {
+	fail := func(stage string, err error) {
+		errs = append(errs, &stageError{input: in.name, stage: stage, err: err})
+	}
+
+	points, err := in.load()
+	if err == nil && len(points) == 0 {
+		err = errors.New("brak poprawnych obserwacji")
+	}
+	if err != nil {
+		fail("wczytywanie danych", err)
+		return errs
+	}
+	pipeline := []transformStep{newStep("wczytanie", in.name, points)}
+	if c.dividends != "" {
+		dividends, err := data.LoadMetric(c.dividends)
+		var total []data.Point
+		if err == nil {
+			total, err = data.TotalReturn(points, dividends)
+		}
+		if err != nil {
+			fail("dywidendy", err)
+			return errs
+		}
+		points = total
+		pipeline = append(pipeline, newStep("całkowita stopa zwrotu", c.dividends, points))
+		log.Printf("Szereg całkowitej stopy zwrotu z dywidendami z %s", c.dividends)
+	}
+	if c.convertTo != "" {
+		converted, err := convertCurrency(points, c.currency, c.convertTo, c.fxSource)
+		if err != nil {
+			fail("przeliczanie waluty", err)
+			return errs
+		}
+		points = converted
+		pipeline = append(pipeline, newStep("przeliczenie waluty", fmt.Sprintf("%s -> %s (%s)", c.currency, c.convertTo, c.fxSource), points))
+		log.Printf("Ceny przeliczone z %s na %s (kursy: %s)", c.currency, c.convertTo, c.fxSource)
+	}
+	if c.deflate != "" {
+		deflated, base, err := deflatePrices(points, c.deflate, c.cpiSeries)
+		if err != nil {
+			fail("urealnianie cen", err)
+			return errs
+		}
+		points, c.realBase = deflated, base
+		pipeline = append(pipeline, newStep("urealnienie", fmt.Sprintf("%s, baza %s", c.deflate, base.Format("2006-01")), points))
+		log.Printf("Ceny urealnione wskaźnikiem %s (baza: %s)", c.deflate, base.Format("2006-01"))
+	}
+	for _, stage := range c.pipeline {
+		transformed, err := stage.Apply(points)
+		if err != nil {
+			fail("potok danych", err)
+			return errs
+		}
+		points = transformed
+		pipeline = append(pipeline, newStep("etap potoku", stage.String(), points))
+		log.Printf("Etap potoku %s: %d obserwacji", stage, len(points))
+	}
+	opts := c.opts
+	data.AssessQuality(points).Log(in.name)
+
+	// Skróty liczone są tylko z ustawień dopasowania i wyników (runKey), żeby nie zależały
+	// od sposobu wywołania
+	key := c.hashKey()
+	prov := newProvenance(in.name, points, key)
+	footer := prov.String()
+	log.Printf("Pochodzenie: %s", footer)
+
+	var raw []data.Point
+	if c.deseason != nil {
+		adjusted, err := data.Deseason(points, c.deseason)
+		if err != nil {
+			fail("usuwanie sezonowości", err)
+			return errs
+		}
+		raw, points = points, adjusted
+		pipeline = append(pipeline, newStep("usunięcie sezonowości", fmt.Sprint(c.deseason), points))
+	}
+	if c.smooth != "" {
+		smoothed, err := data.Smooth(points, c.smooth)
+		if err != nil {
+			fail("wygładzanie", err)
+			return errs
+		}
+		if raw == nil {
+			raw = points
+		}
+		points = smoothed
+		pipeline = append(pipeline, newStep("wygładzanie", c.smooth, points))
+	}
+
+	var record runRecord
+	if c.registry != nil {
+		hash := runHash(points, key)
+		if prev, ok := c.registry.Seen(hash); ok && !c.force {
+			log.Printf("%s: identyczna analiza została wykonana %s, pomijam (użyj -force, aby powtórzyć)",
+				in.name, prev.Time.Format(time.RFC3339))
+			return nil
+		}
+		defer func() {
+			if len(errs) > 0 {
+				return
+			}
+			record.Hash, record.Input, record.Time = hash, in.name, time.Now()
+			if err := c.registry.Add(record); err != nil {
+				errs = append(errs, &stageError{input: in.name, stage: "rejestr analiz", err: err})
+			}
+		}()
+	}
+
+	if c.outDir != "" {
+		dir, err := newRunDir(c.outDir, c.runName(in), time.Now())
+		if err != nil {
+			fail("katalog wyników", err)
+			return errs
+		}
+		c.runDir = dir
+		log.Printf("Wyniki w katalogu %s", dir)
+		defer func() {
+			if len(errs) > 0 {
+				return
+			}
+			if err := linkLatest(dir); err != nil {
+				errs = append(errs, &stageError{input: in.name, stage: "katalog wyników", err: err})
+			}
+		}()
+	}
+
+	if c.upload != nil {
+		var written []string
+		atomicfile.Committed = func(path string) { written = append(written, path) }
+		vars := map[string]string{
+			"symbol": c.runName(in),
+			"input":  filepath.Base(in.name),
+			"date":   points[len(points)-1].Date.Format(time.DateOnly),
+			"time":   time.Now().UTC().Format(runDirLayout),
+		}
+		defer func() {
+			atomicfile.Committed = nil
+			for _, path := range written {
+				target, err := c.upload.upload(path, vars)
+				if err != nil {
+					errs = append(errs, &stageError{input: in.name, stage: "wysyłka wyników", err: err})
+					continue
+				}
+				log.Printf("Wysłano %s do %s", path, target)
+			}
+		}()
+	}
+
+	if c.prescreen {
+		windows := fit.RollingScreen(points, opts, c.minWindow, c.windowStep)
+		var passed int
+		for _, w := range windows {
+			if w.SuperExponential() {
+				passed++
+			}
+		}
+		if len(windows) == 0 {
+			fail("test ponadwykładniczy", errors.New("szereg krótszy niż -min-window"))
+			return errs
+		}
+		last := windows[len(windows)-1]
+		if math.IsInf(last.DeltaAIC, -1) {
+			log.Printf("Test ponadwykładniczy: %d z %d okien, ostatnie okno bez wzrostu potęgowego do tc", passed, len(windows))
+		} else {
+			log.Printf("Test ponadwykładniczy: %d z %d okien, ostatnie okno dAIC=%.2f (tc=%.1f, m=%.1f)",
+				passed, len(windows), last.DeltaAIC, last.Tc, last.M)
+		}
+		if !last.SuperExponential() {
+			log.Printf("%s: brak wzrostu ponadwykładniczego w ostatnim oknie, pomijam dopasowanie LPPL", in.name)
+			return errs
+		}
+	}
+
+	if c.clusterEps > 0 {
+		if cl, err := fit.ClusterTc(points, opts, c.minWindow, c.clusterEps, c.clusterMin); err != nil {
+			fail("grupowanie tc", err)
+		} else {
+			log.Printf("Dominujący klaster tc: %s - %s (%d z %d estymat)",
+				cl.From.Format("2006-01-02"), cl.To.Format("2006-01-02"), cl.Size, cl.Total)
+		}
+	}
+
+	if c.heatmapPrefix != "" {
+		if err := plotStability(points, opts, c.minWindow, c.chartMeta(in, points), c.outputFor(c.heatmapPrefix, in.name), footer); err != nil {
+			fail("mapa stabilności", err)
+		}
+	}
+
+	// Pewność bańki dodatniej: udział kwalifikowanych dopasowań z ostatniego dnia, jeśli
+	// był liczony, a w przeciwnym razie 1 lub 0 zależnie od kwalifikacji dopasowania
+	confidence := -1.0
+
+	var regimeProbs []float64
+	if c.regimes {
+		model, probs, err := fit.Regimes(points)
+		if err != nil {
+			fail("reżimy", err)
+		} else {
+			regimeProbs = probs
+			log.Printf("Reżimy: normalny %.4f±%.4f, ponadwykładniczy %.4f±%.4f (średni zwrot ± odch. std.)",
+				model.Mean[0], math.Sqrt(model.Variance[0]), model.Mean[1], math.Sqrt(model.Variance[1]))
+			log.Printf("Prawdopodobieństwo reżimu ponadwykładniczego na %s: %.3f",
+				points[len(points)-1].Date.Format("2006-01-02"), probs[len(probs)-1])
+		}
+	}
+
+	var fractions []fit.QualifiedFraction
+	if c.fractionsOut != "" || c.signalsOut != "" || c.backtest || c.sizing.Kind != "" || c.paper != nil || c.confidencePanel {
+		series := fit.QualifiedFractions(points, opts, c.minWindow, c.maxWindow, c.windowStep)
+		fractions = series
+		if regimeProbs != nil {
+			fit.AttachRegimes(series, points, regimeProbs)
+		}
+		if c.fractionsOut != "" {
+			if err := fit.WriteFractions(c.outputFor(c.fractionsOut, in.name), series); err != nil {
+				fail("udział kwalifikowanych dopasowań", err)
+			}
+		}
+		if len(series) > 0 {
+			last := series[len(series)-1]
+			confidence = last.Positive
+			log.Printf("Wskaźnik pewności bańki na %s: dodatniej %.2f, ujemnej %.2f (%d okien)",
+				last.Date.Format("2006-01-02"), last.Positive, last.Negative, last.Windows)
+		}
+		if c.sizing.Kind != "" && len(series) > 0 {
+			last := series[len(series)-1]
+			log.Printf("Sugerowana ekspozycja na %s: %.0f%% (wskaźnik pewności bańki %.2f)",
+				last.Date.Format("2006-01-02"), 100*c.sizing.Exposure(last.Positive), last.Positive)
+		}
+		signals := generateSignals(series, c.signalRules)
+		if c.signalsOut != "" {
+			for _, s := range signals {
+				log.Printf("Sygnał %s: %s (udział %.2f, ekspozycja %.2f)", s.Date.Format("2006-01-02"), s.Kind, s.Fraction, s.Exposure)
+			}
+			if err := writeSignals(c.outputFor(c.signalsOut, in.name), signals); err != nil {
+				fail("sygnały", err)
+			}
+		}
+		if c.paper != nil {
+			// Do sieci testowej trafiają tylko sygnały z ostatniej obserwacji; starsze są historią
+			prev, last := 1.0, points[len(points)-1].Date
+			for _, s := range signals {
+				if s.Date.Equal(last) {
+					fill, err := c.paper.Trade(s, prev)
+					if err != nil {
+						fail("handel testowy", err)
+						break
+					}
+					log.Printf("Zlecenie testowe %s %s: %.6f po %.2f (id %d)", fill.Side, fill.Symbol, fill.Quantity, fill.Price, fill.OrderID)
+				}
+				prev = s.Exposure
+			}
+		}
+		if c.backtest {
+			// Handel odbywa się po cenach rynkowych, nie wygładzonych
+			prices := points
+			if raw != nil {
+				prices = raw
+			}
+			bt := backtest(prices, signals, c.fee)
+			log.Printf("Backtest (%d transakcji): zwrot %.1f%%, Sharpe %.2f, maks. obsunięcie %.1f%%",
+				bt.Trades, 100*bt.Return, bt.Sharpe, 100*bt.MaxDrawdown)
+			log.Printf("Kup i trzymaj: zwrot %.1f%%, Sharpe %.2f, maks. obsunięcie %.1f%%",
+				100*bt.BuyHoldReturn, bt.BuyHoldSharpe, 100*bt.BuyHoldMaxDD)
+		}
+	}
+
+	if c.lpplsOut != "" {
+		if err := writeLppls(c.outputFor(c.lpplsOut, in.name), []lpplsNested{nestedFits(points, opts, c.minWindow)}); err != nil {
+			fail("eksport lppls", err)
+		}
+	}
+
+	if c.lpplsIn != "" {
+		if nested, err := readLppls(c.lpplsIn); err != nil {
+			fail("import lppls", err)
+		} else {
+			for _, n := range nested {
+				for _, f := range n.Res {
+					window := windowFrom(points, f)
+					if len(window) < 2 {
+						log.Printf("lppls %s - %s: brak danych w oknie", f.T1D, f.T2D)
+						continue
+					}
+					params := fromLppls(f)
+					timeIndex := data.TimeIndex(window)
+					log.Printf("lppls %s - %s: tc=%s koszt=%.6f naruszenia=%v", f.T1D, f.T2D, f.TcD,
+						lppl.Cost(params, window, timeIndex), lppl.CheckFilters(params, window, timeIndex, opts.Filters))
+				}
+			}
+		}
+	}
+
+	var (
+		best     lppl.Result
+		rejected []lppl.Result
+		drawups  []fit.Drawup
+	)
+	if c.drawups {
+		drawups = fit.AbnormalDrawups(points)
+		for _, d := range drawups {
+			log.Printf("Nietypowy wzrost: %s - %s, +%.1f%%, p=%.3f", points[d.Start].Date.Format("2006-01-02"),
+				points[d.End].Date.Format("2006-01-02"), 100*(math.Exp(d.Size)-1), d.PValue)
+		}
+		if len(drawups) == 0 {
+			log.Printf("%s: brak nietypowych wzrostów, okno obejmuje całą historię", in.name)
+		}
+	}
+	if c.lagrangeOut != "" {
+		profile, bestIdx, err := fit.LagrangeProfile(points, opts, c.minWindow)
+		if err != nil {
+			fail("regularyzacja Lagrange'a", err)
+			return errs
+		}
+		if err := fit.WriteLagrangeProfile(c.outputFor(c.lagrangeOut, in.name), points, profile); err != nil {
+			fail("zapis profilu t1", err)
+		}
+		chosen := profile[bestIdx]
+		log.Printf("Regularyzacja Lagrange'a: t1=%s (%d obserwacji)", points[chosen.Start].Date.Format("2006-01-02"), chosen.N)
+		points = points[chosen.Start:]
+		pipeline = append(pipeline, newStep("początek okna", "regularyzacja Lagrange'a", points))
+		if raw != nil {
+			raw = raw[chosen.Start:]
+		}
+		best = chosen.Fit
+	} else if len(drawups) > 0 {
+		start, fit, err := fit.FromDrawups(points, drawups, opts, c.minWindow)
+		if err != nil {
+			fail("dopasowanie od wzrostów", err)
+			return errs
+		}
+		log.Printf("Początek okna z nietypowego wzrostu: %s (%d obserwacji)", points[start].Date.Format("2006-01-02"), len(points)-start)
+		points = points[start:]
+		pipeline = append(pipeline, newStep("początek okna", "nietypowy wzrost", points))
+		if raw != nil {
+			raw = raw[start:]
+		}
+		best = fit
+	} else {
+		best, rejected, err = lppl.FitAll(points, opts)
+		if err != nil {
+			fail("dopasowanie", err)
+			return errs
+		}
+		log.Printf("Starty optymalizacji: %s", lppl.Summarize(best, rejected))
+	}
+	prov.Pipeline = pipeline
+	log.Printf("Przekształcenia danych: %s", pipelineString(pipeline))
+	params := best.Params
+	cond := lppl.Conditioning(points, params)
+
+	log.Printf("Dopasowane parametry (%s):", in.name)
+	log.Printf("tc: %.2f dni", params[0])
+	log.Printf("beta: %.4f%s", params[1], cond.Annotate("m"))
+	log.Printf("omega: %.4f%s", params[2], cond.Annotate("omega"))
+	log.Printf("A: %.4f%s", params[3], cond.Annotate("A"))
+	log.Printf("B: %.4f%s", params[4], cond.Annotate("B"))
+	log.Printf("C: %.4f%s", params[5], cond.Annotate("C"))
+	log.Printf("phi: %.4f%s", params[6], cond.Annotate("phi"))
+	log.Printf("koszt: %.6f, spełnia filtry: %t", best.Cost, best.Qualified())
+	log.Printf("Uwarunkowanie podproblemu liniowego: %.3g", cond.Cond)
+	for _, w := range cond.Warnings {
+		log.Printf("Ostrzeżenie: %s", w)
+	}
+	if c.bootstrap.Samples > 0 {
+		bopts := c.bootstrap
+		bopts.Seed = opts.Seed
+		if b, err := lppl.Bootstrap(points, best, opts, bopts); err != nil {
+			fail("bootstrap", err)
+		} else {
+			best.Bootstrap = &b
+		}
+	}
+	if b := best.Bootstrap; b != nil {
+		log.Printf("Przedziały ufności %.0f%% z %d próbek bootstrapu (nieudane: %d, ziarno: %d):", 100*b.Level, b.Samples, b.Failed, b.Seed)
+		for i, name := range []string{"tc", "beta", "omega", "A", "B", "C", "phi"} {
+			log.Printf("  %s: [%.4f, %.4f]", name, b.Intervals[i][0], b.Intervals[i][1])
+		}
+	}
+	if crash, err := lppl.EstimateCrash(best, points, c.crash); err == nil {
+		log.Printf("Oczekiwany spadek po tc: %.1f%% (%.1f%% - %.1f%%)", 100*crash.Expected, 100*crash.Lower, 100*crash.Upper)
+	}
+	if res := lppl.ClassifyResolution(best); res != "" {
+		log.Printf("Przewidywane zakończenie bańki: %s", res)
+	}
+	if confidence < 0 {
+		confidence = 0
+		if best.Qualified() && params[4] < 0 {
+			confidence = 1
+		}
+	}
+	record.Symbol, record.End = c.runName(in), points[len(points)-1].Date
+	record.Tc = points[0].Date.Add(time.Duration(params[0] * 24 * float64(time.Hour)))
+	record.Cost, record.Qualified, record.Confidence = best.Cost, best.Qualified(), confidence
+	record.Chart = plot.Path(c.outputFor(c.plotOut, in.name))
+	if c.alerts != nil {
+		triage := newAlertTriage(points, best, confidence, c.portfolio, record.Symbol, c.crash)
+		if err := c.alerts.check(record.Symbol, record.End, record.Tc, best, confidence, triage); err != nil {
+			fail("alert webhook", err)
+		}
+	}
+	if c.icsOut != "" {
+		if err := writeICS(c.outputFor(c.icsOut, in.name), record.Symbol, points, best, confidence, time.Now()); err != nil {
+			fail("zapis kalendarza", err)
+		}
+	}
+	if c.format == formatJSON {
+		if err := writeFitDocument(os.Stdout, newFitDocument(record, in.name, points, best, c.crash, prov)); err != nil {
+			fail("wynik JSON", err)
+		}
+	}
+	asset := c.runName(in)
+	if severity, score := alertSeverity(confidence, c.portfolio, asset); severity != "" {
+		if c.portfolio != nil {
+			log.Printf("Alert (%s): bańka dodatnia na %s, pewność %.2f, udział w portfelu %.0f%% (ocena %.2f)",
+				severity, asset, confidence, 100*c.portfolio.Weight(asset), score)
+		} else {
+			log.Printf("Alert (%s): bańka dodatnia na %s, pewność %.2f", severity, asset, confidence)
+		}
+	}
+	if c.hq {
+		if hq, err := lppl.HQAnalysis(points, params[0]); err != nil {
+			fail("analiza (H,q)", err)
+		} else {
+			log.Printf("Analiza (H,q): omega=%.2f (%d z %d par z istotnym pikiem), zgodna z dopasowaniem: %t",
+				hq.Omega, len(hq.Peaks), hq.Pairs, hq.Confirms(params[2]))
+		}
+	}
+	if c.spectrumOut != "" {
+		if err := writeSpectra(points, params, c.chartMeta(in, points), c.outputFor(c.spectrumOut, in.name), footer); err != nil {
+			fail("widmo reszt", err)
+		}
+	}
+	if c.diagOut != "" {
+		if err := writeDiagnostics(points, params, c.chartMeta(in, points), c.outputFor(c.diagOut, in.name), footer); err != nil {
+			fail("diagnostyka reszt", err)
+		}
+	}
+	for _, note := range best.Notes {
+		log.Printf("Optymalizacja: %s", note)
+	}
+
+	for _, r := range rejected {
+		log.Printf("Odrzucone minimum: koszt=%.6f tc=%.2f m=%.4f omega=%.4f naruszenia=%v",
+			r.Cost, r.Params[0], r.Params[1], r.Params[2], r.Violations)
+	}
+
+	for _, name := range c.onchain {
+		name = strings.TrimSpace(name)
+		metric, err := fetchOnchain(name, points[0].Date, points[len(points)-1].Date)
+		if err != nil {
+			fail("metryka sieci", err)
+			continue
+		}
+		r, n := correlateOnchain(points, metric)
+		log.Printf("Metryka %s: korelacja z log-ceną %.3f (%d wspólnych dni)", name, r, n)
+		if c.onchainPlot != "" {
+			if err := plot.Metric(points, metric, name, c.chartMeta(in, points), footer, c.outputFor(c.onchainPlot+"_"+name+".png", in.name)); err != nil {
+				fail("wykres metryki sieci", err)
+			}
+		}
+	}
+
+	if c.arrowOut != "" {
+		if err := writeArrow(c.outputFor(c.arrowOut, in.name), points, best, prov); err != nil {
+			fail("eksport Arrow", err)
+		}
+	}
+	if c.curveOut != "" {
+		if err := writeCurve(c.outputFor(c.curveOut, in.name), points, params); err != nil {
+			fail("eksport krzywej modelu", err)
+		}
+	}
+
+	from, to := points[0].Date, points[len(points)-1].Date
+	for _, e := range c.events {
+		if !e.Date.Before(from) && !e.Date.After(to) {
+			log.Printf("Wydarzenie %s: %s", e.Date.Format("2006-01-02"), e.Label)
+		}
+	}
+
+	meta := c.chartMeta(in, points)
+	var panels []plot.Panel
+	if c.confidencePanel && len(fractions) > 0 {
+		positive := make([]data.Metric, len(fractions))
+		negative := make([]data.Metric, len(fractions))
+		for i, f := range fractions {
+			positive[i] = data.Metric{Date: f.Date, Value: f.Positive}
+			negative[i] = data.Metric{Date: f.Date, Value: f.Negative}
+		}
+		panels = append(panels,
+			plot.Panel{Title: meta.Text("positiveConfidence"), Points: positive, YMax: 1},
+			plot.Panel{Title: meta.Text("negativeConfidence"), Points: negative, YMax: 1})
+	}
+	if c.derivatives != "" {
+		if funding, err := fetchFunding(c.derivatives, from, to); err != nil {
+			fail("stopy finansowania", err)
+		} else {
+			panels = append(panels, plot.Panel{Title: meta.Text("funding"), Points: funding})
+		}
+		if oi, err := fetchOpenInterest(c.derivatives, from, to); err != nil {
+			fail("otwarte pozycje", err)
+		} else {
+			panels = append(panels, plot.Panel{Title: meta.Text("openInterest"), Points: oi})
+		}
+	}
+
+	notice := fitNotice{
+		Symbol: record.Symbol, From: points[0].Date, To: record.End, Tc: record.Tc,
+		M: params[1], Omega: params[2], Cost: best.Cost, Qualified: best.Qualified(), Confidence: confidence,
+	}
+	if err := plot.Results(points, params, plot.Extras{Raw: raw, Panels: panels, Footer: footer, Events: c.events, Meta: meta}, c.outputFor(c.plotOut, in.name)); err != nil {
+		fail("wykres", err)
+	} else {
+		notice.Chart = record.Chart
+	}
+	if c.htmlOut != "" {
+		if err := plot.HTML(points, params, plot.Extras{Footer: footer, Events: c.events, Meta: meta}, c.outputFor(c.htmlOut, in.name)); err != nil {
+			fail("wykres HTML", err)
+		}
+	}
+	for _, n := range c.notifiers {
+		if err := n.Notify(notice); err != nil {
+			fail("powiadomienie "+n.Name(), err)
+			continue
+		}
+		log.Printf("Wysłano podsumowanie do: %s", n.Name())
+	}
+	return errs
+}
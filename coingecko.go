@@ -0,0 +1,138 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"cw3/data"
+	"cw3/internal/atomicfile"
+)
+
+// coingeckoAPI to publiczne API CoinGecko; klucz demo (opcjonalny) podaje się w zmiennej
+// COINGECKO_API_KEY
+const coingeckoAPI = "https://api.coingecko.com/api/v3"
+
+// coingeckoLiveTTL to czas ważności zapisu w pamięci podręcznej dla zakresu obejmującego
+// bieżący dzień; zakresy zamknięte w przeszłości nie zmieniają się i nie wygasają
+const coingeckoLiveTTL = time.Hour
+
+// coingeckoSource pobiera historię cen monety z CoinGecko z pamięcią podręczną na dysku
+type coingeckoSource struct {
+	from, to time.Time
+	// Waluta kwotowania (np. usd) i interwał: "daily" albo pusty (CoinGecko wybiera sam:
+	// godzinowy dla zakresów do 90 dni, dzienny dla dłuższych)
+	vs, interval string
+	// Katalog pamięci podręcznej; pusty wyłącza zapisywanie odpowiedzi
+	cacheDir string
+}
+
+// load zwraca ceny monety id (np. bitcoin) w zakresie [from, to]
+func (s coingeckoSource) load(id string) ([]data.Point, error) {
+	body, err := s.cached(id)
+	if err != nil {
+		return nil, fmt.Errorf("CoinGecko %s: %w", id, err)
+	}
+	var resp struct {
+		Prices [][2]float64 `json:"prices"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("CoinGecko %s: %w", id, err)
+	}
+
+	points := make([]data.Point, 0, len(resp.Prices))
+	for _, p := range resp.Prices {
+		if !data.ValidPrice(p[1]) {
+			continue
+		}
+		points = append(points, data.Point{Date: time.UnixMilli(int64(p[0])).UTC(), Price: p[1]})
+	}
+	// W trybie dziennym ostatni punkt to bieżąca cena z chwili zapytania, a nie zamknięcie dnia
+	if s.interval == "daily" && len(points) > 1 && points[len(points)-1].Date.Truncate(24*time.Hour).Equal(points[len(points)-2].Date.Truncate(24*time.Hour)) {
+		points = points[:len(points)-1]
+	}
+	return points, nil
+}
+
+// cached zwraca odpowiedź API z pamięci podręcznej albo pobiera ją i zapisuje
+func (s coingeckoSource) cached(id string) ([]byte, error) {
+	if s.cacheDir == "" {
+		return s.fetch(id)
+	}
+	name := fmt.Sprintf("%s_%s_%s_%s_%s.json", id, s.vs,
+		s.from.Format("20060102"), s.to.Format("20060102"), cmp.Or(s.interval, "auto"))
+	path := filepath.Join(s.cacheDir, name)
+	if info, err := os.Stat(path); err == nil {
+		live := !s.to.Before(time.Now().Truncate(24 * time.Hour))
+		if !live || time.Since(info.ModTime()) < coingeckoLiveTTL {
+			return os.ReadFile(path)
+		}
+	}
+
+	body, err := s.fetch(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.cacheDir, 0o755); err != nil {
+		return nil, err
+	}
+	f, err := atomicfile.Replace(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Abort()
+	if _, err := f.Write(body); err != nil {
+		return nil, err
+	}
+	return body, f.Commit()
+}
+
+func (s coingeckoSource) fetch(id string) ([]byte, error) {
+	q := url.Values{
+		"vs_currency": {s.vs},
+		"from":        {strconv.FormatInt(s.from.Unix(), 10)},
+		"to":          {strconv.FormatInt(s.to.Add(24*time.Hour-time.Second).Unix(), 10)},
+	}
+	if s.interval != "" {
+		q.Set("interval", s.interval)
+	}
+	req, err := http.NewRequest(http.MethodGet, coingeckoAPI+"/coins/"+url.PathEscape(id)+"/market_chart/range?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if key := os.Getenv("COINGECKO_API_KEY"); key != "" {
+		req.Header.Set("x-cg-demo-api-key", key)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status)
+	}
+	return body, nil
+}
+
+// defaultCacheDir zwraca katalog pamięci podręcznej użytkownika dla danych z API
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "lppl")
+}
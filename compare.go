@@ -0,0 +1,201 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"cw3/data"
+	"cw3/internal/atomicfile"
+	"cw3/lppl"
+	"cw3/plot"
+)
+
+// compareRun to wynik dopasowania jednej z porównywanych konfiguracji
+type compareRun struct {
+	name string
+	best lppl.Result
+	cond lppl.ConditionReport
+	err  error
+}
+
+// loadFitOptions nakłada na ustawienia domyślne opcje dopasowania z pliku: YAML (.yaml,
+// .yml) w formacie -config, z którego brane są tylko flagi dopasowania, albo JSON z polami
+// lppl.FitOptions. Pusta ścieżka oznacza same ustawienia domyślne.
+func loadFitOptions(path string) (lppl.FitOptions, error) {
+	opts := lppl.DefaultFitOptions()
+	if path == "" {
+		return opts, nil
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		fs := flag.NewFlagSet("fit", flag.ContinueOnError)
+		fitFlags(fs, &opts)
+		_, err := readConfig(fs, path, true)
+		return opts, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return opts, err
+	}
+	if err := json.Unmarshal(content, &opts); err != nil {
+		return opts, fmt.Errorf("%s: %w", path, err)
+	}
+	return opts, nil
+}
+
+// checkFitOptions sprawdza zakres tc, granice m i omega, tryb obsługi t >= tc i metodę
+// optymalizacji
+func checkFitOptions(opts lppl.FitOptions) error {
+	if opts.TcMaxFrac <= opts.TcMinFrac {
+		return fmt.Errorf("nieprawidłowy zakres tc: [%.2f, %.2f]", opts.TcMinFrac, opts.TcMaxFrac)
+	}
+	if opts.Bounds.M[1] <= opts.Bounds.M[0] || opts.Bounds.Omega[1] <= opts.Bounds.Omega[0] {
+		return fmt.Errorf("nieprawidłowe granice: m [%g, %g], omega [%g, %g]", opts.Bounds.M[0], opts.Bounds.M[1], opts.Bounds.Omega[0], opts.Bounds.Omega[1])
+	}
+	switch opts.BeyondTc {
+	case lppl.BeyondTcExclude, lppl.BeyondTcPenalty, lppl.BeyondTcClamp:
+	default:
+		return fmt.Errorf("nieznany tryb obsługi t >= tc (-beyond-tc, BeyondTc): %q", opts.BeyondTc)
+	}
+	switch opts.Method {
+	case lppl.MethodNelderMead, lppl.MethodCMAES:
+	default:
+		return fmt.Errorf("nieznana metoda optymalizacji (-method, Method): %q", opts.Method)
+	}
+	return nil
+}
+
+// runCompare obsługuje polecenie compare: dopasowuje te same dane w dwóch
+// konfiguracjach i wypisuje zestawienie parametrów oraz miar dopasowania
+func runCompare(args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	pathA := fs.String("a", "", "pierwsza konfiguracja: plik YAML jak -config albo JSON z polami lppl.FitOptions (pusty - ustawienia domyślne)")
+	pathB := fs.String("b", "", "druga konfiguracja: plik YAML jak -config albo JSON z polami lppl.FitOptions (pusty - ustawienia domyślne)")
+	inputPath := fs.String("input", defaultInput, "plik CSV z notowaniami")
+	output := fs.String("output", "compare.png", "plik wykresu porównania (pusty wyłącza wykres)")
+	fs.BoolVar(&atomicfile.Overwrite, "overwrite", false, "zastąp istniejący plik wykresu")
+	popts := data.DefaultParseOptions()
+	csvFlags(fs, &popts)
+	var lang, currency string
+	chartFlags(fs, &lang, &currency)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Użycie: %s compare -a a.json -b b.json [flagi] [plik.csv]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		return errors.New("compare przyjmuje jeden plik wejściowy")
+	}
+	if fs.NArg() == 1 {
+		*inputPath = fs.Arg(0)
+	}
+	if *pathA == "" && *pathB == "" {
+		return errors.New("podaj co najmniej jedną konfigurację flagą -a lub -b")
+	}
+
+	points, report, err := data.Load(*inputPath, popts)
+	if err != nil {
+		return err
+	}
+	log.Printf("%s: %s", *inputPath, report)
+
+	var runs []compareRun
+	for _, path := range []string{*pathA, *pathB} {
+		run := compareRun{name: configName(path)}
+		opts, err := loadFitOptions(path)
+		if err != nil {
+			return err
+		}
+		run.best, _, run.err = lppl.FitAll(points, opts)
+		if run.err == nil {
+			run.cond = lppl.Conditioning(points, run.best.Params)
+		}
+		runs = append(runs, run)
+	}
+	if runs[0].name == runs[1].name {
+		runs[0].name, runs[1].name = "A: "+runs[0].name, "B: "+runs[1].name
+	}
+
+	writeComparison(os.Stdout, points, runs)
+
+	if *output == "" {
+		return nil
+	}
+	var curves []plot.Curve
+	for _, run := range runs {
+		if run.err == nil {
+			curves = append(curves, plot.Curve{Label: run.name, Params: run.best.Params})
+		}
+	}
+	if len(curves) == 0 {
+		return errors.New("żadna konfiguracja nie dała dopasowania")
+	}
+	meta := plot.Meta{
+		Source:   filepath.Base(*inputPath),
+		Currency: currency,
+		Start:    points[0].Date,
+		End:      points[len(points)-1].Date,
+		Lang:     lang,
+	}
+	return plot.Compare(points, curves, meta, "", *output)
+}
+
+func configName(path string) string {
+	if path == "" {
+		return "domyślna"
+	}
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// writeComparison wypisuje tabelę z kolumną dla każdej konfiguracji i różnicą B - A
+func writeComparison(f io.Writer, points []data.Point, runs []compareRun) {
+	w := tabwriter.NewWriter(f, 0, 4, 2, ' ', tabwriter.AlignRight)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "\t%s\t%s\tróżnica\t\n", runs[0].name, runs[1].name)
+	for _, run := range runs {
+		if run.err != nil {
+			fmt.Fprintf(w, "błąd %s:\t%v\t\t\t\n", run.name, run.err)
+		}
+	}
+	if runs[0].err != nil || runs[1].err != nil {
+		return
+	}
+
+	names := []string{"tc", "m", "omega", "A", "B", "C", "phi"}
+	for i, name := range names {
+		a, b := runs[0].best.Params[i], runs[1].best.Params[i]
+		fmt.Fprintf(w, "%s\t%.4f\t%.4f\t%+.4f\t\n", name, a, b, b-a)
+	}
+	n := float64(len(points))
+	rmse := func(r compareRun) float64 { return math.Sqrt(r.best.Cost / n) }
+	a, b := runs[0], runs[1]
+	fmt.Fprintf(w, "koszt\t%.6f\t%.6f\t%+.6f\t\n", a.best.Cost, b.best.Cost, b.best.Cost-a.best.Cost)
+	fmt.Fprintf(w, "RMSE ln(ceny)\t%.5f\t%.5f\t%+.5f\t\n", rmse(a), rmse(b), rmse(b)-rmse(a))
+	fmt.Fprintf(w, "uwarunkowanie\t%.3g\t%.3g\t\t\n", a.cond.Cond, b.cond.Cond)
+	fmt.Fprintf(w, "spełnia filtry\t%t\t%t\t\t\n", a.best.Qualified(), b.best.Qualified())
+	fmt.Fprintf(w, "zakończenie\t%s\t%s\t\t\n", orDash(lppl.ClassifyResolution(a.best)), orDash(lppl.ClassifyResolution(b.best)))
+	for _, run := range runs {
+		if len(run.best.Violations) > 0 {
+			fmt.Fprintf(w, "naruszenia %s:\t%s\t\t\t\n", run.name, strings.Join(run.best.Violations, ", "))
+		}
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,172 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"cw3/data"
+)
+
+// Klucze pliku konfiguracji, które nie są nazwami flag
+const (
+	// Lista plików wejściowych
+	configInputs = "inputs"
+	// Lista etapów potoku przygotowania danych
+	configPipeline = "pipeline"
+)
+
+// configFile to ustawienia z pliku konfiguracji, których nie da się wyrazić flagami
+type configFile struct {
+	inputs   []string
+	pipeline []data.StageSpec
+}
+
+// configPath wyszukuje w argumentach wartość flagi -config, zanim zostaną sparsowane
+// pozostałe flagi: ustawienia z pliku muszą trafić do zmiennych przed flagami z wiersza
+// poleceń, żeby te ostatnie je nadpisywały
+func configPath(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// applyConfig wczytuje plik YAML i ustawia flagi z fs w kolejności z pliku. Klucze to
+// nazwy flag bez myślnika; mapowania najwyższego poziomu (np. data, fit, output) służą
+// tylko do grupowania. Listy są łączone przecinkami. Zwraca listę plików wejściowych i
+// etapy potoku przygotowania danych.
+func applyConfig(fs *flag.FlagSet, path string) (configFile, error) {
+	return readConfig(fs, path, false)
+}
+
+// readConfig to applyConfig, który przy skipUnknown pomija klucze spoza fs, zamiast
+// zgłaszać błąd
+func readConfig(fs *flag.FlagSet, path string, skipUnknown bool) (configFile, error) {
+	var cf configFile
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return cf, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return cf, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return cf, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return cf, fmt.Errorf("%s: oczekiwano mapowania nazw opcji na wartości", path)
+	}
+
+	var apply func(m *yaml.Node, nested bool) error
+	apply = func(m *yaml.Node, nested bool) error {
+		for i := 0; i+1 < len(m.Content); i += 2 {
+			key, value := m.Content[i].Value, m.Content[i+1]
+			switch {
+			case key == configInputs:
+				if err := value.Decode(&cf.inputs); err != nil {
+					return fmt.Errorf("%s: wiersz %d: %w", path, value.Line, err)
+				}
+			case key == configPipeline:
+				stages, err := configStages(value)
+				if err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+				cf.pipeline = stages
+			case value.Kind == yaml.MappingNode && !nested:
+				if err := apply(value, true); err != nil {
+					return err
+				}
+			default:
+				s, err := configValue(value)
+				if err != nil {
+					return fmt.Errorf("%s: wiersz %d: %s: %w", path, value.Line, key, err)
+				}
+				if fs.Lookup(key) == nil && skipUnknown {
+					continue
+				}
+				if fs.Lookup(key) == nil {
+					return fmt.Errorf("%s: wiersz %d: nieznana opcja %q", path, m.Content[i].Line, key)
+				}
+				if err := fs.Set(key, s); err != nil {
+					return fmt.Errorf("%s: wiersz %d: %s: %w", path, value.Line, key, err)
+				}
+			}
+		}
+		return nil
+	}
+	return cf, apply(root, false)
+}
+
+// configStages odczytuje listę etapów potoku: każdy element to mapowanie z kluczem stage
+// (nazwa etapu) i parametrami etapu jako pozostałymi kluczami
+func configStages(n *yaml.Node) ([]data.StageSpec, error) {
+	if n.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("wiersz %d: %s: oczekiwano listy etapów", n.Line, configPipeline)
+	}
+	var specs []data.StageSpec
+	for _, item := range n.Content {
+		if item.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("wiersz %d: etap musi być mapowaniem z kluczem stage", item.Line)
+		}
+		spec := data.StageSpec{Params: map[string]string{}}
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			key, value := item.Content[i].Value, item.Content[i+1]
+			s, err := configValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("wiersz %d: %s: %w", value.Line, key, err)
+			}
+			if key == "stage" {
+				spec.Name = s
+			} else {
+				spec.Params[key] = s
+			}
+		}
+		if spec.Name == "" {
+			return nil, fmt.Errorf("wiersz %d: etap bez nazwy (klucz stage)", item.Line)
+		}
+		if err := spec.Check(); err != nil {
+			return nil, fmt.Errorf("wiersz %d: %w", item.Line, err)
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// configValue zamienia wartość z YAML na tekst w postaci przyjmowanej przez flagę
+func configValue(n *yaml.Node) (string, error) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return n.Value, nil
+	case yaml.SequenceNode:
+		items := make([]string, len(n.Content))
+		for i, item := range n.Content {
+			if item.Kind != yaml.ScalarNode {
+				return "", errors.New("elementy listy muszą być wartościami prostymi")
+			}
+			items[i] = item.Value
+		}
+		return strings.Join(items, ","), nil
+	}
+	return "", errors.New("nieobsługiwany typ wartości")
+}
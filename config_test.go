@@ -0,0 +1,66 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cw3/lppl"
+)
+
+func TestLoadFitOptions(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	defaults := lppl.DefaultFitOptions()
+	tests := []struct {
+		name    string
+		path    string
+		check   func(o lppl.FitOptions) bool
+		wantErr bool
+	}{
+		{name: "pusta ścieżka", check: func(o lppl.FitOptions) bool { return o.TcMaxFrac == defaults.TcMaxFrac }},
+		{
+			name: "JSON z polami FitOptions",
+			path: write("opcje.json", `{"TcMaxFrac": 0.4, "Method": "cmaes"}`),
+			check: func(o lppl.FitOptions) bool {
+				return o.TcMaxFrac == 0.4 && o.Method == lppl.MethodCMAES && o.MaxIterations == defaults.MaxIterations
+			},
+		},
+		{
+			// Plik -config głównego polecenia: opcje spoza dopasowania są pomijane
+			name: "YAML jak -config",
+			path: write("analiza.yaml", "inputs: [dane.csv]\ndata:\n  date-col: 0\nfit:\n  tc-max: 0.4\n  method: cmaes\n  start-m: [0.3, 0.5]\n  filters: default\noutput:\n  output: wynik.png\n"),
+			check: func(o lppl.FitOptions) bool {
+				return o.TcMaxFrac == 0.4 && o.Method == lppl.MethodCMAES && len(o.StartM) == 2 && o.StartM[1] == 0.5
+			},
+		},
+		{name: "błędna wartość w YAML", path: write("zle.yml", "tc-max: dużo\n"), wantErr: true},
+		{name: "błędny JSON", path: write("zle.json", `{"TcMaxFrac": "0.4"}`), wantErr: true},
+		{name: "brak pliku", path: filepath.Join(dir, "brak.yaml"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := loadFitOptions(tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("oczekiwano błędu")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(opts) {
+				t.Errorf("nieoczekiwane ustawienia: %+v", opts)
+			}
+		})
+	}
+}
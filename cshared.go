@@ -0,0 +1,30 @@
+//go:build cshared
+
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"strings"
+	"unsafe"
+)
+
+// lppl_fit przyjmuje treść pliku CSV i opcjonalny JSON z polami lppl.FitOptions, a zwraca
+// wynik jako JSON. Zwrócony bufor należy zwolnić funkcją lppl_free.
+//
+//export lppl_fit
+func lppl_fit(csv *C.char, options *C.char) *C.char {
+	var opts string
+	if options != nil {
+		opts = C.GoString(options)
+	}
+	return C.CString(fitCSVJSON(strings.NewReader(C.GoString(csv)), opts))
+}
+
+//export lppl_free
+func lppl_free(p *C.char) {
+	C.free(unsafe.Pointer(p))
+}
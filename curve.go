@@ -0,0 +1,85 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"encoding/csv"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"cw3/data"
+	"cw3/internal/atomicfile"
+	"cw3/lppl"
+)
+
+// maxCurveRows ogranicza liczbę wierszy ekstrapolacji, gdy tc leży daleko za danymi
+const maxCurveRows = 10000
+
+// writeCurve zapisuje CSV z ceną, wartością modelu i resztą (ln ceny - ln modelu) dla
+// każdej obserwacji, a po nich wartości modelu ekstrapolowane do tc z krokiem równym
+// medianie odstępów między obserwacjami; wiersze ekstrapolacji mają puste pola price i residual
+func writeCurve(path string, points []data.Point, params []float64) error {
+	file, err := atomicfile.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+
+	p := params
+	model := func(t float64) float64 {
+		return math.Exp(lppl.Model(t, p[0], p[1], p[2], p[3], p[4], p[5], p[6]))
+	}
+	dateOnly := true
+	for _, point := range points {
+		if point.Date.Truncate(24*time.Hour) != point.Date {
+			dateOnly = false
+			break
+		}
+	}
+	format := func(t time.Time) string {
+		if dateOnly {
+			return t.Format(time.DateOnly)
+		}
+		return t.Format(time.RFC3339)
+	}
+	number := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"date", "price", "model", "residual"})
+	timeIndex := data.TimeIndex(points)
+	for i, point := range points {
+		m := model(timeIndex[i])
+		w.Write([]string{format(point.Date), number(point.Price), number(m), number(math.Log(point.Price) - math.Log(m))})
+	}
+	if step := medianStep(timeIndex); step > 0 {
+		t := timeIndex[len(timeIndex)-1] + step
+		for n := 0; t < p[0] && n < maxCurveRows; n++ {
+			date := points[0].Date.Add(time.Duration(t * 24 * float64(time.Hour)))
+			if dateOnly {
+				date = date.Round(24 * time.Hour)
+			}
+			w.Write([]string{format(date), "", number(model(t)), ""})
+			t += step
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return file.Commit()
+}
+
+// medianStep zwraca medianę odstępów między kolejnymi chwilami (w dniach)
+func medianStep(timeIndex []float64) float64 {
+	if len(timeIndex) < 2 {
+		return 0
+	}
+	steps := make([]float64, len(timeIndex)-1)
+	for i := range steps {
+		steps[i] = timeIndex[i+1] - timeIndex[i]
+	}
+	slices.Sort(steps)
+	return steps[len(steps)/2]
+}
@@ -0,0 +1,236 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"time"
+
+	"gonum.org/v1/gonum/stat"
+
+	"cw3/data"
+	"cw3/lppl"
+)
+
+//go:embed dashboard/index.html
+var dashboardPage []byte
+
+// curveTail to liczba punktów krzywej modelu między ostatnią obserwacją a tc w GET /curve
+const curveTail = 200
+
+// curveBand to szerokość pasma wokół krzywej modelu w odchyleniach standardowych reszt
+const curveBand = 2
+
+// previewRows to liczba początkowych wierszy pliku (z nagłówkiem) w odpowiedzi POST /preview
+const previewRows = 11
+
+// watchEntry to symbol z rejestru z ostatnią analizą w odpowiedzi GET /watchlist
+type watchEntry struct {
+	Symbol string `json:"symbol"`
+	Runs   int    `json:"runs"`
+	historyEntry
+}
+
+// curveReply to dane interaktywnego wykresu panelu: czasy w milisekundach od epoki Uniksa,
+// ceny (null za ostatnią obserwacją), krzywa modelu do tc i pasmo ±curveBand odchyleń
+// standardowych reszt ln ceny
+type curveReply struct {
+	Symbol string     `json:"symbol"`
+	Time   []int64    `json:"time"`
+	Price  []*float64 `json:"price"`
+	Model  []*float64 `json:"model"`
+	Lower  []*float64 `json:"lower"`
+	Upper  []*float64 `json:"upper"`
+	Fit    *fitReply  `json:"fit"`
+}
+
+// handleDashboard zwraca panel w przeglądarce wbudowany w program
+func (s *apiServer) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(dashboardPage)
+}
+
+// handleWatchlist zwraca symbole z rejestru z ostatnią analizą każdego z nich
+func (s *apiServer) handleWatchlist(w http.ResponseWriter, r *http.Request) {
+	bySymbol, err := s.history.symbols()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	names, err := s.history.names()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	entries := []watchEntry{}
+	for _, name := range names {
+		rec := bySymbol[name][0]
+		entries = append(entries, watchEntry{Symbol: name, Runs: len(bySymbol[name]), historyEntry: historyEntry{
+			Time: rec.Time, End: rec.End, Tc: rec.Tc, TcDays: metricValue(rec, "tc_days"),
+			Cost: rec.Cost, Qualified: rec.Qualified, Confidence: rec.Confidence,
+		}})
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// handleCurve dopasowuje model do ostatnich danych symbolu (w oknie ?window= jak GET /chart)
+// i zwraca ceny, krzywą modelu i pasmo reszt do narysowania w przeglądarce
+func (s *apiServer) handleCurve(w http.ResponseWriter, r *http.Request) {
+	window := r.URL.Query().Get("window")
+	days, count, err := parseChartWindow(window)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	rec, code, err := s.latestRecord(r.PathValue("symbol"))
+	if err != nil {
+		writeError(w, code, err)
+		return
+	}
+	points, state, err := s.recordInput(rec)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	points = windowPoints(points, days, count)
+	if len(points) < minChartWindow {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Errorf("za mało obserwacji w oknie: %d", len(points)))
+		return
+	}
+
+	// Krzywe panelu i wykresy GET /chart dzielą pamięć podręczną i limit -jobs
+	key := fmt.Sprintf("curve|%s|%s|%s|%d", rec.Symbol, window, state, rec.Time.UnixNano())
+	content, ok := s.charts.get(key)
+	if !ok {
+		s.renders <- struct{}{}
+		reply, err := fitReplyFor(points, s.opts, rec.Symbol)
+		<-s.renders
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		if content, err = json.Marshal(modelCurve(rec.Symbol, points, reply)); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		s.charts.put(key, content)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(content)
+}
+
+// modelCurve liczy wartości modelu w obserwacjach i za nimi do tc; nieokreślone wartości
+// modelu (za tc) są pomijane jako null
+func modelCurve(symbol string, points []data.Point, reply *fitReply) curveReply {
+	p := reply.best.Params
+	_, residuals := lppl.Residuals(points, p)
+	sd := stat.StdDev(residuals, nil)
+	c := curveReply{Symbol: symbol, Fit: reply}
+	add := func(t float64, at time.Time, price *float64) {
+		c.Time = append(c.Time, at.UnixMilli())
+		c.Price = append(c.Price, price)
+		v := lppl.Model(t, p[0], p[1], p[2], p[3], p[4], p[5], p[6])
+		if math.IsNaN(v) || math.IsInf(v, 0) || math.IsNaN(sd) {
+			c.Model, c.Lower, c.Upper = append(c.Model, nil), append(c.Lower, nil), append(c.Upper, nil)
+			return
+		}
+		model, lower, upper := math.Exp(v), math.Exp(v-curveBand*sd), math.Exp(v+curveBand*sd)
+		c.Model, c.Lower, c.Upper = append(c.Model, &model), append(c.Lower, &lower), append(c.Upper, &upper)
+	}
+	timeIndex := data.TimeIndex(points)
+	for i, point := range points {
+		price := point.Price
+		add(timeIndex[i], point.Date, &price)
+	}
+	start := points[0].Date
+	if last := timeIndex[len(timeIndex)-1]; p[0] > last {
+		step := (p[0] - last) / curveTail
+		// Ostatni punkt leży tuż przed tc, gdzie człon (tc-t)^m jest jeszcze określony
+		for i := 1; i < curveTail; i++ {
+			t := last + step*float64(i)
+			add(t, start.Add(time.Duration(t*24*float64(time.Hour))), nil)
+		}
+	}
+	return c
+}
+
+// handleRefit zleca jako zadanie POST /jobs ponowne dopasowanie ostatnich danych symbolu
+// z ustawieniami -fit; wynik nie trafia do rejestru analiz
+func (s *apiServer) handleRefit(w http.ResponseWriter, r *http.Request) {
+	rec, code, err := s.latestRecord(r.PathValue("symbol"))
+	if err != nil {
+		writeError(w, code, err)
+		return
+	}
+	points, _, err := s.recordInput(rec)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	job, err := s.jobs.submit(points, s.opts)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// handlePreview zwraca początkowe wiersze przesłanego pliku CSV (z separatorem ?delimiter=
+// albo serwera) lub skoroszytu xlsx, z których panel buduje mapowanie kolumn
+func (s *apiServer) handlePreview(w http.ResponseWriter, r *http.Request) {
+	popts, err := s.parseOptions(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	rows, err := previewUpload(http.MaxBytesReader(w, r.Body, maxFitBody), mediaType, popts.Delimiter)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Rows [][]string `json:"rows"`
+	}{rows})
+}
+
+// previewUpload czyta co najwyżej previewRows wierszy pliku jak parseUpload
+func previewUpload(r io.Reader, mediaType string, delimiter rune) ([][]string, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(4)
+	if mediaType == xlsxMediaType || data.IsXLSX(head) {
+		content, err := io.ReadAll(br)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := data.ReadXLSX(bytes.NewReader(content), int64(len(content)))
+		return rows[:min(len(rows), previewRows)], err
+	}
+	reader := csv.NewReader(br)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	rows := [][]string{}
+	for len(rows) < previewRows {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, record)
+	}
+	return rows, nil
+}
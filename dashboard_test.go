@@ -0,0 +1,151 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"cw3/data"
+	"cw3/lppl"
+)
+
+// dashboardServer zwraca serwer z rejestrem dwóch analiz BTC i jednej ETH na wbudowanych danych
+func dashboardServer(t *testing.T) http.Handler {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "runs.json")
+	registry, err := openRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC)
+	for _, rec := range []runRecord{
+		{Hash: "a", Input: "sample:btc-2025", Time: now.Add(-time.Hour), Symbol: "BTC", Confidence: 0.1},
+		{Hash: "b", Input: "sample:btc-2025", Time: now, Symbol: "BTC", Confidence: 0.4, Qualified: true},
+		{Hash: "c", Input: "eth.csv", Time: now, Symbol: "ETH"},
+	} {
+		if err := registry.Add(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &apiServer{opts: lppl.DefaultFitOptions(), parse: data.DefaultParseOptions(), history: newRunHistory(path),
+		renders: make(chan struct{}, 1), metrics: newFitMetrics()}
+	return s.handler()
+}
+
+func TestDashboardWatchlist(t *testing.T) {
+	h := dashboardServer(t)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Panel LPPL") {
+		t.Fatalf("GET /: kod %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/watchlist", nil))
+	var entries []watchEntry
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Symbol != "BTC" || entries[1].Symbol != "ETH" {
+		t.Fatalf("symbole %+v, oczekiwano BTC i ETH", entries)
+	}
+	if btc := entries[0]; btc.Runs != 2 || btc.Confidence != 0.4 || !btc.Qualified {
+		t.Errorf("BTC: %+v, oczekiwano 2 analiz i ostatniej z pewnością 0.4", btc)
+	}
+}
+
+func TestDashboardCurve(t *testing.T) {
+	h := dashboardServer(t)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/curve/BTC", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("kod %d: %s", rec.Code, rec.Body)
+	}
+	var c curveReply
+	if err := json.Unmarshal(rec.Body.Bytes(), &c); err != nil {
+		t.Fatal(err)
+	}
+	in, err := sampleInput("btc-2025")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := in.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Time) < len(want) || len(c.Price) != len(c.Time) || len(c.Model) != len(c.Time) {
+		t.Fatalf("%d czasów, %d cen, %d wartości modelu dla %d notowań", len(c.Time), len(c.Price), len(c.Model), len(want))
+	}
+	for i := range c.Time {
+		if i < len(want) && (c.Price[i] == nil || *c.Price[i] != want[i].Price) {
+			t.Fatalf("cena %d: %v, oczekiwano %g", i, c.Price[i], want[i].Price)
+		}
+		if i >= len(want) && c.Price[i] != nil {
+			t.Fatalf("cena %d za ostatnią obserwacją: %g", i, *c.Price[i])
+		}
+		if c.Model[i] != nil && !(*c.Lower[i] < *c.Model[i] && *c.Model[i] < *c.Upper[i]) {
+			t.Fatalf("punkt %d: model %g poza pasmem [%g, %g]", i, *c.Model[i], *c.Lower[i], *c.Upper[i])
+		}
+		if i > 0 && c.Time[i] <= c.Time[i-1] {
+			t.Fatalf("czasy nie rosną w punkcie %d", i)
+		}
+	}
+
+	for _, tt := range []struct {
+		url  string
+		code int
+	}{
+		{"/curve/DOGE", http.StatusNotFound},
+		{"/curve/BTC?window=0d", http.StatusBadRequest},
+		{"/curve/ETH", http.StatusUnprocessableEntity},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", tt.url, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s: kod %d, oczekiwano %d", tt.url, rec.Code, tt.code)
+		}
+	}
+}
+
+func TestDashboardPreview(t *testing.T) {
+	h := dashboardServer(t)
+	csv := "data,otwarcie,kurs\n2025-03-11,1,2\n2025-03-12,3,4\n"
+	for _, tt := range []struct {
+		query string
+		code  int
+		width int
+	}{
+		// Bez parametru obowiązuje separator serwera (;), więc wiersz to jedna kolumna
+		{"", http.StatusOK, 1},
+		{"?delimiter=,", http.StatusOK, 3},
+		{"?delimiter=,,", http.StatusBadRequest, 0},
+		{"?date-col=-1", http.StatusBadRequest, 0},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("POST", "/preview"+tt.query, strings.NewReader(csv)))
+		if rec.Code != tt.code {
+			t.Errorf("%q: kod %d, oczekiwano %d", tt.query, rec.Code, tt.code)
+			continue
+		}
+		if tt.code != http.StatusOK {
+			continue
+		}
+		var reply struct {
+			Rows [][]string `json:"rows"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+			t.Fatal(err)
+		}
+		if len(reply.Rows) != 3 || len(reply.Rows[0]) != tt.width {
+			t.Errorf("%q: wiersze %q, oczekiwano 3 wierszy po %d kolumn", tt.query, reply.Rows, tt.width)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package data
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+)
+
+// Nazwy kolumn rozpoznawane w plikach Arrow/Feather, w kolejności preferencji
+var (
+	arrowDateColumns  = []string{"date", "timestamp", "time", "timeOpen"}
+	arrowPriceColumns = []string{"price", "close", "Close"}
+)
+
+func findColumn(schema *arrow.Schema, names []string) (int, error) {
+	for _, name := range names {
+		if idx := schema.FieldIndices(name); len(idx) > 0 {
+			return idx[0], nil
+		}
+	}
+	return 0, fmt.Errorf("brak kolumny o żadnej z nazw %v", names)
+}
+
+func arrowTime(col arrow.Array, i int) (time.Time, error) {
+	switch c := col.(type) {
+	case *array.Timestamp:
+		unit := c.DataType().(*arrow.TimestampType).Unit
+		return c.Value(i).ToTime(unit).UTC(), nil
+	case *array.Date32:
+		return c.Value(i).ToTime().UTC(), nil
+	case *array.Date64:
+		return c.Value(i).ToTime().UTC(), nil
+	case *array.String:
+		if t, err := time.Parse(time.RFC3339Nano, c.Value(i)); err == nil {
+			return t, nil
+		}
+		return time.Parse("2006-01-02", c.Value(i))
+	}
+	return time.Time{}, fmt.Errorf("nieobsługiwany typ kolumny daty: %s", col.DataType())
+}
+
+func arrowFloat(col arrow.Array, i int) (float64, error) {
+	switch c := col.(type) {
+	case *array.Float64:
+		return c.Value(i), nil
+	case *array.Float32:
+		return float64(c.Value(i)), nil
+	case *array.Int64:
+		return float64(c.Value(i)), nil
+	}
+	return 0, fmt.Errorf("nieobsługiwany typ kolumny ceny: %s", col.DataType())
+}
+
+// LoadArrow wczytuje szereg z pliku Arrow IPC (Feather v2), np. zapisanego przez
+// pandas.DataFrame.to_feather lub polars.DataFrame.write_ipc
+func LoadArrow(path string) ([]Point, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader, err := ipc.NewFileReader(file, ipc.WithAllocator(memory.NewGoAllocator()))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	dateIdx, err := findColumn(reader.Schema(), arrowDateColumns)
+	if err != nil {
+		return nil, err
+	}
+	priceIdx, err := findColumn(reader.Schema(), arrowPriceColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	var dataPoints []Point
+	for r := 0; r < reader.NumRecords(); r++ {
+		rec, err := reader.Record(r)
+		if err != nil {
+			return nil, err
+		}
+		dates, prices := rec.Column(dateIdx), rec.Column(priceIdx)
+		for i := 0; i < int(rec.NumRows()); i++ {
+			if dates.IsNull(i) || prices.IsNull(i) {
+				continue
+			}
+			date, err := arrowTime(dates, i)
+			if err != nil {
+				return nil, err
+			}
+			price, err := arrowFloat(prices, i)
+			if err != nil {
+				return nil, err
+			}
+			dataPoints = append(dataPoints, Point{Date: date, Price: price})
+		}
+	}
+
+	sort.Slice(dataPoints, func(i, j int) bool {
+		return dataPoints[i].Date.Before(dataPoints[j].Date)
+	})
+	return dataPoints, nil
+}
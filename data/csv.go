@@ -0,0 +1,235 @@
+package data
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseOptions steruje obsługą błędnych wierszy: w trybie ścisłym pierwszy błędny
+// wiersz przerywa wczytywanie, w łagodnym jest pomijany i liczony w podsumowaniu
+type ParseOptions struct {
+	Strict bool
+
+	// Formaty znacznika czasu próbowane po kolei; "unix" i "unixms" oznaczają liczbę
+	// sekund lub milisekund od 1970-01-01. Pusta lista oznacza DefaultDateLayouts.
+	DateLayouts []string
+
+	// Numery kolumn (od 0) z datą i ceną oraz separator pól
+	DateColumn  int
+	PriceColumn int
+	Delimiter   rune
+}
+
+// DefaultParseOptions odpowiada eksportowi historii notowań z CoinMarketCap: data
+// otwarcia w pierwszej kolumnie, cena zamknięcia w siódmej, pola oddzielone średnikiem
+func DefaultParseOptions() ParseOptions {
+	return ParseOptions{PriceColumn: 6, Delimiter: ';'}
+}
+
+var DefaultDateLayouts = []string{
+	"2006-01-02T15:04:05.000Z",
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"unix",
+	"unixms",
+}
+
+type ParseReport struct {
+	Rows       int
+	BadColumns int
+	BadDates   int
+	BadPrices  int
+	// Wiersze z błędną składnią CSV, np. cudzysłowem w środku pola bez cudzysłowu
+	BadSyntax int
+
+	// Liczba wierszy rozpoznanych danym formatem daty
+	Layouts map[string]int
+}
+
+// DateLayout zwraca format daty, którym rozpoznano najwięcej wierszy
+func (r ParseReport) DateLayout() string {
+	var best string
+	for layout, n := range r.Layouts {
+		if n > r.Layouts[best] || (n == r.Layouts[best] && layout < best) {
+			best = layout
+		}
+	}
+	return best
+}
+
+// ParseDate próbuje kolejnych formatów, zaczynając od ostatnio udanego
+func ParseDate(s string, layouts []string, last string) (time.Time, string, error) {
+	try := func(layout string) (time.Time, error) {
+		switch layout {
+		case "unix", "unixms":
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return time.Time{}, err
+			}
+			if layout == "unixms" {
+				return time.UnixMilli(n).UTC(), nil
+			}
+			// Wartości tej wielkości to już milisekundy, nie sekundy
+			if n > 1e11 || n < -1e11 {
+				return time.Time{}, fmt.Errorf("%d poza zakresem sekund unix", n)
+			}
+			return time.Unix(n, 0).UTC(), nil
+		}
+		return time.Parse(layout, s)
+	}
+
+	if last != "" {
+		if t, err := try(last); err == nil {
+			return t, last, nil
+		}
+	}
+	for _, layout := range layouts {
+		if t, err := try(layout); err == nil {
+			return t, layout, nil
+		}
+	}
+	return time.Time{}, "", fmt.Errorf("%q nie pasuje do żadnego z formatów %v", s, layouts)
+}
+
+func (r ParseReport) Skipped() int {
+	return r.BadColumns + r.BadDates + r.BadPrices + r.BadSyntax
+}
+
+func (r ParseReport) String() string {
+	if r.Skipped() == 0 {
+		return fmt.Sprintf("wczytano %d wierszy, format daty: %s", r.Rows, r.DateLayout())
+	}
+	var reasons []string
+	for _, c := range []struct {
+		n    int
+		desc string
+	}{
+		{r.BadDates, "błędne daty"},
+		{r.BadPrices, "błędne ceny"},
+		{r.BadColumns, "brakujące kolumny"},
+		{r.BadSyntax, "błędna składnia CSV"},
+	} {
+		if c.n > 0 {
+			reasons = append(reasons, fmt.Sprintf("%s: %d", c.desc, c.n))
+		}
+	}
+	return fmt.Sprintf("pominięto %d z %d wierszy: %s; format daty: %s",
+		r.Skipped(), r.Rows, strings.Join(reasons, ", "), r.DateLayout())
+}
+
+// isSyntaxError odróżnia błędy składni pojedynczego wiersza CSV od błędów odczytu
+func isSyntaxError(err error) bool {
+	var parseErr *csv.ParseError
+	return errors.As(err, &parseErr)
+}
+
+// Load wczytuje plik CSV albo, z rozszerzeniem .xlsx, pierwszy arkusz skoroszytu Excel
+func Load(filePath string, popts ParseOptions) ([]Point, ParseReport, error) {
+	if strings.EqualFold(filepath.Ext(filePath), ".xlsx") {
+		return loadXLSX(filePath, popts)
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, ParseReport{}, err
+	}
+	defer file.Close()
+
+	return Parse(file, popts)
+}
+
+func Parse(r io.Reader, popts ParseOptions) ([]Point, ParseReport, error) {
+	report := ParseReport{Layouts: map[string]int{}}
+	if popts.DateColumn < 0 || popts.PriceColumn < 0 || popts.DateColumn == popts.PriceColumn {
+		return nil, report, fmt.Errorf("nieprawidłowe kolumny daty (%d) i ceny (%d)", popts.DateColumn, popts.PriceColumn)
+	}
+	columns := max(popts.DateColumn, popts.PriceColumn) + 1
+
+	reader := csv.NewReader(r)
+	reader.Comma = popts.Delimiter
+	reader.FieldsPerRecord = -1
+
+	// Błąd składni w nagłówku bez -strict nie przeszkadza, bo nagłówek i tak jest pomijany
+	if _, err := reader.Read(); err != nil && (popts.Strict || !isSyntaxError(err)) {
+		return nil, report, err
+	}
+
+	layouts := popts.DateLayouts
+	if len(layouts) == 0 {
+		layouts = DefaultDateLayouts
+	}
+	var lastLayout string
+
+	var dataPoints []Point
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil && !popts.Strict && isSyntaxError(err) {
+			report.Rows++
+			report.BadSyntax++
+			continue
+		}
+		if err != nil {
+			return nil, report, err
+		}
+		report.Rows++
+		line, _ := reader.FieldPos(0)
+
+		if len(record) < columns {
+			if popts.Strict {
+				return nil, report, fmt.Errorf("wiersz %d: za mało kolumn (%d)", line, len(record))
+			}
+			report.BadColumns++
+			continue
+		}
+
+		timeStr := strings.Trim(record[popts.DateColumn], "\"")
+		priceStr := strings.TrimSpace(record[popts.PriceColumn])
+
+		date, layout, err := ParseDate(timeStr, layouts, lastLayout)
+		if err != nil {
+			if popts.Strict {
+				return nil, report, fmt.Errorf("wiersz %d: błąd parsowania daty: %w", line, err)
+			}
+			report.BadDates++
+			continue
+		}
+
+		price, err := strconv.ParseFloat(priceStr, 64)
+		if err == nil && !ValidPrice(price) {
+			err = fmt.Errorf("cena %v nie jest dodatnią liczbą skończoną", price)
+		}
+		if err != nil {
+			if popts.Strict {
+				return nil, report, fmt.Errorf("wiersz %d: błąd parsowania ceny: %w", line, err)
+			}
+			report.BadPrices++
+			continue
+		}
+
+		lastLayout = layout
+		report.Layouts[layout]++
+		dataPoints = append(dataPoints, Point{
+			Date:  date,
+			Price: price,
+		})
+	}
+
+	// Plik CMC jest posortowany od najnowszych notowań
+	sort.Slice(dataPoints, func(i, j int) bool {
+		return dataPoints[i].Date.Before(dataPoints[j].Date)
+	})
+
+	return dataPoints, report, nil
+}
@@ -0,0 +1,122 @@
+package data
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in      string
+		last    string
+		want    time.Time
+		layout  string
+		wantErr bool
+	}{
+		{in: "2025-03-11", want: time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC), layout: "2006-01-02"},
+		{in: "2025-03-11T12:30:00.000Z", want: time.Date(2025, 3, 11, 12, 30, 0, 0, time.UTC), layout: "2006-01-02T15:04:05.000Z"},
+		{in: "2025-03-11 12:30:00", want: time.Date(2025, 3, 11, 12, 30, 0, 0, time.UTC), layout: "2006-01-02 15:04:05"},
+		{in: "1741651200", want: time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC), layout: "unix"},
+		// Za duże na sekundy, więc rozpoznane jako milisekundy
+		{in: "1741651200000", want: time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC), layout: "unixms"},
+		// Ostatnio udany format ma pierwszeństwo
+		{in: "1741651200", last: "unixms", want: time.UnixMilli(1741651200).UTC(), layout: "unixms"},
+		{in: "2025-03-11", last: "unix", want: time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC), layout: "2006-01-02"},
+		{in: "11.03.2025", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, layout, err := ParseDate(tt.in, DefaultDateLayouts, tt.last)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDate(%q): oczekiwano błędu, otrzymano %v (%s)", tt.in, got, layout)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDate(%q, last=%q): %v", tt.in, tt.last, err)
+			continue
+		}
+		if !got.Equal(tt.want) || layout != tt.layout {
+			t.Errorf("ParseDate(%q, last=%q) = %v, %q; oczekiwano %v, %q", tt.in, tt.last, got, layout, tt.want, tt.layout)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	csvOpts := ParseOptions{DateColumn: 0, PriceColumn: 1, Delimiter: ','}
+	tests := []struct {
+		name    string
+		in      string
+		opts    ParseOptions
+		prices  []float64
+		report  ParseReport
+		wantErr bool
+	}{
+		{
+			name: "eksport CoinMarketCap od najnowszych",
+			in: `timeOpen;timeClose;timeHigh;timeLow;name;open;close
+"2025-03-12T00:00:00.000Z";x;x;x;2781;1;82000.5
+"2025-03-11T00:00:00.000Z";x;x;x;2781;1;78000
+`,
+			opts:   DefaultParseOptions(),
+			prices: []float64{78000, 82000.5},
+			report: ParseReport{Rows: 2},
+		},
+		{
+			name:   "łagodny tryb pomija błędne wiersze",
+			in:     "date,price\n2025-01-01,1\nzła data,2\n2025-01-03,-3\n2025-01-04\n2025-01-05,a\"b\n2025-01-06,6\n",
+			opts:   csvOpts,
+			prices: []float64{1, 6},
+			report: ParseReport{Rows: 6, BadDates: 1, BadPrices: 1, BadColumns: 1, BadSyntax: 1},
+		},
+		{
+			name:    "ścisły tryb przerywa na błędnej cenie",
+			in:      "date,price\n2025-01-01,1\n2025-01-02,NaN\n",
+			opts:    ParseOptions{DateColumn: 0, PriceColumn: 1, Delimiter: ',', Strict: true},
+			wantErr: true,
+		},
+		{
+			name:    "ścisły tryb przerywa na błędzie składni",
+			in:      "date,price\n2025-01-01,a\"b\n",
+			opts:    ParseOptions{DateColumn: 0, PriceColumn: 1, Delimiter: ',', Strict: true},
+			wantErr: true,
+		},
+		{
+			name:    "ta sama kolumna daty i ceny",
+			in:      "date,price\n",
+			opts:    ParseOptions{Delimiter: ','},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, report, err := Parse(strings.NewReader(tt.in), tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("oczekiwano błędu, wczytano %d punktów", len(points))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(points) != len(tt.prices) {
+				t.Fatalf("wczytano %d punktów, oczekiwano %d", len(points), len(tt.prices))
+			}
+			for i, p := range points {
+				if p.Price != tt.prices[i] {
+					t.Errorf("punkt %d: cena %v, oczekiwano %v", i, p.Price, tt.prices[i])
+				}
+				if i > 0 && !p.Date.After(points[i-1].Date) {
+					t.Errorf("punkt %d: daty nie rosną", i)
+				}
+			}
+			if report.Rows != tt.report.Rows || report.BadDates != tt.report.BadDates || report.BadPrices != tt.report.BadPrices ||
+				report.BadColumns != tt.report.BadColumns || report.BadSyntax != tt.report.BadSyntax {
+				t.Errorf("raport %+v, oczekiwano %+v", report, tt.report)
+			}
+		})
+	}
+}
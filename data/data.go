@@ -0,0 +1,41 @@
+// Package data wczytuje i przygotowuje szeregi cen: parsowanie CSV i Arrow, ocenę
+// jakości, wygładzanie, usuwanie sezonowości i potoki tych przekształceń.
+package data
+
+import (
+	"math"
+	"time"
+)
+
+type Point struct {
+	Date  time.Time
+	Price float64
+}
+
+// ValidPrice odrzuca ceny, których logarytm nie istnieje lub jest nieskończony
+func ValidPrice(p float64) bool {
+	return p > 0 && !math.IsInf(p, 0) && !math.IsNaN(p)
+}
+
+func TimeIndex(points []Point) []float64 {
+	timeIndex := make([]float64, len(points))
+	start := points[0].Date
+	for i := range points {
+		timeIndex[i] = points[i].Date.Sub(start).Hours() / 24
+	}
+	return timeIndex
+}
+
+func LogPrices(points []Point) []float64 {
+	y := make([]float64, len(points))
+	for i, d := range points {
+		y[i] = math.Log(d.Price)
+	}
+	return y
+}
+
+// Metric to wartość pomocniczego szeregu (metryki sieci, finansowania itp.) w danym dniu
+type Metric struct {
+	Date  time.Time
+	Value float64
+}
@@ -0,0 +1,109 @@
+package data
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadMetric wczytuje szereg pomocniczy (np. kursy walut) z pliku CSV o kolumnach
+// data,wartość; wiersz nagłówka z niepoprawną datą jest pomijany
+func LoadMetric(path string) ([]Metric, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadMetric(file, path)
+}
+
+// ReadMetric działa jak LoadMetric, ale czyta z r; name opisuje źródło w komunikatach błędów
+func ReadMetric(in io.Reader, name string) ([]Metric, error) {
+	r := csv.NewReader(in)
+	r.FieldsPerRecord = 2
+	var metrics []Metric
+	var layout string
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		date, l, err := ParseDate(strings.TrimSpace(record[0]), DefaultDateLayouts, layout)
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+		layout = l
+		metrics = append(metrics, Metric{Date: date, Value: v})
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Date.Before(metrics[j].Date) })
+	return metrics, nil
+}
+
+// Convert przelicza ceny na inną walutę. rates to kursy (jednostki waluty docelowej
+// za jednostkę waluty notowań) posortowane według daty; w dni bez kursu (weekendy,
+// święta) używany jest ostatni wcześniejszy.
+func Convert(points []Point, rates []Metric) ([]Point, error) {
+	return scale(points, rates, "kursu", func(rate float64) float64 { return rate })
+}
+
+// Deflate zamienia ceny nominalne na realne według wskaźnika cen konsumpcyjnych cpi
+// (posortowanego według daty, zwykle miesięcznego). Ceny wyrażone są w pieniądzu z
+// okresu ostatniej obserwacji; w dni bez odczytu używany jest ostatni wcześniejszy.
+// Zwraca też datę odczytu CPI, który posłużył za bazę.
+func Deflate(points []Point, cpi []Metric) ([]Point, time.Time, error) {
+	if len(points) == 0 {
+		return points, time.Time{}, nil
+	}
+	base, ok := lastOnOrBefore(cpi, points[len(points)-1].Date)
+	if !ok || !ValidPrice(base.Value) {
+		return nil, time.Time{}, fmt.Errorf("brak poprawnego odczytu CPI z dnia %s lub wcześniejszego",
+			points[len(points)-1].Date.Format("2006-01-02"))
+	}
+	deflated, err := scale(points, cpi, "odczytu CPI", func(index float64) float64 { return base.Value / index })
+	return deflated, base.Date, err
+}
+
+// scale mnoży ceny przez factor(wartość) ostatniej obserwacji series z dnia ceny lub
+// wcześniejszej; what to nazwa wartości w dopełniaczu do komunikatów błędów
+func scale(points []Point, series []Metric, what string, factor func(float64) float64) ([]Point, error) {
+	scaled := make([]Point, len(points))
+	j := -1
+	for i, p := range points {
+		for j+1 < len(series) && !series[j+1].Date.After(p.Date) {
+			j++
+		}
+		if j < 0 {
+			return nil, fmt.Errorf("brak %s z dnia %s lub wcześniejszego", what, p.Date.Format("2006-01-02"))
+		}
+		price := p.Price * factor(series[j].Value)
+		if !ValidPrice(price) {
+			return nil, fmt.Errorf("nieprawidłowa wartość %s %v z dnia %s", what, series[j].Value, series[j].Date.Format("2006-01-02"))
+		}
+		scaled[i] = Point{Date: p.Date, Price: price}
+	}
+	return scaled, nil
+}
+
+// lastOnOrBefore zwraca ostatnią obserwację series z dnia date lub wcześniejszego
+func lastOnOrBefore(series []Metric, date time.Time) (Metric, bool) {
+	i := sort.Search(len(series), func(i int) bool { return series[i].Date.After(date) })
+	if i == 0 {
+		return Metric{}, false
+	}
+	return series[i-1], true
+}
@@ -0,0 +1,157 @@
+package data
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Stage to etap potoku przygotowania szeregu. Params to nazwy przyjmowanych parametrów;
+// Apply dostaje tylko te z nich, które podano w specyfikacji etapu.
+type Stage struct {
+	Params []string
+	Apply  func(points []Point, params map[string]string) ([]Point, error)
+}
+
+// StageSpec to wywołanie etapu w potoku: nazwa zarejestrowanego etapu i wartości parametrów
+type StageSpec struct {
+	Name   string
+	Params map[string]string
+}
+
+// LooksAhead mówi, czy etap zmienia obserwację na podstawie późniejszych notowań
+// (usuwanie sezonowości z całej historii, dwustronne wygładzanie)
+func (s StageSpec) LooksAhead() bool {
+	switch s.Name {
+	case "deseason":
+		return true
+	case "smooth":
+		return TwoSided(s.Params["method"])
+	}
+	return false
+}
+
+// String zwraca etap w postaci nazwa(klucz=wartość, ...) z kluczami w kolejności alfabetycznej
+func (s StageSpec) String() string {
+	keys := make([]string, 0, len(s.Params))
+	for k := range s.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		keys[i] = k + "=" + s.Params[k]
+	}
+	return s.Name + "(" + strings.Join(keys, ", ") + ")"
+}
+
+var stages = map[string]Stage{
+	"total-return": {Params: []string{"file"}, Apply: func(points []Point, p map[string]string) ([]Point, error) {
+		dividends, err := LoadMetric(p["file"])
+		if err != nil {
+			return nil, err
+		}
+		return TotalReturn(points, dividends)
+	}},
+	"convert": {Params: []string{"file"}, Apply: func(points []Point, p map[string]string) ([]Point, error) {
+		rates, err := LoadMetric(p["file"])
+		if err != nil {
+			return nil, err
+		}
+		return Convert(points, rates)
+	}},
+	"deflate": {Params: []string{"file"}, Apply: func(points []Point, p map[string]string) ([]Point, error) {
+		cpi, err := LoadMetric(p["file"])
+		if err != nil {
+			return nil, err
+		}
+		deflated, _, err := Deflate(points, cpi)
+		return deflated, err
+	}},
+	"trim": {Params: []string{"from", "to"}, Apply: trimStage},
+	"deseason": {Params: []string{"periods"}, Apply: func(points []Point, p map[string]string) ([]Point, error) {
+		periods, err := ParsePeriods(p["periods"])
+		if err != nil {
+			return nil, err
+		}
+		return Deseason(points, periods)
+	}},
+	"smooth": {Params: []string{"method", "window"}, Apply: func(points []Point, p map[string]string) ([]Point, error) {
+		spec := p["method"]
+		if spec == "" {
+			spec = smoothMA
+		}
+		if w := p["window"]; w != "" {
+			spec += ":" + w
+		}
+		return Smooth(points, spec)
+	}},
+}
+
+// RegisterStage dodaje etap, którego można używać w potokach pod podaną nazwą.
+// Ponowna rejestracja tej samej nazwy zastępuje wcześniejszy etap.
+func RegisterStage(name string, s Stage) {
+	stages[name] = s
+}
+
+// StageNames zwraca nazwy zarejestrowanych etapów oddzielone przecinkami
+func StageNames() string {
+	names := make([]string, 0, len(stages))
+	for name := range stages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// Check sprawdza, czy etap jest zarejestrowany i przyjmuje podane parametry
+func (s StageSpec) Check() error {
+	stage, ok := stages[s.Name]
+	if !ok {
+		return fmt.Errorf("nieznany etap %q (dostępne: %s)", s.Name, StageNames())
+	}
+	for k := range s.Params {
+		if !slices.Contains(stage.Params, k) {
+			return fmt.Errorf("etap %s: nieznany parametr %q", s.Name, k)
+		}
+	}
+	return nil
+}
+
+// Apply wykonuje etap na szeregu, sprawdzając wcześniej jego nazwę i parametry
+func (s StageSpec) Apply(points []Point) ([]Point, error) {
+	if err := s.Check(); err != nil {
+		return nil, err
+	}
+	out, err := stages[s.Name].Apply(points, s.Params)
+	if err != nil {
+		return nil, fmt.Errorf("etap %s: %w", s.Name, err)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("etap %s: brak obserwacji po przekształceniu", s.Name)
+	}
+	return out, nil
+}
+
+// trimStage ogranicza szereg do dat from-to (RRRR-MM-DD, obie opcjonalne i włącznie)
+func trimStage(points []Point, p map[string]string) ([]Point, error) {
+	var from, to time.Time
+	for key, t := range map[string]*time.Time{"from": &from, "to": &to} {
+		if v := p[key]; v != "" {
+			d, err := time.Parse(time.DateOnly, v)
+			if err != nil {
+				return nil, fmt.Errorf("nieprawidłowa data %s=%q", key, v)
+			}
+			*t = d
+		}
+	}
+	var out []Point
+	for _, point := range points {
+		if !from.IsZero() && point.Date.Before(from) || !to.IsZero() && !point.Date.Before(to.AddDate(0, 0, 1)) {
+			continue
+		}
+		out = append(out, point)
+	}
+	return out, nil
+}
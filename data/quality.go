@@ -0,0 +1,118 @@
+package data
+
+import (
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"cw3/internal/stats"
+)
+
+type Gap struct {
+	From, To time.Time
+	Missing  int
+}
+
+type Outlier struct {
+	Date  time.Time
+	Price float64
+	Score float64
+}
+
+// QualityReport opisuje, czym faktycznie karmiony był model
+type QualityReport struct {
+	Rows       int
+	From, To   time.Time
+	Step       time.Duration
+	Gaps       []Gap
+	Duplicates []time.Time
+	Outliers   []Outlier
+	MinPrice   float64
+	MaxPrice   float64
+}
+
+// Próg odpornego z-score (mediana i MAD) dla logarytmicznych stóp zwrotu
+const outlierScore = 5.0
+
+// AssessQuality zakłada dane posortowane rosnąco po dacie
+func AssessQuality(points []Point) QualityReport {
+	q := QualityReport{Rows: len(points)}
+	if len(points) == 0 {
+		return q
+	}
+	q.From, q.To = points[0].Date, points[len(points)-1].Date
+	q.MinPrice, q.MaxPrice = math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		q.MinPrice = math.Min(q.MinPrice, p.Price)
+		q.MaxPrice = math.Max(q.MaxPrice, p.Price)
+	}
+	if len(points) < 2 {
+		return q
+	}
+
+	var steps []time.Duration
+	for i := 1; i < len(points); i++ {
+		if d := points[i].Date.Sub(points[i-1].Date); d > 0 {
+			steps = append(steps, d)
+		} else {
+			q.Duplicates = append(q.Duplicates, points[i].Date)
+		}
+	}
+	if len(steps) > 0 {
+		sorted := append([]time.Duration(nil), steps...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		q.Step = sorted[len(sorted)/2]
+	}
+	for i := 1; i < len(points); i++ {
+		if d := points[i].Date.Sub(points[i-1].Date); q.Step > 0 && d > q.Step*3/2 {
+			q.Gaps = append(q.Gaps, Gap{
+				From:    points[i-1].Date,
+				To:      points[i].Date,
+				Missing: int(d/q.Step) - 1,
+			})
+		}
+	}
+
+	returns := make([]float64, 0, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		returns = append(returns, math.Log(points[i].Price/points[i-1].Price))
+	}
+	med := stats.Median(returns)
+	deviations := make([]float64, len(returns))
+	for i, r := range returns {
+		deviations[i] = math.Abs(r - med)
+	}
+	// 1.4826 skaluje MAD do odchylenia standardowego rozkładu normalnego
+	mad := 1.4826 * stats.Median(deviations)
+	if mad > 0 {
+		for i, r := range returns {
+			if score := math.Abs(r-med) / mad; score > outlierScore {
+				q.Outliers = append(q.Outliers, Outlier{Date: points[i+1].Date, Price: points[i+1].Price, Score: score})
+			}
+		}
+	}
+	return q
+}
+
+func (q QualityReport) Log(name string) {
+	log.Printf("Jakość danych (%s):", name)
+	if q.Rows == 0 {
+		log.Printf("  brak obserwacji")
+		return
+	}
+	log.Printf("  obserwacje: %d, zakres: %s - %s, typowy krok: %s",
+		q.Rows, q.From.Format("2006-01-02 15:04"), q.To.Format("2006-01-02 15:04"), q.Step)
+	log.Printf("  cena: %.4f - %.4f", q.MinPrice, q.MaxPrice)
+	log.Printf("  luki: %d, zduplikowane znaczniki czasu: %d, podejrzane wartości: %d",
+		len(q.Gaps), len(q.Duplicates), len(q.Outliers))
+	for _, g := range q.Gaps {
+		log.Printf("  luka: %s - %s (brak ok. %d obserwacji)", g.From.Format("2006-01-02 15:04"), g.To.Format("2006-01-02 15:04"), g.Missing)
+	}
+	for _, d := range q.Duplicates {
+		log.Printf("  duplikat: %s", d.Format("2006-01-02 15:04"))
+	}
+	for _, o := range q.Outliers {
+		log.Printf("  podejrzana wartość: %s cena=%.4f (z=%.1f)", o.Date.Format("2006-01-02 15:04"), o.Price, o.Score)
+	}
+}
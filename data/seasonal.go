@@ -0,0 +1,109 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// stlIterations to liczba przebiegów pętli wewnętrznej (trend, sezonowość)
+const stlIterations = 2
+
+// ParsePeriods odczytuje listę okresów sezonowości w dniach, np. "7,365"
+func ParsePeriods(s string) ([]int, error) {
+	var periods []int
+	for _, f := range strings.Split(s, ",") {
+		p, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil || p < 2 {
+			return nil, fmt.Errorf("nieprawidłowy okres sezonowości %q", f)
+		}
+		periods = append(periods, p)
+	}
+	return periods, nil
+}
+
+// Deseason usuwa z logarytmów cen składowe sezonowe o podanych okresach (w dniach
+// kalendarzowych) uproszczoną dekompozycją STL i zwraca szereg skorygowany
+func Deseason(points []Point, periods []int) ([]Point, error) {
+	y := LogPrices(points)
+	days := make([]int, len(points))
+	for i, t := range TimeIndex(points) {
+		days[i] = int(math.Round(t))
+	}
+	span := days[len(days)-1] + 1
+	for _, p := range periods {
+		if span < 2*p {
+			return nil, fmt.Errorf("okres %d dni wymaga co najmniej dwóch pełnych cykli danych (jest %d dni)", p, span)
+		}
+		y = removeSeason(y, days, p)
+	}
+
+	adjusted := make([]Point, len(points))
+	for i, d := range points {
+		adjusted[i] = Point{Date: d.Date, Price: math.Exp(y[i])}
+	}
+	return adjusted, nil
+}
+
+// removeSeason wykonuje pętlę wewnętrzną STL dla jednego okresu: trend to średnia
+// krocząca o szerokości okresu, sezonowość to średnie podszeregów cyklicznych
+// (obserwacji o tej samej fazie) z reszty po trendzie, wycentrowane do zera
+func removeSeason(y []float64, days []int, period int) []float64 {
+	seasonal := make([]float64, len(y))
+	for iter := 0; iter < stlIterations; iter++ {
+		deseasoned := make([]float64, len(y))
+		for i := range y {
+			deseasoned[i] = y[i] - seasonal[i]
+		}
+		trend := centeredAverage(deseasoned, days, period)
+
+		sums := make([]float64, period)
+		counts := make([]int, period)
+		for i := range y {
+			phase := days[i] % period
+			sums[phase] += y[i] - trend[i]
+			counts[phase]++
+		}
+		var total float64
+		var phases int
+		for k := range sums {
+			if counts[k] > 0 {
+				sums[k] /= float64(counts[k])
+				total += sums[k]
+				phases++
+			}
+		}
+		offset := total / float64(phases)
+		for i := range y {
+			seasonal[i] = sums[days[i]%period] - offset
+		}
+	}
+
+	out := make([]float64, len(y))
+	for i := range y {
+		out[i] = y[i] - seasonal[i]
+	}
+	return out
+}
+
+// centeredAverage liczy średnią z obserwacji odległych o mniej niż pół okresu (w dniach)
+func centeredAverage(y []float64, days []int, period int) []float64 {
+	out := make([]float64, len(y))
+	half := period / 2
+	lo := 0
+	hi := 0
+	var sum float64
+	for i := range y {
+		for hi < len(y) && days[hi] <= days[i]+half {
+			sum += y[hi]
+			hi++
+		}
+		for days[lo] < days[i]-half {
+			sum -= y[lo]
+			lo++
+		}
+		out[i] = sum / float64(hi-lo)
+	}
+	return out
+}
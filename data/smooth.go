@@ -0,0 +1,133 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Metody wygładzania szeregu cen przed dopasowaniem
+const (
+	smoothNone   = ""
+	smoothMA     = "ma"
+	smoothKalman = "kalman"
+)
+
+// minNoiseVariance zapobiega zerowym wariancjom szumu w modelu lokalnego poziomu
+const minNoiseVariance = 1e-12
+
+// Smooth wygładza logarytmy cen metodą opisaną specyfikacją: "ma:N" (średnia
+// krocząca z N obserwacji) albo "kalman" (wygładzacz lokalnego poziomu). Pusta
+// specyfikacja zwraca dane bez zmian.
+func Smooth(points []Point, spec string) ([]Point, error) {
+	method, arg, _ := strings.Cut(spec, ":")
+	var levels []float64
+	switch method {
+	case smoothNone:
+		return points, nil
+	case smoothMA:
+		window, err := strconv.Atoi(arg)
+		if err != nil || window < 1 {
+			return nil, fmt.Errorf("nieprawidłowa długość średniej kroczącej %q", arg)
+		}
+		levels = movingAverage(LogPrices(points), window)
+	case smoothKalman:
+		if arg != "" {
+			return nil, fmt.Errorf("metoda kalman nie przyjmuje argumentu %q", arg)
+		}
+		levels = localLevel(LogPrices(points))
+	default:
+		return nil, fmt.Errorf("nieznana metoda wygładzania %q (dostępne: ma:N, kalman)", spec)
+	}
+
+	smoothed := make([]Point, len(points))
+	for i, d := range points {
+		smoothed[i] = Point{Date: d.Date, Price: math.Exp(levels[i])}
+	}
+	return smoothed, nil
+}
+
+// TwoSided mówi, czy wygładzanie spec korzysta z obserwacji późniejszych niż wygładzana:
+// wygładzacz Kalmana tak, średnia krocząca wstecz nie
+func TwoSided(spec string) bool {
+	method, _, _ := strings.Cut(spec, ":")
+	return method == smoothKalman
+}
+
+// movingAverage liczy średnią kroczącą wstecz; na początku szeregu okno jest krótsze
+func movingAverage(y []float64, window int) []float64 {
+	out := make([]float64, len(y))
+	var sum float64
+	for i, v := range y {
+		sum += v
+		if i >= window {
+			sum -= y[i-window]
+		}
+		out[i] = sum / float64(min(i+1, window))
+	}
+	return out
+}
+
+// localLevel wygładza szereg modelem lokalnego poziomu
+//
+//	y_t = mu_t + eps_t,   eps_t ~ N(0, r)
+//	mu_t = mu_{t-1} + eta_t,  eta_t ~ N(0, q)
+//
+// filtrem Kalmana i wygładzaczem Raucha-Tunga-Striebela. Wariancje szumów są
+// szacowane z autokowariancji pierwszych różnic: var(dy) = q + 2r, cov(dy_t, dy_{t-1}) = -r.
+func localLevel(y []float64) []float64 {
+	n := len(y)
+	if n < 3 {
+		return append([]float64(nil), y...)
+	}
+	q, r := localLevelNoise(y)
+
+	// Filtr: a - estymata poziomu, p - jej wariancja; *Pred - prognozy jednokrokowe
+	a := make([]float64, n)
+	p := make([]float64, n)
+	aPred := make([]float64, n)
+	pPred := make([]float64, n)
+	aPred[0], pPred[0] = y[0], r
+	for t := 0; t < n; t++ {
+		if t > 0 {
+			aPred[t] = a[t-1]
+			pPred[t] = p[t-1] + q
+		}
+		k := pPred[t] / (pPred[t] + r)
+		a[t] = aPred[t] + k*(y[t]-aPred[t])
+		p[t] = (1 - k) * pPred[t]
+	}
+
+	// Wygładzanie wstecz
+	s := make([]float64, n)
+	s[n-1] = a[n-1]
+	for t := n - 2; t >= 0; t-- {
+		j := p[t] / pPred[t+1]
+		s[t] = a[t] + j*(s[t+1]-aPred[t+1])
+	}
+	return s
+}
+
+func localLevelNoise(y []float64) (q, r float64) {
+	d := make([]float64, len(y)-1)
+	var mean float64
+	for i := range d {
+		d[i] = y[i+1] - y[i]
+		mean += d[i]
+	}
+	mean /= float64(len(d))
+	var g0, g1 float64
+	for i := range d {
+		g0 += (d[i] - mean) * (d[i] - mean)
+		if i > 0 {
+			g1 += (d[i] - mean) * (d[i-1] - mean)
+		}
+	}
+	g0 /= float64(len(d))
+	g1 /= float64(len(d))
+
+	r = math.Max(-g1, minNoiseVariance)
+	q = math.Max(g0-2*r, minNoiseVariance)
+	return q, r
+}
@@ -0,0 +1,34 @@
+package data
+
+import "fmt"
+
+// TotalReturn buduje szereg całkowitej stopy zwrotu (dywidendy reinwestowane w dniu
+// odcięcia) z cen i dywidend na akcję posortowanych według daty. Szereg zaczyna się od
+// pierwszej ceny; dywidenda z dnia bez notowania trafia do najbliższej późniejszej sesji,
+// a dywidendy sprzed pierwszej i po ostatniej obserwacji są pomijane.
+func TotalReturn(points []Point, dividends []Metric) ([]Point, error) {
+	if len(points) == 0 {
+		return points, nil
+	}
+	total := make([]Point, len(points))
+	total[0] = points[0]
+	j := 0
+	for j < len(dividends) && !dividends[j].Date.After(points[0].Date) {
+		j++
+	}
+	for i := 1; i < len(points); i++ {
+		var paid float64
+		for ; j < len(dividends) && !dividends[j].Date.After(points[i].Date); j++ {
+			if dividends[j].Value < 0 {
+				return nil, fmt.Errorf("ujemna dywidenda %v z dnia %s", dividends[j].Value, dividends[j].Date.Format("2006-01-02"))
+			}
+			paid += dividends[j].Value
+		}
+		price := total[i-1].Price * (points[i].Price + paid) / points[i-1].Price
+		if !ValidPrice(price) {
+			return nil, fmt.Errorf("nieprawidłowa wartość szeregu z dywidendami z dnia %s", points[i].Date.Format("2006-01-02"))
+		}
+		total[i] = Point{Date: points[i].Date, Price: price}
+	}
+	return total, nil
+}
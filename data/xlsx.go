@@ -0,0 +1,282 @@
+package data
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// xlsxMaxPart ogranicza rozmiar rozpakowanej części skoroszytu (arkusza, tekstów, stylów)
+const xlsxMaxPart = 256 << 20
+
+type xlsxWorkbook struct {
+	Pr struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRels struct {
+	Rels []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText to tekst komórki: zwykły (t) albo z formatowaniem (kolejne r/t)
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (x xlsxText) String() string {
+	if len(x.Runs) == 0 {
+		return x.T
+	}
+	var b strings.Builder
+	for _, r := range x.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	Xfs []struct {
+		NumFmt int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Style  int      `xml:"s,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadXLSX odczytuje pierwszy arkusz skoroszytu Excel (.xlsx) jako wiersze tekstu. Komórki
+// sformatowane jako data lub czas są zapisywane jako 2006-01-02 albo 2006-01-02T15:04:05,
+// więc pasują do DefaultDateLayouts; liczby i teksty są przepisywane bez zmian.
+func ReadXLSX(r io.ReaderAt, size int64) ([][]string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("skoroszyt xlsx: %w", err)
+	}
+	parts := map[string]*zip.File{}
+	for _, f := range zr.File {
+		parts[strings.TrimPrefix(f.Name, "/")] = f
+	}
+	decode := func(name string, v any) (bool, error) {
+		f, ok := parts[name]
+		if !ok {
+			return false, nil
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return false, err
+		}
+		defer rc.Close()
+		if err := xml.NewDecoder(io.LimitReader(rc, xlsxMaxPart)).Decode(v); err != nil {
+			return false, fmt.Errorf("%s: %w", name, err)
+		}
+		return true, nil
+	}
+
+	var workbook xlsxWorkbook
+	if _, err := decode("xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	sheetPath := "xl/worksheets/sheet1.xml"
+	var rels xlsxRels
+	if _, err := decode("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) > 0 {
+		for _, rel := range rels.Rels {
+			if rel.ID == workbook.Sheets[0].ID {
+				if strings.HasPrefix(rel.Target, "/") {
+					sheetPath = strings.TrimPrefix(rel.Target, "/")
+				} else {
+					sheetPath = path.Join("xl", rel.Target)
+				}
+			}
+		}
+	}
+
+	var shared struct {
+		Items []xlsxText `xml:"si"`
+	}
+	if _, err := decode("xl/sharedStrings.xml", &shared); err != nil {
+		return nil, err
+	}
+	var styles xlsxStyles
+	if _, err := decode("xl/styles.xml", &styles); err != nil {
+		return nil, err
+	}
+	dateStyle := make([]bool, len(styles.Xfs))
+	for i, xf := range styles.Xfs {
+		dateStyle[i] = builtinDateFormat(xf.NumFmt)
+		for _, f := range styles.NumFmts {
+			if f.ID == xf.NumFmt {
+				dateStyle[i] = dateFormatCode(f.Code)
+			}
+		}
+	}
+
+	var sheet xlsxSheet
+	ok, err := decode(sheetPath, &sheet)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("skoroszyt xlsx: brak arkusza %s", sheetPath)
+	}
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		var record []string
+		for _, c := range row.Cells {
+			value := c.Value
+			switch c.Type {
+			case "s":
+				i, err := strconv.Atoi(c.Value)
+				if err != nil || i < 0 || i >= len(shared.Items) {
+					return nil, fmt.Errorf("komórka %s: nieprawidłowy numer tekstu %q", c.Ref, c.Value)
+				}
+				value = shared.Items[i].String()
+			case "inlineStr":
+				value = c.Inline.String()
+			case "", "n":
+				if c.Style >= 0 && c.Style < len(dateStyle) && dateStyle[c.Style] {
+					if serial, err := strconv.ParseFloat(c.Value, 64); err == nil {
+						value = excelDate(serial, workbook.Pr.Date1904)
+					}
+				}
+			}
+			// Puste komórki są w arkuszu pomijane, więc kolumnę wyznacza adres komórki
+			if col, ok := cellColumn(c.Ref); ok && col >= len(record) {
+				record = append(record, make([]string, col-len(record))...)
+			}
+			record = append(record, value)
+		}
+		rows = append(rows, record)
+	}
+	return rows, nil
+}
+
+// cellColumn zwraca numer kolumny (od 0) z adresu komórki, np. 2 dla C7
+func cellColumn(ref string) (int, bool) {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A') + 1
+		n++
+	}
+	if n == 0 || n > 3 {
+		return 0, false
+	}
+	return col - 1, true
+}
+
+// builtinDateFormat rozpoznaje wbudowane formaty liczbowe Excela z datą lub czasem
+func builtinDateFormat(id int) bool {
+	return (id >= 14 && id <= 22) || (id >= 45 && id <= 47)
+}
+
+// dateFormatCode rozpoznaje własny format z datą lub czasem: po pominięciu tekstów w
+// cudzysłowach, znaków poprzedzonych \ i sekcji w nawiasach kwadratowych (kolor, waluta)
+// zawiera symbol dnia, roku, godziny lub sekundy
+func dateFormatCode(code string) bool {
+	quoted, bracket, escaped := false, false, false
+	for _, r := range strings.ToLower(code) {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '[':
+			bracket = true
+		case r == ']':
+			bracket = false
+		case bracket:
+		case r == 'd' || r == 'y' || r == 'h' || r == 's':
+			return true
+		}
+	}
+	return false
+}
+
+// excelDate zamienia numer seryjny daty Excela (dni od 1899-12-30, a w systemie 1904 od
+// 1904-01-01) na tekst; czas jest zaokrąglany do sekundy
+func excelDate(serial float64, date1904 bool) string {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	t := epoch.Add(time.Duration(math.Round(serial*86400)) * time.Second)
+	if t.Truncate(24 * time.Hour).Equal(t) {
+		return t.Format(time.DateOnly)
+	}
+	return t.Format("2006-01-02T15:04:05")
+}
+
+// ParseXLSX wczytuje notowania z pierwszego arkusza skoroszytu xlsx tak jak Parse z pliku
+// CSV: pierwszy wiersz to nagłówek, a kolumny wskazują popts.DateColumn i popts.PriceColumn
+// (separator popts.Delimiter nie ma tu znaczenia)
+func ParseXLSX(r io.ReaderAt, size int64, popts ParseOptions) ([]Point, ParseReport, error) {
+	rows, err := ReadXLSX(r, size)
+	if err != nil {
+		return nil, ParseReport{Layouts: map[string]int{}}, err
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, ParseReport{Layouts: map[string]int{}}, err
+	}
+	popts.Delimiter = ','
+	return Parse(&buf, popts)
+}
+
+// IsXLSX rozpoznaje skoroszyt xlsx po sygnaturze archiwum ZIP
+func IsXLSX(head []byte) bool {
+	return bytes.HasPrefix(head, []byte("PK\x03\x04"))
+}
+
+func loadXLSX(filePath string, popts ParseOptions) ([]Point, ParseReport, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, ParseReport{}, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, ParseReport{}, err
+	}
+	return ParseXLSX(file, info.Size(), popts)
+}
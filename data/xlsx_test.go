@@ -0,0 +1,109 @@
+package data
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testWorkbook buduje skoroszyt xlsx z podanymi częściami xl/
+func testWorkbook(t *testing.T, workbookPr, sheet string) []byte {
+	t.Helper()
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+ xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` + workbookPr +
+			`<sheets><sheet name="Kursy" sheetId="1" r:id="rId3"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Target="styles.xml"/><Relationship Id="rId3" Target="worksheets/kursy.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>Data</t></si><si><t>Kurs</t></si><si><r><t>uwa</t></r><r><t>ga</t></r></si></sst>`,
+		// Style: 0 ogólny, 1 wbudowana data (14), 2 własny format z czasem, 3 własny format liczbowy
+		"xl/styles.xml": `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts><numFmt numFmtId="164" formatCode="yyyy\-mm\-dd hh:mm"/><numFmt numFmtId="165" formatCode="[Red]#,##0.00&quot; zł&quot;"/></numFmts>
+<cellXfs><xf numFmtId="0"/><xf numFmtId="14"/><xf numFmtId="164"/><xf numFmtId="165"/></cellXfs></styleSheet>`,
+		"xl/worksheets/kursy.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + sheet + `</sheetData></worksheet>`,
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadXLSX(t *testing.T) {
+	sheet := `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2" s="1"><v>45727</v></c><c r="B2" t="s"><v>2</v></c><c r="C2" s="3"><v>82000.5</v></c></row>
+<row r="3"><c r="A3" s="2"><v>45728.5</v></c><c r="C3"><v>83000</v></c></row>
+<row r="4"><c r="A4" t="inlineStr"><is><t>2025-03-13</t></is></c><c r="C4"><v>84000</v></c></row>`
+	content := testWorkbook(t, "", sheet)
+	if !IsXLSX(content) {
+		t.Fatal("IsXLSX nie rozpoznał skoroszytu")
+	}
+	rows, err := ReadXLSX(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"Data", "", "Kurs"},
+		{"2025-03-11", "uwaga", "82000.5"},
+		{"2025-03-12T12:00:00", "", "83000"},
+		{"2025-03-13", "", "84000"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("wiersze %q, oczekiwano %q", rows, want)
+	}
+
+	points, report, err := ParseXLSX(bytes.NewReader(content), int64(len(content)), ParseOptions{PriceColumn: 2, Delimiter: ';'})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 3 || report.Skipped() != 0 {
+		t.Fatalf("%d notowań, %s", len(points), report)
+	}
+	if got := points[1]; !got.Date.Equal(time.Date(2025, 3, 12, 12, 0, 0, 0, time.UTC)) || got.Price != 83000 {
+		t.Errorf("drugie notowanie %v %g", got.Date, got.Price)
+	}
+
+	// W systemie dat 1904 ten sam numer seryjny oznacza datę o 1462 dni późniejszą
+	content = testWorkbook(t, `<workbookPr date1904="1"/>`, `<row r="1"><c r="A1" s="1"><v>44265</v></c></row>`)
+	rows, err = ReadXLSX(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0][0] != "2025-03-11" {
+		t.Errorf("data w systemie 1904: %q", rows)
+	}
+
+	if _, err := ReadXLSX(bytes.NewReader([]byte("data;cena\n")), 10); err == nil {
+		t.Error("plik CSV przyjęty jako skoroszyt")
+	}
+}
+
+func TestDateFormatCode(t *testing.T) {
+	for code, want := range map[string]bool{
+		"yyyy-mm-dd":           true,
+		"d/m/yy h:mm":          true,
+		"[$-409]mmmm d, yyyy":  true,
+		"hh:mm:ss":             true,
+		"0.00":                 false,
+		"#,##0":                false,
+		`[Red]#,##0" days"`:    false,
+		`0\d`:                  false,
+		"General":              false,
+		`[$€-407] #,##0.00;-0`: false,
+	} {
+		if got := dateFormatCode(code); got != want {
+			t.Errorf("dateFormatCode(%q) = %t, oczekiwano %t", code, got, want)
+		}
+	}
+}
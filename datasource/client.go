@@ -0,0 +1,37 @@
+package datasource
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+)
+
+// Open uruchamia plik wykonywalny wtyczki i zwraca połączone źródło danych.
+// Wywołujący musi zamknąć proces wtyczki funkcją zwróconą jako drugi wynik.
+func Open(path string) (Source, func(), error) {
+	client := plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig: Handshake,
+		Plugins:         map[string]plugin.Plugin{PluginName: &Plugin{}},
+		Cmd:             exec.Command(path),
+		Logger:          hclog.New(&hclog.LoggerOptions{Name: "datasource", Level: hclog.Warn}),
+	})
+
+	rpcClient, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return nil, nil, err
+	}
+	raw, err := rpcClient.Dispense(PluginName)
+	if err != nil {
+		client.Kill()
+		return nil, nil, err
+	}
+	source, ok := raw.(Source)
+	if !ok {
+		client.Kill()
+		return nil, nil, fmt.Errorf("wtyczka %s nie implementuje datasource.Source", path)
+	}
+	return source, client.Kill, nil
+}
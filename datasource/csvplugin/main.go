@@ -0,0 +1,55 @@
+// Przykładowa wtyczka źródła danych: czyta pliki <symbol>.csv z kolumnami date,price
+// z katalogu wskazanego zmienną LPPL_CSV_DIR. Służy jako szablon dla własnych wtyczek.
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"cw3/datasource"
+)
+
+type csvSource struct {
+	dir string
+}
+
+func (s csvSource) Fetch(req datasource.Request) ([]datasource.Point, error) {
+	file, err := os.Open(filepath.Join(s.dir, req.Symbol+".csv"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var points []datasource.Point
+	for _, record := range records[1:] {
+		date, err := time.Parse("2006-01-02", record[0])
+		if err != nil {
+			continue
+		}
+		price, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			continue
+		}
+		if (!req.From.IsZero() && date.Before(req.From)) || (!req.To.IsZero() && date.After(req.To)) {
+			continue
+		}
+		points = append(points, datasource.Point{Date: date, Price: price})
+	}
+	return points, nil
+}
+
+func main() {
+	dir := os.Getenv("LPPL_CSV_DIR")
+	if dir == "" {
+		dir = "."
+	}
+	datasource.Serve(csvSource{dir: dir})
+}
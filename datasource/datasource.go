@@ -0,0 +1,77 @@
+// Package datasource definiuje interfejs zewnętrznych źródeł danych uruchamianych jako
+// osobne procesy przez hashicorp/go-plugin, dzięki czemu wewnętrzne systemy danych
+// rynkowych można podłączyć bez wkompilowywania ich w program.
+package datasource
+
+import (
+	"net/rpc"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+)
+
+type Point struct {
+	Date  time.Time
+	Price float64
+}
+
+// Source zwraca notowania symbolu z przedziału [From, To]; zerowe daty oznaczają brak ograniczenia
+type Source interface {
+	Fetch(req Request) ([]Point, error)
+}
+
+type Request struct {
+	Symbol string
+	From   time.Time
+	To     time.Time
+}
+
+// Handshake musi być identyczny w programie i we wtyczce
+var Handshake = plugin.HandshakeConfig{
+	ProtocolVersion:  1,
+	MagicCookieKey:   "LPPL_DATASOURCE_PLUGIN",
+	MagicCookieValue: "lppl",
+}
+
+const PluginName = "datasource"
+
+// Serve uruchamia wtyczkę; wywoływane z funkcji main programu wtyczki
+func Serve(impl Source) {
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: Handshake,
+		Plugins:         map[string]plugin.Plugin{PluginName: &Plugin{Impl: impl}},
+	})
+}
+
+// Plugin łączy interfejs Source z transportem net/rpc biblioteki go-plugin
+type Plugin struct {
+	Impl Source
+}
+
+func (p *Plugin) Server(*plugin.MuxBroker) (interface{}, error) {
+	return &rpcServer{impl: p.Impl}, nil
+}
+
+func (Plugin) Client(b *plugin.MuxBroker, c *rpc.Client) (interface{}, error) {
+	return &rpcClient{client: c}, nil
+}
+
+type rpcClient struct {
+	client *rpc.Client
+}
+
+func (c *rpcClient) Fetch(req Request) ([]Point, error) {
+	var points []Point
+	err := c.client.Call("Plugin.Fetch", req, &points)
+	return points, err
+}
+
+type rpcServer struct {
+	impl Source
+}
+
+func (s *rpcServer) Fetch(req Request, resp *[]Point) error {
+	points, err := s.impl.Fetch(req)
+	*resp = points
+	return err
+}
@@ -0,0 +1,50 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"cw3/data"
+)
+
+// fredGraphCSV udostępnia bez klucza szeregi FRED w postaci CSV data,wartość
+const fredGraphCSV = "https://fred.stlouisfed.org/graph/fredgraph.csv"
+
+// cpiSourceFRED to nazwa źródła CPI pobieranego z FRED; inna wartość flagi -deflate
+// oznacza plik CSV z odczytami wskaźnika
+const cpiSourceFRED = "fred"
+
+// fetchCPI pobiera z FRED szereg wskaźnika cen series (np. CPIAUCSL, miesięczny CPI
+// dla USA) od roku przed start, żeby pierwsze obserwacje miały odczyt
+func fetchCPI(series string, start time.Time) ([]data.Metric, error) {
+	q := url.Values{"id": {series}, "cosd": {start.AddDate(-1, 0, 0).Format("2006-01-02")}}
+	resp, err := httpClient.Get(fredGraphCSV + "?" + q.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("CPI %s: %w", series, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CPI %s: %s", series, resp.Status)
+	}
+	return data.ReadMetric(resp.Body, "FRED "+series)
+}
+
+// deflatePrices zamienia ceny na realne wskaźnikiem ze źródła source (cpiSourceFRED
+// albo ścieżka pliku CSV z kolumnami data,CPI); zwraca też datę odczytu bazowego
+func deflatePrices(points []data.Point, source, series string) ([]data.Point, time.Time, error) {
+	var cpi []data.Metric
+	var err error
+	if source == cpiSourceFRED {
+		cpi, err = fetchCPI(series, points[0].Date)
+	} else {
+		cpi, err = data.LoadMetric(source)
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return data.Deflate(points, cpi)
+}
@@ -0,0 +1,86 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"cw3/data"
+)
+
+// binanceFuturesAPI to publiczne API kontraktów wieczystych Binance USDⓈ-M
+const binanceFuturesAPI = "https://fapi.binance.com"
+
+// fetchFunding pobiera stopy finansowania kontraktu wieczystego (co 8 godzin) z przedziału [from, to]
+func fetchFunding(symbol string, from, to time.Time) ([]data.Metric, error) {
+	var rows []struct {
+		FundingTime int64  `json:"fundingTime"`
+		FundingRate string `json:"fundingRate"`
+	}
+	q := url.Values{
+		"symbol":    {symbol},
+		"startTime": {strconv.FormatInt(from.UnixMilli(), 10)},
+		"endTime":   {strconv.FormatInt(to.Add(24*time.Hour).UnixMilli(), 10)},
+		"limit":     {"1000"},
+	}
+	if err := getJSON(binanceFuturesAPI+"/fapi/v1/fundingRate?"+q.Encode(), &rows); err != nil {
+		return nil, fmt.Errorf("stopy finansowania %s: %w", symbol, err)
+	}
+
+	metrics := make([]data.Metric, 0, len(rows))
+	for _, r := range rows {
+		rate, err := strconv.ParseFloat(r.FundingRate, 64)
+		if err != nil {
+			return nil, fmt.Errorf("stopy finansowania %s: %w", symbol, err)
+		}
+		metrics = append(metrics, data.Metric{Date: time.UnixMilli(r.FundingTime).UTC(), Value: 100 * rate})
+	}
+	return metrics, nil
+}
+
+// fetchOpenInterest pobiera dzienną wartość otwartych pozycji (w USD). Binance udostępnia
+// tę historię tylko za ostatnie 30 dni, więc starsze okna mają pusty panel.
+func fetchOpenInterest(symbol string, from, to time.Time) ([]data.Metric, error) {
+	var rows []struct {
+		Timestamp int64  `json:"timestamp"`
+		Value     string `json:"sumOpenInterestValue"`
+	}
+	q := url.Values{
+		"symbol":    {symbol},
+		"period":    {"1d"},
+		"startTime": {strconv.FormatInt(from.UnixMilli(), 10)},
+		"endTime":   {strconv.FormatInt(to.Add(24*time.Hour).UnixMilli(), 10)},
+		"limit":     {"500"},
+	}
+	if err := getJSON(binanceFuturesAPI+"/futures/data/openInterestHist?"+q.Encode(), &rows); err != nil {
+		return nil, fmt.Errorf("otwarte pozycje %s: %w", symbol, err)
+	}
+
+	metrics := make([]data.Metric, 0, len(rows))
+	for _, r := range rows {
+		v, err := strconv.ParseFloat(r.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("otwarte pozycje %s: %w", symbol, err)
+		}
+		metrics = append(metrics, data.Metric{Date: time.UnixMilli(r.Timestamp).UTC(), Value: v / 1e9})
+	}
+	return metrics, nil
+}
+
+// getJSON wykonuje zapytanie GET i dekoduje odpowiedź JSON do v
+func getJSON(u string, v any) error {
+	resp, err := httpClient.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,351 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	ossignal "os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cw3/data"
+	"cw3/fit"
+	"cw3/lppl"
+)
+
+// scanTask to fragment historii wskaźnika pewności bańki liczony przez jednego pracownika:
+// dni końcowe First..Last (indeksy w Points) jednego symbolu. Points zawiera tylko
+// obserwacje potrzebne do okien tych dni, więc zadanie nie zależy od plików pracownika.
+type scanTask struct {
+	ID        int             `json:"id"`
+	Symbol    string          `json:"symbol"`
+	Points    []data.Point    `json:"points"`
+	First     int             `json:"first"`
+	Last      int             `json:"last"`
+	MinPoints int             `json:"min_points"`
+	MaxPoints int             `json:"max_points"`
+	Step      int             `json:"step"`
+	Options   lppl.FitOptions `json:"options"`
+}
+
+// coordinator rozdziela zadania między pracowników zapytaniami POST /lease i zbiera
+// wyniki z POST /tasks/{id}. Zadanie niezwrócone w czasie lease wraca do kolejki.
+type coordinator struct {
+	token string
+	lease time.Duration
+
+	mu      sync.Mutex
+	tasks   []*scanTask
+	pending []int
+	leased  map[int]time.Time
+	results map[int][]fit.QualifiedFraction
+	done    chan struct{}
+}
+
+func newCoordinator(tasks []*scanTask, lease time.Duration, token string) *coordinator {
+	c := &coordinator{token: token, lease: lease, tasks: tasks, leased: map[int]time.Time{},
+		results: map[int][]fit.QualifiedFraction{}, done: make(chan struct{})}
+	for _, t := range tasks {
+		c.pending = append(c.pending, t.ID)
+	}
+	if len(tasks) == 0 {
+		close(c.done)
+	}
+	return c
+}
+
+func (c *coordinator) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if c.token != "" && r.Header.Get("Authorization") != "Bearer "+c.token {
+		writeError(w, http.StatusUnauthorized, errors.New("nieprawidłowy token"))
+		return false
+	}
+	return true
+}
+
+// handleLease wydaje następne zadanie: 200 z zadaniem, 204, gdy wszystkie są wydane, ale
+// nie wszystkie zwrócone, i 410 po zakończeniu skanu
+func (c *coordinator) handleLease(w http.ResponseWriter, r *http.Request) {
+	if !c.authorized(w, r) {
+		return
+	}
+	c.mu.Lock()
+	now := time.Now()
+	for id, at := range c.leased {
+		if now.Sub(at) > c.lease {
+			log.Printf("Zadanie %d: brak wyniku po %s, wraca do kolejki", id, c.lease)
+			delete(c.leased, id)
+			c.pending = append(c.pending, id)
+		}
+	}
+	finished := len(c.results) == len(c.tasks)
+	var task *scanTask
+	if len(c.pending) > 0 {
+		id := c.pending[0]
+		c.pending = c.pending[1:]
+		c.leased[id] = now
+		task = c.tasks[id]
+	}
+	c.mu.Unlock()
+
+	switch {
+	case task != nil:
+		writeJSON(w, http.StatusOK, task)
+	case finished:
+		w.WriteHeader(http.StatusGone)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (c *coordinator) handleResult(w http.ResponseWriter, r *http.Request) {
+	if !c.authorized(w, r) {
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 0 || id >= len(c.tasks) {
+		writeError(w, http.StatusNotFound, errors.New("nieznane zadanie"))
+		return
+	}
+	var series []fit.QualifiedFraction
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxFitBody)).Decode(&series); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.results[id]; ok {
+		// Wynik zadania wydanego ponownie po przekroczeniu czasu; pierwszy wystarczy
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	delete(c.leased, id)
+	c.pending = removeID(c.pending, id)
+	c.results[id] = series
+	log.Printf("Zadanie %d (%s): gotowe %d z %d", id, c.tasks[id].Symbol, len(c.results), len(c.tasks))
+	if len(c.results) == len(c.tasks) {
+		close(c.done)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func removeID(ids []int, id int) []int {
+	out := ids[:0]
+	for _, v := range ids {
+		if v != id {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// series składa wyniki zadań w szeregi kolejnych symboli
+func (c *coordinator) series() map[string][]fit.QualifiedFraction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := map[string][]fit.QualifiedFraction{}
+	for id, s := range c.results {
+		sym := c.tasks[id].Symbol
+		out[sym] = append(out[sym], s...)
+	}
+	for _, s := range out {
+		sort.Slice(s, func(i, j int) bool { return s[i].Date.Before(s[j].Date) })
+	}
+	return out
+}
+
+// scanTasks dzieli dni końcowe szeregu na zadania po batch dni
+func scanTasks(symbol string, points []data.Point, opts lppl.FitOptions, minPoints, maxPoints, step, batch, firstID int) []*scanTask {
+	var tasks []*scanTask
+	for first := minPoints - 1; first < len(points); first += batch {
+		last := min(first+batch-1, len(points)-1)
+		start := 0
+		if maxPoints > 0 {
+			start = max(0, first-maxPoints+1)
+		}
+		tasks = append(tasks, &scanTask{
+			ID: firstID + len(tasks), Symbol: symbol, Points: points[start : last+1],
+			First: first - start, Last: last - start,
+			MinPoints: minPoints, MaxPoints: maxPoints, Step: step, Options: opts,
+		})
+	}
+	return tasks
+}
+
+// runCoordinator obsługuje polecenie coordinator: dzieli historię wskaźnika pewności bańki
+// dla plików wejściowych na zadania i czeka na wyniki od pracowników
+func runCoordinator(args []string) error {
+	fs := flag.NewFlagSet("coordinator", flag.ExitOnError)
+	addr := fs.String("addr", ":9090", "adres, na którym koordynator przyjmuje pracowników")
+	batch := fs.Int("batch", 20, "liczba dni końcowych w jednym zadaniu")
+	lease := fs.Duration("lease", 30*time.Minute, "czas na zwrot wyniku, po którym zadanie trafia do innego pracownika")
+	token := fs.String("token", "", "wspólny token pracowników (nagłówek Authorization: Bearer)")
+	out := fs.String("out", "{symbol}_fractions.csv", "plik wynikowy każdego symbolu (CSV lub JSON wg rozszerzenia); {symbol} to nazwa pliku wejściowego")
+	configPath := fs.String("fit", "", "plik ustawień dopasowania: YAML jak -config albo JSON z polami lppl.FitOptions (pusty - ustawienia domyślne)")
+	minWindow := fs.Int("min-window", 30, "najkrótsze okno (w obserwacjach)")
+	maxWindow := fs.Int("max-window", 0, "najdłuższe okno (0 - cała historia do dnia końcowego)")
+	step := fs.Int("window-step", 5, "krok długości okna")
+	popts := data.DefaultParseOptions()
+	csvFlags(fs, &popts)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Użycie: %s coordinator [flagi] plik.csv ...\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("coordinator wymaga co najmniej jednego pliku CSV")
+	}
+	if *batch < 1 || *minWindow < lppl.ParamCount {
+		return fmt.Errorf("-batch musi być dodatni, a -min-window co najmniej %d", lppl.ParamCount)
+	}
+	opts, err := loadFitOptions(*configPath)
+	if err != nil {
+		return err
+	}
+
+	var tasks []*scanTask
+	for _, path := range fs.Args() {
+		points, _, err := data.Load(path, popts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		symbol := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		t := scanTasks(symbol, points, opts, *minWindow, *maxWindow, *step, *batch, len(tasks))
+		log.Printf("%s: %d dni końcowych w %d zadaniach", symbol, max(0, len(points)-*minWindow+1), len(t))
+		tasks = append(tasks, t...)
+	}
+	c := newCoordinator(tasks, *lease, *token)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /lease", c.handleLease)
+	mux.HandleFunc("POST /tasks/{id}", c.handleResult)
+	srv := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := ossignal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		select {
+		case <-c.done:
+			// Pracownicy dostają jeszcze przez chwilę 410, żeby zakończyć się sami
+			time.Sleep(workerPoll)
+		case <-ctx.Done():
+		}
+		srv.Shutdown(context.Background())
+	}()
+	log.Printf("Koordynator na %s: %d zadań", *addr, len(tasks))
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	select {
+	case <-c.done:
+	default:
+		return errors.New("przerwano przed zebraniem wszystkich wyników")
+	}
+	for symbol, series := range c.series() {
+		path := strings.ReplaceAll(*out, "{symbol}", siteDirName(symbol))
+		if err := fit.WriteFractions(path, series); err != nil {
+			return err
+		}
+		log.Printf("%s: wskaźnik pewności dla %d dni zapisany w %s", symbol, len(series), path)
+	}
+	return nil
+}
+
+// workerPoll to odstęp między pytaniami pracownika, gdy koordynator nie ma wolnych zadań
+const workerPoll = 5 * time.Second
+
+// runWorker obsługuje polecenie worker: pobiera zadania od koordynatora, liczy je i
+// odsyła wyniki, aż koordynator zgłosi koniec skanu
+func runWorker(args []string) error {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	addr := fs.String("coordinator", "http://localhost:9090", "adres koordynatora")
+	token := fs.String("token", "", "token koordynatora")
+	workers := fs.Int("workers", 0, "liczba równoległych dopasowań (0 - liczba procesorów)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Użycie: %s worker -coordinator http://host:9090 [flagi]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	base := strings.TrimSuffix(*addr, "/")
+
+	ctx, stop := ossignal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	call := func(path string, body []byte) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if *token != "" {
+			req.Header.Set("Authorization", "Bearer "+*token)
+		}
+		return httpClient.Do(req)
+	}
+
+	var completed int
+	for ctx.Err() == nil {
+		resp, err := call("/lease", nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Printf("Koordynator: %v", err)
+			sleepCtx(ctx, workerPoll)
+			continue
+		}
+		var task scanTask
+		switch resp.StatusCode {
+		case http.StatusOK:
+			err = json.NewDecoder(resp.Body).Decode(&task)
+			resp.Body.Close()
+			if err != nil {
+				return err
+			}
+		case http.StatusGone:
+			resp.Body.Close()
+			log.Printf("Skan zakończony; policzono %d zadań", completed)
+			return nil
+		case http.StatusNoContent:
+			resp.Body.Close()
+			sleepCtx(ctx, workerPoll)
+			continue
+		default:
+			resp.Body.Close()
+			return fmt.Errorf("koordynator: %s", resp.Status)
+		}
+
+		started := time.Now()
+		opts := task.Options
+		opts.Workers = *workers
+		series := fit.FractionsBetween(task.Points, opts, task.MinPoints, task.MaxPoints, task.Step, task.First, task.Last)
+		body, err := json.Marshal(series)
+		if err != nil {
+			return err
+		}
+		resp, err = call("/tasks/"+strconv.Itoa(task.ID), body)
+		if err != nil {
+			// Zadanie wróci do kolejki po upływie czasu dzierżawy
+			log.Printf("Zadanie %d: wysyłka wyniku: %v", task.ID, err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			log.Printf("Zadanie %d: koordynator odrzucił wynik: %s", task.ID, resp.Status)
+			continue
+		}
+		completed++
+		log.Printf("Zadanie %d (%s, %d dni): %s", task.ID, task.Symbol, len(series), time.Since(started).Round(time.Second))
+	}
+	return ctx.Err()
+}
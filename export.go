@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"time"
+
+	"cw3/data"
+	"cw3/internal/safe"
+	"cw3/lppl"
+)
+
+// fitSummary to wynik dopasowania w postaci zwracanej przez powiązania WASM i C
+type fitSummary struct {
+	Params     map[string]float64 `json:"params"`
+	Cost       float64            `json:"cost"`
+	Qualified  bool               `json:"qualified"`
+	Violations []string           `json:"violations"`
+	Resolution string             `json:"resolution,omitempty"`
+	Provenance provenance         `json:"provenance"`
+	Dates      []time.Time        `json:"dates"`
+	Prices     []float64          `json:"prices"`
+	Model      []float64          `json:"model"`
+}
+
+func summarizeFit(points []data.Point, best lppl.Result) fitSummary {
+	p := best.Params
+	timeIndex := data.TimeIndex(points)
+
+	s := fitSummary{
+		Params: map[string]float64{
+			"tc": p[0], "m": p[1], "omega": p[2],
+			"A": p[3], "B": p[4], "C": p[5], "phi": p[6],
+		},
+		Cost:       best.Cost,
+		Qualified:  best.Qualified(),
+		Violations: best.Violations,
+		Resolution: lppl.ClassifyResolution(best),
+	}
+	for i, point := range points {
+		s.Dates = append(s.Dates, point.Date)
+		s.Prices = append(s.Prices, point.Price)
+		s.Model = append(s.Model, math.Exp(lppl.Model(timeIndex[i], p[0], p[1], p[2], p[3], p[4], p[5], p[6])))
+	}
+	return s
+}
+
+// fitCSV wczytuje dane z r i dopasowuje model; optionsJSON może nadpisać pola lppl.FitOptions
+func fitCSV(r io.Reader, optionsJSON string) (summary fitSummary, err error) {
+	err = safe.Guard("dopasowanie", func() error {
+		summary, err = fitCSVUnguarded(r, optionsJSON)
+		return err
+	})
+	return summary, err
+}
+
+func fitCSVUnguarded(r io.Reader, optionsJSON string) (fitSummary, error) {
+	opts := lppl.DefaultFitOptions()
+	if optionsJSON != "" {
+		if err := json.Unmarshal([]byte(optionsJSON), &opts); err != nil {
+			return fitSummary{}, err
+		}
+	}
+
+	points, _, err := data.Parse(r, data.DefaultParseOptions())
+	if err != nil {
+		return fitSummary{}, err
+	}
+	if len(points) == 0 {
+		return fitSummary{}, errors.New("plik nie zawiera poprawnych wierszy")
+	}
+
+	best, err := lppl.Fit(points, opts)
+	if err != nil {
+		return fitSummary{}, err
+	}
+	s := summarizeFit(points, best)
+	s.Provenance = newProvenance("csv", points, opts)
+	s.Provenance.Pipeline = []transformStep{newStep("wczytanie", "csv", points)}
+	return s, nil
+}
+
+// fitCSVJSON zwraca wynik fitCSV lub komunikat błędu jako dokument JSON
+func fitCSVJSON(r io.Reader, optionsJSON string) string {
+	var out []byte
+	summary, err := fitCSV(r, optionsJSON)
+	if err != nil {
+		out, _ = json.Marshal(map[string]string{"error": err.Error()})
+	} else {
+		out, _ = json.Marshal(summary)
+	}
+	return string(out)
+}
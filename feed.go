@@ -0,0 +1,124 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cw3/internal/atomicfile"
+)
+
+// feedLimit to największa liczba wpisów w kanale (najnowsze)
+const feedLimit = 50
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomAuthor to autor kanału; RFC 4287 wymaga go w kanale, gdy nie mają go wszystkie wpisy
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string    `xml:"title"`
+	ID      string    `xml:"id"`
+	Updated string    `xml:"updated"`
+	Link    *atomLink `xml:"link,omitempty"`
+	Summary string    `xml:"summary"`
+	time    time.Time
+}
+
+// feedEntries wybiera z historii symbolu (od najnowszej analizy) wpisy kanału: analizy
+// z kwalifikowaną bańką dodatnią i przesunięcia tc o co najmniej tcChange dni względem
+// poprzedniej analizy
+func feedEntries(s siteSymbol, tcChange float64, baseURL string) []atomEntry {
+	var entries []atomEntry
+	var link *atomLink
+	if baseURL != "" {
+		link = &atomLink{Href: baseURL + "/" + s.Dir + "/index.html"}
+	}
+	for i, rec := range s.History {
+		add := func(kind, title, summary string) {
+			entries = append(entries, atomEntry{
+				Title: title,
+				// Ponowna analiza tych samych danych z -force ma ten sam skrót, więc
+				// identyfikator obejmuje też czas analizy
+				ID:      "urn:lppl:" + rec.Hash + ":" + strconv.FormatInt(rec.Time.UnixNano(), 10) + ":" + kind,
+				Updated: rec.Time.UTC().Format(time.RFC3339),
+				Link:    link,
+				Summary: summary,
+				time:    rec.Time,
+			})
+		}
+		if rec.Qualified && rec.Confidence > 0 {
+			add("signal", fmt.Sprintf("%s: bańka dodatnia, tc %s", s.Name, rec.Tc.Format(time.DateOnly)),
+				fmt.Sprintf("Dopasowanie z danych do %s spełnia filtry; pewność bańki %.2f, koszt %.6f.",
+					rec.End.Format(time.DateOnly), rec.Confidence, rec.Cost))
+		}
+		if i+1 < len(s.History) {
+			prev := s.History[i+1]
+			if shift := rec.Tc.Sub(prev.Tc).Hours() / 24; !prev.Tc.IsZero() && math.Abs(shift) >= tcChange {
+				add("tc", fmt.Sprintf("%s: tc przesunięte z %s na %s", s.Name, prev.Tc.Format(time.DateOnly), rec.Tc.Format(time.DateOnly)),
+					fmt.Sprintf("Zmiana tc o %+.0f dni między analizami danych do %s i %s.",
+						shift, prev.End.Format(time.DateOnly), rec.End.Format(time.DateOnly)))
+			}
+		}
+	}
+	return entries
+}
+
+// writeFeed zapisuje kanał Atom z wpisami wszystkich symboli
+func writeFeed(path string, symbols []siteSymbol, tcChange float64, baseURL, author string, now time.Time) error {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	var entries []atomEntry
+	for _, s := range symbols {
+		entries = append(entries, feedEntries(s, tcChange, baseURL)...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].time.After(entries[j].time) })
+	if len(entries) > feedLimit {
+		entries = entries[:feedLimit]
+	}
+
+	feed := atomFeed{
+		Title:   "Sygnały baniek LPPL",
+		ID:      "urn:lppl:feed",
+		Updated: now.UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: author},
+		Entries: entries,
+	}
+	if baseURL != "" {
+		feed.ID = baseURL + "/atom.xml"
+		feed.Links = []atomLink{{Href: baseURL + "/atom.xml", Rel: "self"}, {Href: baseURL + "/index.html"}}
+	}
+
+	f, err := atomicfile.Replace(path)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+	f.WriteString(xml.Header)
+	enc := xml.NewEncoder(f)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return err
+	}
+	return f.Commit()
+}
@@ -0,0 +1,142 @@
+package fit
+
+import (
+	"math"
+	"time"
+
+	"cw3/data"
+	"cw3/internal/stats"
+	"cw3/lppl"
+)
+
+// Crash to spadek ceny od szczytu Peak o co najmniej zadany próg, stwierdzony w dniu Confirmed
+type Crash struct {
+	Peak, Confirmed time.Time
+	// Spadek od szczytu do dołka jako ułamek ceny szczytowej
+	Depth float64
+}
+
+// FindCrashes wyznacza spadki o co najmniej threshold (np. 0.2) od bieżącego maksimum.
+// Spadek trwa do dołka, po którym cena wraca powyżej szczytu; kolejny spadek liczony jest
+// od nowego maksimum.
+func FindCrashes(points []data.Point, threshold float64) []Crash {
+	var crashes []Crash
+	var current *Crash
+	peak := 0
+	for i, p := range points {
+		if p.Price >= points[peak].Price {
+			if current != nil {
+				crashes = append(crashes, *current)
+				current = nil
+			}
+			peak = i
+			continue
+		}
+		depth := 1 - p.Price/points[peak].Price
+		switch {
+		case current != nil:
+			current.Depth = math.Max(current.Depth, depth)
+		case depth >= threshold:
+			current = &Crash{Peak: points[peak].Date, Confirmed: p.Date, Depth: depth}
+		}
+	}
+	if current != nil {
+		crashes = append(crashes, *current)
+	}
+	return crashes
+}
+
+// BacktestFit to dopasowanie okna kończącego się w dniu End zestawione z pierwszym
+// spadkiem stwierdzonym po End
+type BacktestFit struct {
+	End       time.Time
+	Tc        time.Time
+	Cost      float64
+	Qualified bool
+	// Positive oznacza bańkę dodatnią (B < 0), po której oczekiwany jest spadek
+	Positive bool
+	// Najbliższy późniejszy spadek (nil, jeśli do końca danych go nie było) i różnica
+	// tc względem jego szczytu w dniach
+	Crash      *Crash
+	ErrorDays  float64
+	Hit, Alarm bool
+}
+
+// BacktestSummary podsumowuje trafność przewidywań z RollingBacktest
+type BacktestSummary struct {
+	Fits, Failed, Qualified int
+	// Alarms to kwalifikowane bańki dodatnie, Hits - te z nich, których tc wypadło w
+	// granicach tolerancji od szczytu najbliższego spadku
+	Alarms, Hits int
+	Crashes      int
+	// Anticipated to liczba spadków poprzedzonych co najmniej jednym trafionym alarmem
+	Anticipated int
+	// Mediana |tc - szczyt| w dniach dla alarmów, po których nastąpił spadek
+	MedianAbsError float64
+}
+
+// RollingBacktest przesuwa okno o długości window obserwacji co step, dopasowuje model
+// i sprawdza, czy kwalifikowane bańki dodatnie zapowiadały spadki o co najmniej threshold:
+// alarm jest trafiony, gdy tc różni się od szczytu najbliższego późniejszego spadku
+// najwyżej o tolerance dni
+func RollingBacktest(points []data.Point, opts lppl.FitOptions, window, step int, threshold, tolerance float64) ([]BacktestFit, BacktestSummary) {
+	if step < 1 {
+		step = 1
+	}
+	crashes := FindCrashes(points, threshold)
+	sum := BacktestSummary{Crashes: len(crashes), MedianAbsError: math.NaN()}
+	anticipated := make([]bool, len(crashes))
+	var fits []BacktestFit
+	var absErrors []float64
+	for end := window - 1; end < len(points); end += step {
+		w := points[end+1-window : end+1]
+		best, err := lppl.Fit(w, opts)
+		if err != nil {
+			sum.Failed++
+			continue
+		}
+		f := BacktestFit{
+			End:       w[len(w)-1].Date,
+			Tc:        w[0].Date.Add(time.Duration(best.Params[0] * 24 * float64(time.Hour))),
+			Cost:      best.Cost,
+			Qualified: best.Qualified(),
+			Positive:  best.Params[4] < 0,
+			ErrorDays: math.NaN(),
+		}
+		f.Alarm = f.Qualified && f.Positive
+		for i := range crashes {
+			if crashes[i].Confirmed.After(f.End) {
+				f.Crash = &crashes[i]
+				f.ErrorDays = f.Tc.Sub(crashes[i].Peak).Hours() / 24
+				if f.Alarm {
+					absErrors = append(absErrors, math.Abs(f.ErrorDays))
+					if f.Hit = math.Abs(f.ErrorDays) <= tolerance; f.Hit {
+						anticipated[i] = true
+					}
+				}
+				break
+			}
+		}
+
+		sum.Fits++
+		if f.Qualified {
+			sum.Qualified++
+		}
+		if f.Alarm {
+			sum.Alarms++
+		}
+		if f.Hit {
+			sum.Hits++
+		}
+		fits = append(fits, f)
+	}
+	for _, a := range anticipated {
+		if a {
+			sum.Anticipated++
+		}
+	}
+	if len(absErrors) > 0 {
+		sum.MedianAbsError = stats.Median(absErrors)
+	}
+	return fits, sum
+}
@@ -0,0 +1,118 @@
+package fit
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"cw3/data"
+	"cw3/lppl"
+)
+
+type TcCluster struct {
+	From  time.Time
+	To    time.Time
+	Size  int
+	Total int
+}
+
+// dbscan grupuje wartości jednowymiarowe; zwraca etykiety klastrów, -1 oznacza szum
+func dbscan(values []float64, eps float64, minPts int) []int {
+	labels := make([]int, len(values))
+	for i := range labels {
+		labels[i] = -2 // nieodwiedzony
+	}
+
+	neighbours := func(i int) []int {
+		var idx []int
+		for j, v := range values {
+			if math.Abs(v-values[i]) <= eps {
+				idx = append(idx, j)
+			}
+		}
+		return idx
+	}
+
+	cluster := 0
+	for i := range values {
+		if labels[i] != -2 {
+			continue
+		}
+		seeds := neighbours(i)
+		if len(seeds) < minPts {
+			labels[i] = -1
+			continue
+		}
+		labels[i] = cluster
+		for k := 0; k < len(seeds); k++ {
+			j := seeds[k]
+			if labels[j] == -1 {
+				labels[j] = cluster
+			}
+			if labels[j] != -2 {
+				continue
+			}
+			labels[j] = cluster
+			if more := neighbours(j); len(more) >= minPts {
+				seeds = append(seeds, more...)
+			}
+		}
+		cluster++
+	}
+	return labels
+}
+
+// ClusterTc zbiera estymaty tc ze wszystkich okien i punktów startowych, grupuje je
+// algorytmem DBSCAN (eps w dniach) i zwraca najliczniejszy klaster
+func ClusterTc(points []data.Point, opts lppl.FitOptions, minPoints int, eps float64, minPts int) (TcCluster, error) {
+	var tcs []float64
+	for start := 0; start+minPoints <= len(points); start++ {
+		window := points[start:]
+		best, rejected, err := lppl.FitAll(window, opts)
+		if err != nil {
+			continue
+		}
+		// tc liczone jest w dniach od początku okna, więc sprowadzamy je do wspólnej osi
+		offset := window[0].Date.Sub(points[0].Date).Hours() / 24
+		for _, r := range append([]lppl.Result{best}, rejected...) {
+			tcs = append(tcs, offset+r.Params[0])
+		}
+	}
+	if len(tcs) == 0 {
+		return TcCluster{}, errors.New("brak estymat tc do grupowania")
+	}
+
+	labels := dbscan(tcs, eps, minPts)
+	counts := map[int]int{}
+	dominant, dominantSize := -1, 0
+	for _, l := range labels {
+		if l < 0 {
+			continue
+		}
+		counts[l]++
+		if counts[l] > dominantSize {
+			dominant, dominantSize = l, counts[l]
+		}
+	}
+	if dominant < 0 {
+		return TcCluster{Total: len(tcs)}, errors.New("nie znaleziono klastra tc, wszystkie estymaty są szumem")
+	}
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i, l := range labels {
+		if l == dominant {
+			lo = math.Min(lo, tcs[i])
+			hi = math.Max(hi, tcs[i])
+		}
+	}
+	toDate := func(days float64) time.Time {
+		return points[0].Date.Add(time.Duration(days * 24 * float64(time.Hour)))
+	}
+
+	return TcCluster{
+		From:  toDate(lo),
+		To:    toDate(hi),
+		Size:  dominantSize,
+		Total: len(tcs),
+	}, nil
+}
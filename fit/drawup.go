@@ -0,0 +1,112 @@
+package fit
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"cw3/data"
+	"cw3/internal/stats"
+	"cw3/lppl"
+)
+
+const (
+	// drawupTolerance to dopuszczalne cofnięcie w trakcie wzrostu w jednostkach
+	// odchylenia standardowego dziennych stóp zwrotu (epsilon-drawup)
+	drawupTolerance = 1.0
+	// drawupSignificance to poziom istotności względem rozkładu wykładniczego wielkości wzrostów
+	drawupSignificance = 0.05
+)
+
+// Drawup to nieprzerwany (z tolerancją) wzrost log-ceny od obserwacji Start do End
+type Drawup struct {
+	Start, End int
+	Size       float64
+	// PValue to prawdopodobieństwo co najmniej takiego wzrostu w rozkładzie wykładniczym
+	PValue float64
+}
+
+// FindDrawups dzieli szereg na epsilon-wzrosty: wzrost trwa od lokalnego minimum, dopóki
+// log-cena nie spadnie o więcej niż epsilon poniżej maksimum osiągniętego od jego początku
+func FindDrawups(points []data.Point) []Drawup {
+	y := data.LogPrices(points)
+	if len(y) < 3 {
+		return nil
+	}
+	returns := make([]float64, len(y)-1)
+	for i := range returns {
+		returns[i] = y[i+1] - y[i]
+	}
+	eps := drawupTolerance * math.Sqrt(stats.Variance(returns))
+
+	var drawups []Drawup
+	start, peak := 0, 0
+	for i := 1; i < len(y); i++ {
+		switch {
+		case y[i] > y[peak]:
+			peak = i
+		case y[peak]-y[i] > eps:
+			if peak > start {
+				drawups = append(drawups, Drawup{Start: start, End: peak, Size: y[peak] - y[start]})
+			}
+			start, peak = i, i
+		case y[i] < y[start] && peak == start:
+			start, peak = i, i
+		}
+	}
+	if peak > start {
+		drawups = append(drawups, Drawup{Start: start, End: peak, Size: y[peak] - y[start]})
+	}
+	return drawups
+}
+
+// AbnormalDrawups zwraca wzrosty nieprawdopodobne przy wykładniczym rozkładzie wielkości
+// o średniej równej średniej ze wszystkich wzrostów, od największego
+func AbnormalDrawups(points []data.Point) []Drawup {
+	all := FindDrawups(points)
+	if len(all) == 0 {
+		return nil
+	}
+	var total float64
+	for _, d := range all {
+		total += d.Size
+	}
+	scale := total / float64(len(all))
+
+	var abnormal []Drawup
+	for _, d := range all {
+		d.PValue = math.Exp(-d.Size / scale)
+		if d.PValue < drawupSignificance {
+			abnormal = append(abnormal, d)
+		}
+	}
+	sort.Slice(abnormal, func(i, j int) bool { return abnormal[i].Size > abnormal[j].Size })
+	return abnormal
+}
+
+// FromDrawups dopasowuje model w oknach zaczynających się na początku każdego
+// nietypowego wzrostu i wybiera okno tak jak lppl.FitAll wybiera minimum: najpierw
+// zgodność z filtrami, potem koszt (na obserwację, bo okna mają różne długości).
+// Zwraca indeks początku wybranego okna.
+func FromDrawups(points []data.Point, drawups []Drawup, opts lppl.FitOptions, minPoints int) (int, lppl.Result, error) {
+	bestStart := -1
+	var best lppl.Result
+	perPoint := func(start int, r lppl.Result) float64 { return r.Cost / float64(len(points)-start) }
+	for _, d := range drawups {
+		if len(points)-d.Start < minPoints {
+			continue
+		}
+		r, err := lppl.Fit(points[d.Start:], opts)
+		if err != nil {
+			continue
+		}
+		if bestStart < 0 || len(r.Violations) < len(best.Violations) ||
+			len(r.Violations) == len(best.Violations) && perPoint(d.Start, r) < perPoint(bestStart, best) {
+			bestStart, best = d.Start, r
+		}
+	}
+	if bestStart < 0 {
+		return 0, best, errors.New("żaden nietypowy wzrost nie daje okna do dopasowania")
+	}
+	return bestStart, best, nil
+}
@@ -0,0 +1,219 @@
+package fit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cw3/data"
+	"cw3/internal/atomicfile"
+	"cw3/lppl"
+)
+
+type QualifiedFraction struct {
+	Date     time.Time `json:"date"`
+	Windows  int       `json:"windows"`
+	Positive float64   `json:"positive"`
+	Negative float64   `json:"negative"`
+	// Regime to wygładzone prawdopodobieństwo reżimu ponadwykładniczego (tylko z -regimes)
+	Regime *float64 `json:"regime,omitempty"`
+}
+
+// QualifiedFractions liczy wskaźnik pewności bańki (DS LPPLS confidence indicator): dla
+// każdego dnia końcowego dopasowuje model w zagnieżdżonych oknach o długości od minPoints
+// do maxPoints (0 oznacza pełną historię), co step obserwacji, i liczy udział dopasowań
+// kwalifikowanych osobno dla bańki dodatniej (B < 0) i ujemnej (B > 0). Dni końcowe są
+// rozdzielane między opts.Workers równoległych wątków, a każde okno dopasowywane jednym.
+func QualifiedFractions(points []data.Point, opts lppl.FitOptions, minPoints, maxPoints, step int) []QualifiedFraction {
+	return FractionsBetween(points, opts, minPoints, maxPoints, step, minPoints-1, len(points)-1)
+}
+
+// FractionsBetween liczy wskaźnik jak QualifiedFractions, ale tylko dla dni końcowych o
+// indeksach od first do last włącznie (first nie mniejszy niż minPoints-1); pozwala
+// rozdzielić historię między kilka procesów lub maszyn
+func FractionsBetween(points []data.Point, opts lppl.FitOptions, minPoints, maxPoints, step, first, last int) []QualifiedFraction {
+	if step < 1 {
+		step = 1
+	}
+	if maxPoints <= 0 {
+		maxPoints = len(points)
+	}
+	first, last = max(first, minPoints-1), min(last, len(points)-1)
+	if first > last {
+		return nil
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	window := opts
+	window.Workers = 1
+
+	series := make([]QualifiedFraction, last-first+1)
+	ends := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(series)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for end := range ends {
+				series[end-first] = fractionAt(points, window, end, minPoints, maxPoints, step)
+			}
+		}()
+	}
+	for end := first; end <= last; end++ {
+		ends <- end
+	}
+	close(ends)
+	wg.Wait()
+	return series
+}
+
+// LatestFraction liczy wskaźnik pewności bańki tylko dla ostatniej obserwacji, jak
+// QualifiedFractions, ale bez historii dni wcześniejszych. Okna są rozdzielane między
+// opts.Workers równoległych wątków, a każde dopasowywane jednym.
+func LatestFraction(points []data.Point, opts lppl.FitOptions, minPoints, maxPoints, step int) (QualifiedFraction, bool) {
+	if step < 1 {
+		step = 1
+	}
+	if maxPoints <= 0 {
+		maxPoints = len(points)
+	}
+	if minPoints > len(points) || len(points) == 0 {
+		return QualifiedFraction{}, false
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	window := opts
+	window.Workers = 1
+
+	end := len(points) - 1
+	var sizes []int
+	for size := minPoints; size <= min(end+1, maxPoints); size += step {
+		sizes = append(sizes, size)
+	}
+	fits := make([]windowFit, len(sizes))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(sizes)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fits[i] = fitWindow(points[end+1-sizes[i]:], window)
+			}
+		}()
+	}
+	for i := range sizes {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return tally(points[end].Date, fits), true
+}
+
+// windowFit to wynik dopasowania jednego okna: czy się udało, czy spełnia filtry i znak B
+type windowFit struct {
+	ok, qualified, positive bool
+}
+
+func fitWindow(points []data.Point, opts lppl.FitOptions) windowFit {
+	best, err := lppl.Fit(points, opts)
+	if err != nil {
+		return windowFit{}
+	}
+	return windowFit{ok: true, qualified: best.Qualified(), positive: best.Params[4] < 0}
+}
+
+// tally liczy udziały kwalifikowanych dopasowań bańki dodatniej i ujemnej wśród udanych
+func tally(date time.Time, fits []windowFit) QualifiedFraction {
+	var total, positive, negative int
+	for _, w := range fits {
+		if !w.ok {
+			continue
+		}
+		total++
+		if !w.qualified {
+			continue
+		}
+		if w.positive {
+			positive++
+		} else {
+			negative++
+		}
+	}
+	f := QualifiedFraction{Date: date, Windows: total}
+	if total > 0 {
+		f.Positive = float64(positive) / float64(total)
+		f.Negative = float64(negative) / float64(total)
+	}
+	return f
+}
+
+// fractionAt liczy udziały kwalifikowanych dopasowań dla okien kończących się w obserwacji end
+func fractionAt(points []data.Point, opts lppl.FitOptions, end, minPoints, maxPoints, step int) QualifiedFraction {
+	var fits []windowFit
+	for size := minPoints; size <= min(end+1, maxPoints); size += step {
+		fits = append(fits, fitWindow(points[end+1-size:end+1], opts))
+	}
+	return tally(points[end].Date, fits)
+}
+
+// AttachRegimes dopisuje do szeregu prawdopodobieństwa reżimu z Regimes
+func AttachRegimes(series []QualifiedFraction, points []data.Point, probs []float64) {
+	byDate := make(map[time.Time]float64, len(probs))
+	for i, p := range probs {
+		byDate[points[i+1].Date] = p
+	}
+	for i := range series {
+		if p, ok := byDate[series[i].Date]; ok {
+			series[i].Regime = &p
+		}
+	}
+}
+
+// WriteFractions zapisuje szereg jako JSON lub CSV, zależnie od rozszerzenia pliku
+func WriteFractions(path string, series []QualifiedFraction) error {
+	file, err := atomicfile.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		enc := json.NewEncoder(file)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(series); err != nil {
+			return err
+		}
+		return file.Commit()
+	}
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"date", "windows", "positive", "negative", "regime"})
+	for _, f := range series {
+		var regime string
+		if f.Regime != nil {
+			regime = strconv.FormatFloat(*f.Regime, 'f', 4, 64)
+		}
+		w.Write([]string{
+			f.Date.Format("2006-01-02"),
+			strconv.Itoa(f.Windows),
+			strconv.FormatFloat(f.Positive, 'f', 4, 64),
+			strconv.FormatFloat(f.Negative, 'f', 4, 64),
+			regime,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return file.Commit()
+}
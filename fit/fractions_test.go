@@ -0,0 +1,44 @@
+package fit
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"cw3/data"
+	"cw3/lppl"
+)
+
+func bubble(n int) []data.Point {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := make([]data.Point, n)
+	for i := range points {
+		points[i] = data.Point{Date: start.AddDate(0, 0, i), Price: math.Exp(lppl.Model(float64(i), float64(n)+30, 0.5, 8, 10, -0.05, 0.05, 1))}
+	}
+	return points
+}
+
+// LatestFraction dzieli okna między wątki, ale musi dać to samo co ostatni dzień
+// QualifiedFractions, który liczy okna po kolei
+func TestLatestFractionMatchesSeries(t *testing.T) {
+	points := bubble(80)
+	opts := lppl.DefaultFitOptions()
+	opts.MaxIterations = 500
+	for _, workers := range []int{1, 4} {
+		opts.Workers = workers
+		latest, ok := LatestFraction(points, opts, 40, 0, 10)
+		if !ok {
+			t.Fatal("brak wyniku")
+		}
+		series := FractionsBetween(points, opts, 40, 0, 10, len(points)-1, len(points)-1)
+		if len(series) != 1 || series[0] != latest {
+			t.Errorf("Workers=%d: LatestFraction = %+v, QualifiedFractions = %+v", workers, latest, series)
+		}
+		if latest.Windows != 5 {
+			t.Errorf("Workers=%d: %d okien, oczekiwano 5", workers, latest.Windows)
+		}
+	}
+	if _, ok := LatestFraction(points[:10], opts, 40, 0, 10); ok {
+		t.Error("oczekiwano braku wyniku dla krótszego szeregu niż minimalne okno")
+	}
+}
@@ -0,0 +1,104 @@
+// Package fit zawiera procedury zbudowane na pojedynczym dopasowaniu LPPL: wybór
+// początku okna, klastrowanie tc, frakcje dopasowań, mapy stabilności, testy wstępne
+// i detekcję reżimów.
+package fit
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"cw3/data"
+	"cw3/internal/atomicfile"
+	"cw3/lppl"
+)
+
+type LagrangePoint struct {
+	Start       int
+	N           int
+	Cost        float64
+	Regularized float64
+	Fit         lppl.Result
+}
+
+// LagrangeProfile dopasowuje model dla kolejnych początków okna t1 i stosuje regularyzację
+// Lagrange'a (Demos, Sornette 2017): od znormalizowanego kosztu odejmowany jest trend
+// lambda·n, który w przeciwnym razie faworyzuje krótkie okna. Zwraca profil i indeks
+// najlepszego t1.
+func LagrangeProfile(points []data.Point, opts lppl.FitOptions, minPoints int) ([]LagrangePoint, int, error) {
+	if minPoints <= lppl.ParamCount {
+		minPoints = lppl.ParamCount + 1
+	}
+	if len(points) < minPoints {
+		return nil, 0, fmt.Errorf("za mało danych do profilu t1: %d < %d", len(points), minPoints)
+	}
+
+	var profile []LagrangePoint
+	for start := 0; start+minPoints <= len(points); start++ {
+		window := points[start:]
+		best, err := lppl.Fit(window, opts)
+		if err != nil {
+			continue
+		}
+		profile = append(profile, LagrangePoint{
+			Start: start,
+			N:     len(window),
+			Cost:  best.Cost / float64(len(window)-lppl.ParamCount),
+			Fit:   best,
+		})
+	}
+	if len(profile) < 2 {
+		return nil, 0, errors.New("za mało udanych dopasowań do wyznaczenia lambdy")
+	}
+
+	// Nachylenie lambda z regresji liniowej kosztu względem długości okna
+	var sumN, sumC, sumNN, sumNC float64
+	for _, p := range profile {
+		n := float64(p.N)
+		sumN += n
+		sumC += p.Cost
+		sumNN += n * n
+		sumNC += n * p.Cost
+	}
+	k := float64(len(profile))
+	lambda := (k*sumNC - sumN*sumC) / (k*sumNN - sumN*sumN)
+
+	bestIdx := 0
+	for i := range profile {
+		profile[i].Regularized = profile[i].Cost - lambda*float64(profile[i].N)
+		if profile[i].Regularized < profile[bestIdx].Regularized {
+			bestIdx = i
+		}
+	}
+
+	return profile, bestIdx, nil
+}
+
+func WriteLagrangeProfile(path string, points []data.Point, profile []LagrangePoint) error {
+	file, err := atomicfile.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"t1", "n", "cost", "regularized_cost", "tc", "m", "omega", "qualified"})
+	for _, p := range profile {
+		w.Write([]string{
+			points[p.Start].Date.Format("2006-01-02"),
+			strconv.Itoa(p.N),
+			strconv.FormatFloat(p.Cost, 'g', -1, 64),
+			strconv.FormatFloat(p.Regularized, 'g', -1, 64),
+			strconv.FormatFloat(p.Fit.Params[0], 'f', 4, 64),
+			strconv.FormatFloat(p.Fit.Params[1], 'f', 4, 64),
+			strconv.FormatFloat(p.Fit.Params[2], 'f', 4, 64),
+			strconv.FormatBool(p.Fit.Qualified()),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return file.Commit()
+}
@@ -0,0 +1,143 @@
+package fit
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"cw3/data"
+	"cw3/internal/stats"
+)
+
+const (
+	regimeMinReturns  = 20
+	regimeMaxIter     = 500
+	regimeTolerance   = 1e-8
+	regimeMinVariance = 1e-10
+)
+
+// RegimeModel to dwustanowy model Markowa dla dziennych log-stóp zwrotu. Stan 0 to
+// wzrost normalny, stan 1 - reżim o wyższym średnim zwrocie, w którym cena rośnie
+// szybciej niż wynikałoby z trendu wykładniczego stanu normalnego.
+type RegimeModel struct {
+	Mean       [2]float64
+	Variance   [2]float64
+	Transition [2][2]float64
+	LogLik     float64
+}
+
+// Regimes estymuje model algorytmem EM (filtr Hamiltona i wygładzacz Kima) i zwraca
+// wygładzone prawdopodobieństwa reżimu wzrostu ponadwykładniczego; element i dotyczy
+// zwrotu z data[i] na data[i+1]
+func Regimes(points []data.Point) (RegimeModel, []float64, error) {
+	logP := data.LogPrices(points)
+	r := make([]float64, len(logP)-1)
+	for i := range r {
+		r[i] = logP[i+1] - logP[i]
+	}
+	var m RegimeModel
+	if len(r) < regimeMinReturns {
+		return m, nil, errors.New("za mało stóp zwrotu do estymacji reżimów")
+	}
+
+	// Start: dolna i górna połowa posortowanych zwrotów, wspólna wariancja
+	sorted := append([]float64(nil), r...)
+	sort.Float64s(sorted)
+	half := len(sorted) / 2
+	m.Mean[0], m.Mean[1] = stats.Mean(sorted[:half]), stats.Mean(sorted[half:])
+	v := stats.Variance(r)
+	m.Variance = [2]float64{v, v}
+	m.Transition = [2][2]float64{{0.9, 0.1}, {0.1, 0.9}}
+	initial := [2]float64{0.5, 0.5}
+
+	n := len(r)
+	pred := make([][2]float64, n)
+	filt := make([][2]float64, n)
+	smooth := make([][2]float64, n)
+	prevLL := math.Inf(-1)
+	for iter := 0; iter < regimeMaxIter; iter++ {
+		// Filtr Hamiltona
+		m.LogLik = 0
+		for t := 0; t < n; t++ {
+			if t == 0 {
+				pred[t] = initial
+			} else {
+				for j := 0; j < 2; j++ {
+					pred[t][j] = filt[t-1][0]*m.Transition[0][j] + filt[t-1][1]*m.Transition[1][j]
+				}
+			}
+			var c float64
+			for j := 0; j < 2; j++ {
+				filt[t][j] = pred[t][j] * normalPDF(r[t], m.Mean[j], m.Variance[j])
+				c += filt[t][j]
+			}
+			if c == 0 || math.IsNaN(c) {
+				return m, nil, errors.New("zerowa wiarygodność w modelu reżimów")
+			}
+			filt[t][0] /= c
+			filt[t][1] /= c
+			m.LogLik += math.Log(c)
+		}
+
+		// Wygładzacz Kima i łączne prawdopodobieństwa przejść
+		smooth[n-1] = filt[n-1]
+		var trans [2][2]float64
+		for t := n - 2; t >= 0; t-- {
+			for i := 0; i < 2; i++ {
+				smooth[t][i] = 0
+				for j := 0; j < 2; j++ {
+					xi := filt[t][i] * m.Transition[i][j] * smooth[t+1][j] / pred[t+1][j]
+					smooth[t][i] += xi
+					trans[i][j] += xi
+				}
+			}
+		}
+
+		// Krok M
+		for i := 0; i < 2; i++ {
+			var w, wr float64
+			for t := 0; t < n; t++ {
+				w += smooth[t][i]
+				wr += smooth[t][i] * r[t]
+			}
+			m.Mean[i] = wr / w
+			var wv float64
+			for t := 0; t < n; t++ {
+				d := r[t] - m.Mean[i]
+				wv += smooth[t][i] * d * d
+			}
+			m.Variance[i] = math.Max(wv/w, regimeMinVariance)
+			rowSum := trans[i][0] + trans[i][1]
+			for j := 0; j < 2; j++ {
+				m.Transition[i][j] = trans[i][j] / rowSum
+			}
+		}
+		initial = smooth[0]
+
+		if m.LogLik-prevLL < regimeTolerance {
+			break
+		}
+		prevLL = m.LogLik
+	}
+
+	probs := make([]float64, n)
+	bubble := 1
+	if m.Mean[0] > m.Mean[1] {
+		bubble = 0
+		m.Mean[0], m.Mean[1] = m.Mean[1], m.Mean[0]
+		m.Variance[0], m.Variance[1] = m.Variance[1], m.Variance[0]
+		m.Transition = [2][2]float64{
+			{m.Transition[1][1], m.Transition[1][0]},
+			{m.Transition[0][1], m.Transition[0][0]},
+		}
+	}
+	for t := range smooth {
+		probs[t] = smooth[t][bubble]
+	}
+	return m, probs, nil
+}
+
+func normalPDF(x, mu, variance float64) float64 {
+	d := x - mu
+	return math.Exp(-d*d/(2*variance)) / math.Sqrt(2*math.Pi*variance)
+}
@@ -0,0 +1,93 @@
+package fit
+
+import (
+	"math"
+	"time"
+
+	"cw3/data"
+	"cw3/internal/stats"
+	"cw3/lppl"
+)
+
+// Siatka modelu potęgowego w teście wzrostu ponadwykładniczego
+const (
+	screenTcSteps = 20
+	screenMSteps  = 9
+)
+
+// ScreenWindow to wynik testu dla jednego okna kończącego się w dniu End
+type ScreenWindow struct {
+	End time.Time
+	// DeltaAIC > 0 oznacza, że wzrost potęgowy do tc opisuje okno lepiej niż wykładniczy
+	DeltaAIC float64
+	Tc, M    float64
+}
+
+func (w ScreenWindow) SuperExponential() bool {
+	return w.DeltaAIC > 0
+}
+
+// SuperExponentialTest porównuje na oknie wzrost wykładniczy ln p = a + b t (2 parametry)
+// z potęgowym ln p = A + B (tc-t)^m, B < 0 (4 parametry) kryterium Akaikego. Model
+// potęgowy liczony jest na siatce (tc, m), a A i B wyznacza regresja liniowa, więc test
+// kosztuje ułamek pełnego dopasowania LPPL.
+func SuperExponentialTest(points []data.Point, opts lppl.FitOptions) ScreenWindow {
+	t := data.TimeIndex(points)
+	y := data.LogPrices(points)
+	n := float64(len(y))
+	res := ScreenWindow{End: points[len(points)-1].Date, DeltaAIC: math.Inf(-1)}
+
+	_, _, rssExp := linearFit(t, y)
+	lo, hi := lppl.TcRange(t, opts)
+	x := make([]float64, len(t))
+	for i := 0; i < screenTcSteps; i++ {
+		tc := lo + (hi-lo)*float64(i)/(screenTcSteps-1)
+		for j := 1; j <= screenMSteps; j++ {
+			m := float64(j) / (screenMSteps + 1)
+			for k, tk := range t {
+				x[k] = math.Pow(tc-tk, m)
+			}
+			_, b, rss := linearFit(x, y)
+			if b >= 0 {
+				continue
+			}
+			// AIC = n ln(RSS/n) + 2k; od różnicy odpada składnik n ln n
+			if d := n*math.Log(rssExp/rss) - 2*2; d > res.DeltaAIC {
+				res.DeltaAIC, res.Tc, res.M = d, tc, m
+			}
+		}
+	}
+	return res
+}
+
+// linearFit dopasowuje y = a + b x metodą najmniejszych kwadratów i zwraca sumę kwadratów reszt
+func linearFit(x, y []float64) (a, b, rss float64) {
+	mx, my := stats.Mean(x), stats.Mean(y)
+	var sxy, sxx float64
+	for i := range x {
+		sxy += (x[i] - mx) * (y[i] - my)
+		sxx += (x[i] - mx) * (x[i] - mx)
+	}
+	if sxx > 0 {
+		b = sxy / sxx
+	}
+	a = my - b*mx
+	for i := range x {
+		r := y[i] - a - b*x[i]
+		rss += r * r
+	}
+	return a, b, rss
+}
+
+// RollingScreen wykonuje test na oknach długości size przesuwanych co step obserwacji,
+// tak żeby ostatnie okno kończyło się na ostatniej obserwacji
+func RollingScreen(points []data.Point, opts lppl.FitOptions, size, step int) []ScreenWindow {
+	if step < 1 {
+		step = 1
+	}
+	var windows []ScreenWindow
+	for end := len(points); end >= size; end -= step {
+		windows = append([]ScreenWindow{SuperExponentialTest(points[end-size:end], opts)}, windows...)
+	}
+	return windows
+}
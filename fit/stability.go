@@ -0,0 +1,58 @@
+package fit
+
+import (
+	"math"
+
+	"cw3/data"
+	"cw3/lppl"
+)
+
+// Grid przechowuje wartość jednego parametru dla siatki (początek okna, koniec okna)
+type Grid struct {
+	xs, ys []float64
+	z      [][]float64 // z[koniec][początek]
+}
+
+func (g Grid) Dims() (c, r int) { return len(g.xs), len(g.ys) }
+
+func (g Grid) Z(c, r int) float64 { return g.z[r][c] }
+
+func (g Grid) X(c int) float64 { return g.xs[c] }
+
+func (g Grid) Y(r int) float64 { return g.ys[r] }
+
+// StabilityGrids dopasowuje model dla każdej pary (t1, t2) o co najmniej minPoints
+// obserwacjach i zwraca siatki m i omega; komórki bez dopasowania mają wartość NaN
+func StabilityGrids(points []data.Point, opts lppl.FitOptions, minPoints int) (Grid, Grid) {
+	timeIndex := data.TimeIndex(points)
+	starts := len(points) - minPoints + 1
+	if starts < 1 {
+		starts = 0
+	}
+
+	newGrid := func() Grid {
+		g := Grid{xs: timeIndex[:starts], ys: timeIndex[minPoints-1:]}
+		g.z = make([][]float64, len(g.ys))
+		for r := range g.z {
+			g.z[r] = make([]float64, len(g.xs))
+			for c := range g.z[r] {
+				g.z[r][c] = math.NaN()
+			}
+		}
+		return g
+	}
+	mGrid, omegaGrid := newGrid(), newGrid()
+
+	for start := 0; start < starts; start++ {
+		for end := start + minPoints - 1; end < len(points); end++ {
+			best, err := lppl.Fit(points[start:end+1], opts)
+			if err != nil {
+				continue
+			}
+			r := end - (minPoints - 1)
+			mGrid.z[r][start] = best.Params[1]
+			omegaGrid.z[r][start] = best.Params[2]
+		}
+	}
+	return mGrid, omegaGrid
+}
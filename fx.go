@@ -0,0 +1,68 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"cw3/data"
+)
+
+// frankfurterAPI udostępnia bez klucza dzienne kursy referencyjne EBC
+const frankfurterAPI = "https://api.frankfurter.app/"
+
+// fxSourceAPI to nazwa źródła kursów pobieranych z frankfurterAPI; inna wartość
+// flagi -fx oznacza plik CSV z kursami
+const fxSourceAPI = "frankfurter"
+
+// fxLookback to zapas przed początkiem szeregu, żeby pierwszy dzień (np. święto) miał kurs
+const fxLookback = 7 * 24 * time.Hour
+
+// fetchFX pobiera kursy EBC waluty to za jednostkę waluty from w przedziale [start, end]
+func fetchFX(from, to string, start, end time.Time) ([]data.Metric, error) {
+	var resp struct {
+		Rates map[string]map[string]float64 `json:"rates"`
+	}
+	u := fmt.Sprintf("%s%s..%s?from=%s&to=%s", frankfurterAPI,
+		start.Add(-fxLookback).Format("2006-01-02"), end.Format("2006-01-02"), from, to)
+	if err := getJSON(u, &resp); err != nil {
+		return nil, fmt.Errorf("kursy %s/%s: %w", from, to, err)
+	}
+
+	rates := make([]data.Metric, 0, len(resp.Rates))
+	for day, byCurrency := range resp.Rates {
+		date, err := time.Parse("2006-01-02", day)
+		if err != nil {
+			return nil, fmt.Errorf("kursy %s/%s: %w", from, to, err)
+		}
+		rate, ok := byCurrency[to]
+		if !ok {
+			return nil, fmt.Errorf("kursy %s/%s: brak kursu w odpowiedzi z %s", from, to, day)
+		}
+		rates = append(rates, data.Metric{Date: date, Value: rate})
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].Date.Before(rates[j].Date) })
+	return rates, nil
+}
+
+// convertCurrency przelicza szereg z waluty from na to kursami ze źródła source
+// (fxSourceAPI albo ścieżka pliku CSV z kolumnami data,kurs)
+func convertCurrency(points []data.Point, from, to, source string) ([]data.Point, error) {
+	if strings.EqualFold(from, to) {
+		return points, nil
+	}
+	var rates []data.Metric
+	var err error
+	if source == fxSourceAPI {
+		rates, err = fetchFX(from, to, points[0].Date, points[len(points)-1].Date)
+	} else {
+		rates, err = data.LoadMetric(source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data.Convert(points, rates)
+}
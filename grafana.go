@@ -0,0 +1,273 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// grafanaMetrics to wielkości z rejestru analiz dostępne jako serie Grafany
+var grafanaMetrics = []string{"confidence", "tc_days", "cost", "qualified"}
+
+// grafanaRange to zakres czasu panelu
+type grafanaRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// contains sprawdza, czy t leży w zakresie; zerowy zakres obejmuje wszystko
+func (r grafanaRange) contains(t time.Time) bool {
+	return (r.From.IsZero() || !t.Before(r.From)) && (r.To.IsZero() || !t.After(r.To))
+}
+
+type grafanaQuery struct {
+	Range   grafanaRange `json:"range"`
+	Targets []struct {
+		Target string `json:"target"`
+		Type   string `json:"type"`
+	} `json:"targets"`
+}
+
+type grafanaSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+type grafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+type grafanaTable struct {
+	Type    string          `json:"type"`
+	Columns []grafanaColumn `json:"columns"`
+	Rows    [][]any         `json:"rows"`
+}
+
+type grafanaAnnotation struct {
+	Annotation json.RawMessage `json:"annotation"`
+	Time       int64           `json:"time"`
+	Title      string          `json:"title"`
+	Text       string          `json:"text"`
+	Tags       []string        `json:"tags"`
+}
+
+// grafanaPoint to punkt serii dla wtyczki Infinity
+type grafanaPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// grafanaHandlers rejestruje punkty końcowe źródła danych Grafana SimpleJSON (GET /grafana,
+// /search, /query, /annotations) oraz GET /grafana/series dla wtyczki Infinity
+func (s *apiServer) grafanaHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET /grafana", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("POST /grafana/search", s.handleGrafanaSearch)
+	mux.HandleFunc("POST /grafana/query", s.handleGrafanaQuery)
+	mux.HandleFunc("POST /grafana/annotations", s.handleGrafanaAnnotations)
+	mux.HandleFunc("GET /grafana/series", s.handleGrafanaSeries)
+}
+
+// recordTime to chwila, do której odnosi się analiza: ostatnia obserwacja albo czas przebiegu
+func recordTime(rec runRecord) time.Time {
+	if rec.End.IsZero() {
+		return rec.Time
+	}
+	return rec.End
+}
+
+// metricValue zwraca wartość metryki metric analizy rec
+func metricValue(rec runRecord, metric string) float64 {
+	switch metric {
+	case "confidence":
+		return rec.Confidence
+	case "tc_days":
+		return rec.Tc.Sub(recordTime(rec)).Hours() / 24
+	case "cost":
+		return rec.Cost
+	}
+	if rec.Qualified {
+		return 1
+	}
+	return 0
+}
+
+// parseTarget rozdziela cel postaci SYMBOL:metryka
+func parseTarget(target string) (symbol, metric string, err error) {
+	i := strings.LastIndex(target, ":")
+	if i < 0 {
+		return "", "", fmt.Errorf("cel %q: oczekiwano SYMBOL:metryka", target)
+	}
+	symbol, metric = target[:i], target[i+1:]
+	for _, m := range grafanaMetrics {
+		if m == metric {
+			return symbol, metric, nil
+		}
+	}
+	return "", "", fmt.Errorf("cel %q: nieznana metryka (dostępne: %s)", target, strings.Join(grafanaMetrics, ", "))
+}
+
+// series zwraca punkty metryki symbolu w zakresie, od najstarszego
+func (s *apiServer) series(target string, rng grafanaRange) ([]runRecord, string, error) {
+	symbol, metric, err := parseTarget(target)
+	if err != nil {
+		return nil, "", err
+	}
+	history, _, err := s.history.symbol(symbol)
+	if err != nil {
+		return nil, "", err
+	}
+	var out []runRecord
+	for i := len(history) - 1; i >= 0; i-- {
+		if rng.contains(recordTime(history[i])) {
+			out = append(out, history[i])
+		}
+	}
+	return out, metric, nil
+}
+
+func (s *apiServer) handleGrafanaSearch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Target string `json:"target"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	names, err := s.history.names()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	targets := []string{}
+	for _, name := range names {
+		for _, metric := range grafanaMetrics {
+			if target := name + ":" + metric; strings.Contains(strings.ToLower(target), strings.ToLower(req.Target)) {
+				targets = append(targets, target)
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, targets)
+}
+
+// handleGrafanaQuery zwraca serie (type timeserie) albo tabele analiz (type table, cel
+// to sam symbol)
+func (s *apiServer) handleGrafanaQuery(w http.ResponseWriter, r *http.Request) {
+	var req grafanaQuery
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	out := []any{}
+	for _, t := range req.Targets {
+		if t.Type == "table" {
+			history, _, err := s.history.symbol(t.Target)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			table := grafanaTable{Type: "table", Columns: []grafanaColumn{
+				{"Time", "time"}, {"tc", "time"}, {"confidence", "number"}, {"cost", "number"}, {"qualified", "string"},
+			}, Rows: [][]any{}}
+			for _, rec := range history {
+				if req.Range.contains(recordTime(rec)) {
+					table.Rows = append(table.Rows, []any{recordTime(rec).UnixMilli(), rec.Tc.UnixMilli(), rec.Confidence, rec.Cost, fmt.Sprint(rec.Qualified)})
+				}
+			}
+			out = append(out, table)
+			continue
+		}
+		records, metric, err := s.series(t.Target, req.Range)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		series := grafanaSeries{Target: t.Target, Datapoints: [][2]float64{}}
+		for _, rec := range records {
+			series.Datapoints = append(series.Datapoints, [2]float64{metricValue(rec, metric), float64(recordTime(rec).UnixMilli())})
+		}
+		out = append(out, series)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// handleGrafanaAnnotations oznacza tc ostatniej analizy symboli z zapytania adnotacji
+// (symbole oddzielone przecinkami, puste - wszystkie), gdy tc leży w zakresie panelu
+func (s *apiServer) handleGrafanaAnnotations(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Range      grafanaRange    `json:"range"`
+		Annotation json.RawMessage `json:"annotation"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var spec struct {
+		Query string `json:"query"`
+	}
+	json.Unmarshal(req.Annotation, &spec)
+	bySymbol, err := s.history.symbols()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	names, _ := s.history.names()
+	if q := strings.TrimSpace(spec.Query); q != "" {
+		names = strings.Split(q, ",")
+	}
+	out := []grafanaAnnotation{}
+	for _, name := range names {
+		history := bySymbol[strings.TrimSpace(name)]
+		if len(history) == 0 || history[0].Tc.IsZero() || !req.Range.contains(history[0].Tc) {
+			continue
+		}
+		rec := history[0]
+		out = append(out, grafanaAnnotation{
+			Annotation: req.Annotation,
+			Time:       rec.Tc.UnixMilli(),
+			Title:      "tc " + rec.Symbol,
+			Text: fmt.Sprintf("Analiza danych do %s: pewność bańki %.2f, filtry: %t",
+				rec.End.Format(time.DateOnly), rec.Confidence, rec.Qualified),
+			Tags: []string{"tc", rec.Symbol},
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// handleGrafanaSeries zwraca serię ?target=SYMBOL:metryka jako listę punktów time/value
+// z opcjonalnym zakresem ?from= i ?to= w RFC 3339
+func (s *apiServer) handleGrafanaSeries(w http.ResponseWriter, r *http.Request) {
+	var rng grafanaRange
+	for _, bound := range []struct {
+		name string
+		t    *time.Time
+	}{{"from", &rng.From}, {"to", &rng.To}} {
+		if v := r.URL.Query().Get(bound.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("%s: %w", bound.name, err))
+				return
+			}
+			*bound.t = t
+		}
+	}
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		writeError(w, http.StatusBadRequest, errors.New("brak parametru target"))
+		return
+	}
+	records, metric, err := s.series(target, rng)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	points := []grafanaPoint{}
+	for _, rec := range records {
+		points = append(points, grafanaPoint{Time: recordTime(rec), Value: metricValue(rec, metric)})
+	}
+	writeJSON(w, http.StatusOK, points)
+}
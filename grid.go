@@ -0,0 +1,347 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	ossignal "os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"cw3/data"
+	"cw3/internal/atomicfile"
+	"cw3/lppl"
+)
+
+// gridCheckpoint to stan przeszukiwania siatki zapisywany po każdym fragmencie. Przebieg
+// wznowiony z tym samym plikiem pomija gotowe fragmenty; przebiegi z różnymi -shard
+// zapisują osobne pliki, łączone potem flagą -merge.
+type gridCheckpoint struct {
+	Spec       lppl.GridSpec   `json:"spec"`
+	DataHash   string          `json:"data_hash"`
+	ConfigHash string          `json:"config_hash"`
+	ChunkSize  int             `json:"chunk_size"`
+	Keep       int             `json:"keep"`
+	Done       []int           `json:"done"`
+	Best       []lppl.GridCell `json:"best"`
+	Updated    time.Time       `json:"updated"`
+}
+
+func (c *gridCheckpoint) chunks() int {
+	return (c.Spec.Cells() + c.ChunkSize - 1) / c.ChunkSize
+}
+
+// compatible sprawdza, czy dwa stany dotyczą tej samej siatki, danych i ustawień
+func (c *gridCheckpoint) compatible(o *gridCheckpoint) error {
+	switch {
+	case c.Spec != o.Spec:
+		return errors.New("inna siatka (tc, m, omega)")
+	case c.DataHash != o.DataHash:
+		return errors.New("inne dane wejściowe")
+	case c.ConfigHash != o.ConfigHash:
+		return errors.New("inne ustawienia dopasowania")
+	case c.ChunkSize != o.ChunkSize:
+		return errors.New("inny rozmiar fragmentu")
+	}
+	return nil
+}
+
+func (c *gridCheckpoint) markDone(chunk int) {
+	if i, found := slices.BinarySearch(c.Done, chunk); !found {
+		c.Done = slices.Insert(c.Done, i, chunk)
+	}
+}
+
+func loadGridCheckpoint(path string) (*gridCheckpoint, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c gridCheckpoint
+	if err := json.Unmarshal(content, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &c, nil
+}
+
+func (c *gridCheckpoint) save(path string) error {
+	c.Updated = time.Now().UTC()
+	f, err := atomicfile.Replace(path)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+	if err := json.NewEncoder(f).Encode(c); err != nil {
+		return err
+	}
+	return f.Commit()
+}
+
+// parseAxis odczytuje oś siatki w postaci lo:hi:n
+func parseAxis(name, v string) (lppl.GridAxis, error) {
+	parts := strings.Split(v, ":")
+	if len(parts) != 3 {
+		return lppl.GridAxis{}, fmt.Errorf("-%s: oczekiwano lo:hi:n, podano %q", name, v)
+	}
+	lo, err1 := strconv.ParseFloat(parts[0], 64)
+	hi, err2 := strconv.ParseFloat(parts[1], 64)
+	n, err3 := strconv.Atoi(parts[2])
+	if err := errors.Join(err1, err2, err3); err != nil || n < 1 || hi < lo {
+		return lppl.GridAxis{}, fmt.Errorf("-%s: nieprawidłowa oś %q", name, v)
+	}
+	return lppl.GridAxis{Lo: lo, Hi: hi, N: n}, nil
+}
+
+// parseShard odczytuje numer i liczbę części w postaci k/n (k od 0)
+func parseShard(v string) (int, int, error) {
+	k, n, ok := strings.Cut(v, "/")
+	shard, err1 := strconv.Atoi(k)
+	shards, err2 := strconv.Atoi(n)
+	if !ok || err1 != nil || err2 != nil || shards < 1 || shard < 0 || shard >= shards {
+		return 0, 0, fmt.Errorf("-shard: oczekiwano k/n z 0 <= k < n, podano %q", v)
+	}
+	return shard, shards, nil
+}
+
+// runGrid obsługuje polecenie grid: przeszukiwanie siatki (tc, m, omega) z zapisem postępu
+func runGrid(args []string) error {
+	fs := flag.NewFlagSet("grid", flag.ExitOnError)
+	tcAxis := fs.String("tc", "1:180:180", "oś tc w dniach po ostatniej obserwacji: lo:hi:n")
+	mAxis := fs.String("m", "0.1:0.9:81", "oś m: lo:hi:n")
+	omegaAxis := fs.String("omega", "4:15:111", "oś omega: lo:hi:n")
+	checkpoint := fs.String("checkpoint", ".lppl_grid.json", "plik stanu przeszukiwania, zapisywany po każdym fragmencie i używany do wznowienia")
+	chunk := fs.Int("chunk", 100000, "liczba komórek siatki w jednym fragmencie (jednostce zapisu postępu)")
+	shardSpec := fs.String("shard", "0/1", "część k/n siatki liczona w tym przebiegu: fragmenty o numerach k, k+n, k+2n, ...")
+	keep := fs.Int("keep", 20, "liczba najlepszych komórek przechowywanych w stanie")
+	limit := fs.Duration("duration", 0, "wstrzymaj przeszukiwanie po takim czasie (0 - do końca); postęp zostaje w -checkpoint")
+	merge := fs.String("merge", "", "połącz pliki stanu części (oddzielone przecinkami) w -checkpoint zamiast liczyć")
+	configPath := fs.String("fit", "", "plik ustawień dopasowania: YAML jak -config albo JSON z polami lppl.FitOptions (pusty - ustawienia domyślne)")
+	out := fs.String("out", "", "zapisz najlepsze komórki do pliku CSV")
+	refine := fs.Int("refine", 0, "liczba poziomów doprecyzowania wokół najlepszych komórek po zakończeniu przeszukiwania (0 wyłącza)")
+	refineTop := fs.Int("refine-top", 5, "liczba najlepszych komórek, wokół których zagęszczana jest siatka na każdym poziomie -refine")
+	refineFactor := fs.Int("refine-factor", 4, "ile razy drobniejszy jest krok siatki na każdym kolejnym poziomie -refine")
+	fs.BoolVar(&atomicfile.Overwrite, "overwrite", false, "zastąp istniejący plik -out")
+	popts := data.DefaultParseOptions()
+	csvFlags(fs, &popts)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Użycie: %s grid [flagi] plik.csv\n       %s grid -merge a.json,b.json -checkpoint wynik.json\n", os.Args[0], os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *merge != "" {
+		state, err := mergeGridCheckpoints(strings.Split(*merge, ","))
+		if err != nil {
+			return err
+		}
+		if err := state.save(*checkpoint); err != nil {
+			return err
+		}
+		log.Printf("Połączony stan zapisany w %s: %d z %d fragmentów", *checkpoint, len(state.Done), state.chunks())
+		return nil
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("grid wymaga jednego pliku CSV")
+	}
+	shard, shards, err := parseShard(*shardSpec)
+	if err != nil {
+		return err
+	}
+	if *chunk < 1 || *keep < 1 {
+		return errors.New("-chunk i -keep muszą być dodatnie")
+	}
+	opts, err := loadFitOptions(*configPath)
+	if err != nil {
+		return err
+	}
+	points, _, err := data.Load(fs.Arg(0), popts)
+	if err != nil {
+		return err
+	}
+	if len(points) == 0 {
+		return errors.New("brak poprawnych obserwacji")
+	}
+
+	var spec lppl.GridSpec
+	for _, a := range []struct {
+		name, value string
+		axis        *lppl.GridAxis
+	}{{"tc", *tcAxis, &spec.Tc}, {"m", *mAxis, &spec.M}, {"omega", *omegaAxis, &spec.Omega}} {
+		if *a.axis, err = parseAxis(a.name, a.value); err != nil {
+			return err
+		}
+	}
+	// Oś tc podawana jest względem ostatniej obserwacji, a model liczy tc od pierwszej
+	last := data.TimeIndex(points)[len(points)-1]
+	spec.Tc.Lo += last
+	spec.Tc.Hi += last
+
+	state := &gridCheckpoint{Spec: spec, DataHash: dataHash(points), ConfigHash: configHash(opts), ChunkSize: *chunk, Keep: *keep}
+	prev, err := loadGridCheckpoint(*checkpoint)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := prev.compatible(state); err != nil {
+			return fmt.Errorf("%s: stan dotyczy innego przeszukiwania (%v); usuń plik lub podaj inny -checkpoint", *checkpoint, err)
+		}
+		state.Done, state.Best = prev.Done, lppl.MergeGridCells(*keep, prev.Best)
+		log.Printf("Wznawiam z %s: %d z %d fragmentów gotowych", *checkpoint, len(state.Done), state.chunks())
+	}
+
+	ctx, stop := ossignal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *limit > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *limit)
+		defer cancel()
+	}
+
+	total := state.chunks()
+	log.Printf("Siatka %d x %d x %d = %d komórek w %d fragmentach, część %d/%d",
+		spec.Tc.N, spec.M.N, spec.Omega.N, spec.Cells(), total, shard, shards)
+	started := time.Now()
+	for c := shard; c < total; c += shards {
+		if _, done := slices.BinarySearch(state.Done, c); done {
+			continue
+		}
+		if ctx.Err() != nil {
+			log.Printf("Wstrzymano po %s; uruchom ponownie z tym samym -checkpoint, aby kontynuować", time.Since(started).Round(time.Second))
+			break
+		}
+		cells, err := lppl.GridEvaluate(points, spec, opts, c*state.ChunkSize, (c+1)*state.ChunkSize, *keep)
+		if err != nil {
+			return err
+		}
+		state.Best = lppl.MergeGridCells(*keep, state.Best, cells)
+		state.markDone(c)
+		if err := state.save(*checkpoint); err != nil {
+			return err
+		}
+		bestCost := math.NaN()
+		if len(state.Best) > 0 {
+			bestCost = state.Best[0].Cost
+		}
+		log.Printf("Fragment %d: gotowe %d z %d (%.1f%%), najlepszy koszt %.6f",
+			c, len(state.Done), total, 100*float64(len(state.Done))/float64(total), bestCost)
+	}
+	best := state.Best
+	if *refine > 0 {
+		if len(state.Done) < total {
+			log.Printf("Pomijam -refine: przeszukiwanie siatki nie jest zakończone")
+		} else if best, err = refineGrid(points, spec, best, opts, *refine, *refineFactor, *refineTop, *keep); err != nil {
+			return err
+		}
+	}
+	reportGrid(points, state, best, opts)
+	if *out != "" {
+		return writeGridCells(*out, points, best, opts)
+	}
+	return nil
+}
+
+// refineGrid zagęszcza siatkę wokół najlepszych komórek i wypisuje, ile to kosztowało
+// w porównaniu z siatką o kroku najdrobniejszego poziomu w całym zakresie
+func refineGrid(points []data.Point, spec lppl.GridSpec, best []lppl.GridCell, opts lppl.FitOptions, levels, factor, top, keep int) ([]lppl.GridCell, error) {
+	started := time.Now()
+	refined, evaluated, err := lppl.GridRefine(points, spec, best, opts, levels, factor, top, keep)
+	if err != nil {
+		return nil, err
+	}
+	dense := 1.0
+	for _, a := range []lppl.GridAxis{spec.Tc, spec.M, spec.Omega} {
+		dense *= float64((a.N-1)*int(math.Pow(float64(factor), float64(levels))) + 1)
+	}
+	log.Printf("Doprecyzowanie siatki (poziomy: %d, obszary: %d): %d komórek w %s zamiast %.3g przy pełnej siatce o tym kroku",
+		levels, top, evaluated, time.Since(started).Round(time.Millisecond), dense)
+	if len(best) > 0 && len(refined) > 0 {
+		log.Printf("Najlepszy koszt: %.6f na siatce, %.6f po doprecyzowaniu", best[0].Cost, refined[0].Cost)
+	}
+	return refined, nil
+}
+
+// mergeGridCheckpoints łączy stany części tego samego przeszukiwania
+func mergeGridCheckpoints(paths []string) (*gridCheckpoint, error) {
+	var merged *gridCheckpoint
+	for _, path := range paths {
+		c, err := loadGridCheckpoint(strings.TrimSpace(path))
+		if err != nil {
+			return nil, err
+		}
+		if merged == nil {
+			merged = c
+			continue
+		}
+		if err := merged.compatible(c); err != nil {
+			return nil, fmt.Errorf("%s: nie pasuje do %s: %v", path, paths[0], err)
+		}
+		for _, chunk := range c.Done {
+			merged.markDone(chunk)
+		}
+		merged.Keep = max(merged.Keep, c.Keep)
+		merged.Best = lppl.MergeGridCells(merged.Keep, merged.Best, c.Best)
+	}
+	if merged == nil {
+		return nil, errors.New("-merge: brak plików stanu")
+	}
+	return merged, nil
+}
+
+// reportGrid wypisuje najlepsze komórki z wynikiem filtrów
+func reportGrid(points []data.Point, state *gridCheckpoint, best []lppl.GridCell, opts lppl.FitOptions) {
+	if len(state.Done) < state.chunks() {
+		log.Printf("Przeszukano %d z %d fragmentów; wyniki są częściowe", len(state.Done), state.chunks())
+	}
+	timeIndex := data.TimeIndex(points)
+	for i, c := range best[:min(5, len(best))] {
+		p := c.Params
+		tc := points[0].Date.Add(time.Duration(p[0] * 24 * float64(time.Hour)))
+		filters := "spełnia filtry"
+		if v := lppl.CheckFilters(p, points, timeIndex, opts.Filters); len(v) > 0 {
+			filters = strings.Join(v, "; ")
+		}
+		log.Printf("%d. koszt %.6f: tc %s, m %.3f, omega %.3f (%s)", i+1, c.Cost, tc.Format(time.DateOnly), p[1], p[2], filters)
+	}
+}
+
+func writeGridCells(path string, points []data.Point, cells []lppl.GridCell, opts lppl.FitOptions) error {
+	file, err := atomicfile.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+
+	timeIndex := data.TimeIndex(points)
+	w := csv.NewWriter(file)
+	w.Write([]string{"cell", "tc", "tc_date", "m", "omega", "A", "B", "C", "phi", "cost", "qualified"})
+	for _, c := range cells {
+		row := []string{strconv.Itoa(c.Index)}
+		for i, v := range c.Params {
+			row = append(row, strconv.FormatFloat(v, 'g', -1, 64))
+			if i == 0 {
+				tc := points[0].Date.Add(time.Duration(v * 24 * float64(time.Hour)))
+				row = append(row, tc.Format(time.DateOnly))
+			}
+		}
+		qualified := len(lppl.CheckFilters(c.Params, points, timeIndex, opts.Filters)) == 0
+		row = append(row, strconv.FormatFloat(c.Cost, 'g', -1, 64), strconv.FormatBool(qualified))
+		w.Write(row)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return file.Commit()
+}
@@ -0,0 +1,144 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"cw3/data"
+	"cw3/internal/safe"
+	"cw3/lppl"
+	"cw3/lpplpb"
+)
+
+type lpplServer struct {
+	lpplpb.UnimplementedLPPLServer
+	opts      lppl.FitOptions
+	minWindow int
+}
+
+func seriesToData(s *lpplpb.Series) ([]data.Point, error) {
+	points := make([]data.Point, 0, len(s.GetObservations()))
+	for _, o := range s.GetObservations() {
+		points = append(points, data.Point{Date: o.GetDate().AsTime(), Price: o.GetPrice()})
+	}
+	if len(points) < lppl.ParamCount+1 {
+		return nil, errors.New("za mało obserwacji w szeregu")
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Date.Before(points[j].Date)
+	})
+	return points, nil
+}
+
+func toProtoFit(window []data.Point, r lppl.Result) *lpplpb.FitResult {
+	p := r.Params
+	start := window[0].Date
+	return &lpplpb.FitResult{
+		WindowStart: timestamppb.New(start),
+		WindowEnd:   timestamppb.New(window[len(window)-1].Date),
+		Tc:          p[0],
+		TcDate:      timestamppb.New(start.Add(time.Duration(p[0] * 24 * float64(time.Hour)))),
+		M:           p[1],
+		Omega:       p[2],
+		A:           p[3],
+		B:           p[4],
+		C:           p[5],
+		Phi:         p[6],
+		Cost:        r.Cost,
+		Qualified:   r.Qualified(),
+		Violations:  r.Violations,
+		Notes:       r.Notes,
+	}
+}
+
+func (s *lpplServer) Fit(ctx context.Context, series *lpplpb.Series) (*lpplpb.FitResult, error) {
+	points, err := seriesToData(series)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var best lppl.Result
+	err = safe.Guard("dopasowanie", func() error {
+		var fitErr error
+		best, fitErr = lppl.Fit(points, s.opts)
+		return fitErr
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return toProtoFit(points, best), nil
+}
+
+func (s *lpplServer) Scan(req *lpplpb.ScanRequest, stream grpc.ServerStreamingServer[lpplpb.ScanUpdate]) error {
+	points, err := seriesToData(req.GetSeries())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	minWindow := int(req.GetMinWindow())
+	if minWindow <= lppl.ParamCount {
+		minWindow = s.minWindow
+	}
+
+	summary := &lpplpb.ScanSummary{}
+	var positive, negative int
+	var bestCost float64
+	for start := 0; start+minWindow <= len(points); start++ {
+		if err := stream.Context().Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		window := points[start:]
+		var r lppl.Result
+		err := safe.Guard("dopasowanie", func() error {
+			var fitErr error
+			r, fitErr = lppl.Fit(window, s.opts)
+			return fitErr
+		})
+		if err != nil {
+			continue
+		}
+		fit := toProtoFit(window, r)
+		if err := stream.Send(&lpplpb.ScanUpdate{Update: &lpplpb.ScanUpdate_Fit{Fit: fit}}); err != nil {
+			return err
+		}
+
+		summary.Windows++
+		if r.Qualified() {
+			summary.Qualified++
+			if r.Params[4] < 0 {
+				positive++
+			} else {
+				negative++
+			}
+		}
+		if summary.Best == nil || r.Cost < bestCost {
+			summary.Best, bestCost = fit, r.Cost
+		}
+	}
+	if summary.Windows > 0 {
+		summary.PositiveFraction = float64(positive) / float64(summary.Windows)
+		summary.NegativeFraction = float64(negative) / float64(summary.Windows)
+	}
+	return stream.Send(&lpplpb.ScanUpdate{Update: &lpplpb.ScanUpdate_Summary{Summary: summary}})
+}
+
+func serveGRPC(addr string, opts lppl.FitOptions, minWindow int) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := grpc.NewServer()
+	lpplpb.RegisterLPPLServer(srv, &lpplServer{opts: opts, minWindow: minWindow})
+	log.Printf("Serwer gRPC nasłuchuje na %s", lis.Addr())
+	return srv.Serve(lis)
+}
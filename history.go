@@ -0,0 +1,166 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// runHistory udostępnia serwerowi analizy z rejestru -runs-file, dopisywane przez
+// zaplanowane przebiegi programu. Rejestr jest zastępowany w całości przy każdym zapisie,
+// więc wystarczy wczytać go ponownie, gdy zmieni się czas modyfikacji lub rozmiar pliku.
+// Bezpieczna dla wielu wątków; zwracane mapy i wycinki nie są później zmieniane.
+type runHistory struct {
+	path string
+
+	mu       sync.Mutex
+	modTime  time.Time
+	size     int64
+	bySymbol map[string][]runRecord
+}
+
+func newRunHistory(path string) *runHistory {
+	return &runHistory{path: path, bySymbol: map[string][]runRecord{}}
+}
+
+// symbols zwraca analizy według symbolu, od najnowszej; brak rejestru oznacza pustą historię
+func (h *runHistory) symbols() (map[string][]runRecord, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	info, err := os.Stat(h.path)
+	if errors.Is(err, os.ErrNotExist) {
+		h.bySymbol, h.modTime, h.size = map[string][]runRecord{}, time.Time{}, 0
+		return h.bySymbol, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.ModTime().Equal(h.modTime) && info.Size() == h.size {
+		return h.bySymbol, nil
+	}
+	registry, err := openRegistry(h.path)
+	if err != nil {
+		return nil, err
+	}
+	h.bySymbol, h.modTime, h.size = groupBySymbol(registry.Runs), info.ModTime(), info.Size()
+	return h.bySymbol, nil
+}
+
+// symbol zwraca historię analiz jednego symbolu, od najnowszej
+func (h *runHistory) symbol(name string) ([]runRecord, bool, error) {
+	bySymbol, err := h.symbols()
+	if err != nil {
+		return nil, false, err
+	}
+	history, ok := bySymbol[name]
+	return history, ok, nil
+}
+
+// names zwraca posortowane symbole z historią
+func (h *runHistory) names() ([]string, error) {
+	bySymbol, err := h.symbols()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(bySymbol))
+	for name := range bySymbol {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Domyślna i największa liczba analiz na stronie GET /history
+const (
+	historyPageSize = 50
+	historyMaxPage  = 500
+)
+
+// historyEntry to jedna analiza w odpowiedzi GET /history
+type historyEntry struct {
+	Time       time.Time `json:"time"`
+	End        time.Time `json:"end"`
+	Tc         time.Time `json:"tc"`
+	TcDays     float64   `json:"tc_days"`
+	Cost       float64   `json:"cost"`
+	Qualified  bool      `json:"qualified"`
+	Confidence float64   `json:"confidence"`
+}
+
+type historyPage struct {
+	Symbol string         `json:"symbol"`
+	Total  int            `json:"total"`
+	Offset int            `json:"offset"`
+	Limit  int            `json:"limit"`
+	Runs   []historyEntry `json:"runs"`
+	// Adres następnej strony, pusty na ostatniej
+	Next string `json:"next,omitempty"`
+}
+
+// handleHistory zwraca analizy symbolu od najnowszej: ewolucję tc i pewności bańki,
+// stronicowane parametrami ?offset= i ?limit=, z filtrem ?qualified=true|false
+func (s *apiServer) handleHistory(w http.ResponseWriter, r *http.Request) {
+	symbol := r.PathValue("symbol")
+	q := r.URL.Query()
+	page := historyPage{Symbol: symbol, Limit: historyPageSize, Runs: []historyEntry{}}
+	for _, p := range []struct {
+		name string
+		v    *int
+		max  int
+	}{{"offset", &page.Offset, math.MaxInt32}, {"limit", &page.Limit, historyMaxPage}} {
+		if v := q.Get(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 || n > p.max || (p.name == "limit" && n == 0) {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("%s: nieprawidłowa wartość %q", p.name, v))
+				return
+			}
+			*p.v = n
+		}
+	}
+	var qualified *bool
+	if v := q.Get("qualified"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("qualified: nieprawidłowa wartość %q", v))
+			return
+		}
+		qualified = &b
+	}
+
+	history, ok, err := s.history.symbol(symbol)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("brak analiz symbolu %s", symbol))
+		return
+	}
+	var matching []runRecord
+	for _, rec := range history {
+		if qualified == nil || rec.Qualified == *qualified {
+			matching = append(matching, rec)
+		}
+	}
+	page.Total = len(matching)
+	for _, rec := range matching[min(page.Offset, len(matching)):min(page.Offset+page.Limit, len(matching))] {
+		page.Runs = append(page.Runs, historyEntry{
+			Time: rec.Time, End: rec.End, Tc: rec.Tc, TcDays: metricValue(rec, "tc_days"),
+			Cost: rec.Cost, Qualified: rec.Qualified, Confidence: rec.Confidence,
+		})
+	}
+	if next := page.Offset + page.Limit; next < page.Total {
+		q.Set("offset", strconv.Itoa(next))
+		q.Set("limit", strconv.Itoa(page.Limit))
+		page.Next = r.URL.Path + "?" + q.Encode()
+	}
+	writeJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,102 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cw3/data"
+	"cw3/internal/atomicfile"
+	"cw3/lppl"
+)
+
+// icsDate to format dat wydarzeń całodniowych w iCalendar
+const icsDate = "20060102"
+
+// criticalWindow zwraca przedział dni, w którym model umieszcza tc: przedział ufności
+// bootstrapu, gdy był liczony, a w przeciwnym razie sam dzień tc
+func criticalWindow(points []data.Point, best lppl.Result) (from, to time.Time) {
+	lo, hi := best.Params[0], best.Params[0]
+	if b := best.Bootstrap; b != nil {
+		lo, hi = b.Intervals[0][0], b.Intervals[0][1]
+	}
+	day := func(t float64) time.Time {
+		return points[0].Date.Add(time.Duration(t * 24 * float64(time.Hour))).UTC().Truncate(24 * time.Hour)
+	}
+	return day(lo), day(hi)
+}
+
+// writeICS zapisuje okno krytyczne jako wydarzenie całodniowe w pliku iCalendar. UID
+// zależy tylko od symbolu, a SEQUENCE rośnie z czasem przebiegu, więc kalendarz
+// subskrybujący plik przesuwa istniejące wydarzenie zamiast dodawać nowe. Plik jest
+// zastępowany bez -overwrite, bo ma się zmieniać przy każdej zaplanowanej analizie.
+func writeICS(path, symbol string, points []data.Point, best lppl.Result, confidence float64, now time.Time) error {
+	from, to := criticalWindow(points, best)
+	tc := points[0].Date.Add(time.Duration(best.Params[0] * 24 * float64(time.Hour)))
+	status, kind := "TENTATIVE", "dopasowanie nie spełnia filtrów"
+	if best.Qualified() {
+		status, kind = "CONFIRMED", "dopasowanie spełnia filtry"
+	}
+	description := fmt.Sprintf("tc %s, %s; pewność bańki %.2f, koszt %.6f; dane do %s.",
+		tc.Format(time.DateOnly), kind, confidence, best.Cost, points[len(points)-1].Date.Format(time.DateOnly))
+	if b := best.Bootstrap; b != nil {
+		description += fmt.Sprintf(" Okno to przedział ufności %.0f%% z %d próbek bootstrapu.", 100*b.Level, b.Samples)
+	}
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//cw3//lppl//PL",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:" + icsText("Okna krytyczne LPPL: "+symbol),
+		"BEGIN:VEVENT",
+		"UID:" + icsText("lppl-"+strings.ToLower(symbol)+"@cw3"),
+		fmt.Sprintf("SEQUENCE:%d", now.Unix()),
+		"DTSTAMP:" + now.UTC().Format("20060102T150405Z"),
+		"DTSTART;VALUE=DATE:" + from.Format(icsDate),
+		"DTEND;VALUE=DATE:" + to.AddDate(0, 0, 1).Format(icsDate),
+		"SUMMARY:" + icsText(symbol+": okno krytyczne LPPL"),
+		"DESCRIPTION:" + icsText(description),
+		"STATUS:" + status,
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}
+
+	f, err := atomicfile.Replace(path)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+	for _, line := range lines {
+		if _, err := f.WriteString(icsFold(line)); err != nil {
+			return err
+		}
+	}
+	return f.Commit()
+}
+
+// icsText ucieka znaki specjalne wartości tekstowej (RFC 5545, 3.3.11)
+func icsText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// icsFold łamie wiersz na fragmenty po co najwyżej 75 bajtów, nie dzieląc znaków UTF-8,
+// i kończy go CRLF
+func icsFold(line string) string {
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		n := len(string(r))
+		if width+n > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	b.WriteString("\r\n")
+	return b.String()
+}
@@ -0,0 +1,104 @@
+// Package atomicfile zapisuje pliki wynikowe przez plik tymczasowy w katalogu docelowym,
+// dzięki czemu przerwany przebieg ani równoległy proces nie zostawiają połowicznych plików.
+package atomicfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Overwrite pozwala Create zastępować istniejące pliki (flaga -overwrite); domyślnie
+// zapis do istniejącej ścieżki kończy się błędem
+var Overwrite bool
+
+// Committed, jeśli nie jest nil, dostaje ścieżkę każdego pliku z Create umieszczonego
+// pod docelową nazwą (np. do wysłania wyników do zasobnika)
+var Committed func(path string)
+
+// File to plik tymczasowy, który Commit umieszcza pod docelową nazwą
+type File struct {
+	*os.File
+	path    string
+	replace bool
+	output  bool
+	done    bool
+}
+
+// Create otwiera plik tymczasowy dla path; gdy path istnieje, a Overwrite nie jest
+// ustawione, zwraca błąd od razu, zanim zostanie wykonana praca na zapis
+func Create(path string) (*File, error) {
+	if !Overwrite {
+		if _, err := os.Lstat(path); err == nil {
+			return nil, existsError(path)
+		}
+	}
+	f, err := create(path, Overwrite)
+	if f != nil {
+		f.output = true
+	}
+	return f, err
+}
+
+// Replace działa jak Create, ale zawsze zastępuje istniejący plik; służy do plików stanu
+// (np. rejestru analiz) i stron odtwarzanych w całości przy każdym przebiegu
+func Replace(path string) (*File, error) {
+	return create(path, true)
+}
+
+func create(path string, replace bool) (*File, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &File{File: f, path: path, replace: replace}, nil
+}
+
+// Commit zapisuje plik na dysk i przenosi go pod docelową nazwę. Bez zgody na nadpisanie
+// używa dowiązania twardego, które nie zastępuje pliku utworzonego w międzyczasie.
+func (f *File) Commit() error {
+	if f.done {
+		return nil
+	}
+	f.done = true
+	defer os.Remove(f.Name())
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		return err
+	}
+	if f.replace {
+		if err := os.Rename(f.Name(), f.path); err != nil {
+			return err
+		}
+	} else if err := os.Link(f.Name(), f.path); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return existsError(f.path)
+		}
+		return err
+	}
+	if f.output && Committed != nil {
+		Committed(f.path)
+	}
+	return nil
+}
+
+// Abort usuwa plik tymczasowy; po Commit nic nie robi, więc nadaje się do defer
+func (f *File) Abort() {
+	if f.done {
+		return
+	}
+	f.done = true
+	f.Close()
+	os.Remove(f.Name())
+}
+
+func existsError(path string) error {
+	return fmt.Errorf("plik %s już istnieje (użyj -overwrite, aby go zastąpić)", path)
+}
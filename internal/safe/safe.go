@@ -0,0 +1,18 @@
+// Package safe chroni etapy obliczeń przed paniką w bibliotekach zewnętrznych.
+package safe
+
+import (
+	"fmt"
+)
+
+// Guard wykonuje fn i zamienia ewentualną panikę na błąd, żeby patologiczne okno
+// (np. macierz zerowego rozmiaru w gonum) nie przerywało pracy serwera obsługującego
+// wiele symboli ani procesu, który załadował bibliotekę
+func Guard(stage string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s: przechwycona panika: %v", stage, r)
+		}
+	}()
+	return fn()
+}
@@ -0,0 +1,37 @@
+// Package stats zawiera proste statystyki opisowe używane w kilku pakietach.
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+func Median(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func Mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func Variance(values []float64) float64 {
+	mu := Mean(values)
+	var sum float64
+	for _, v := range values {
+		sum += (v - mu) * (v - mu)
+	}
+	return sum / float64(len(values))
+}
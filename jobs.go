@@ -0,0 +1,190 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"cw3/data"
+	"cw3/lppl"
+	"cw3/plot"
+)
+
+// Stany zadania dopasowania
+const (
+	jobQueued  = "queued"
+	jobRunning = "running"
+	jobDone    = "done"
+	jobFailed  = "failed"
+)
+
+// fitJob to zadanie dopasowania zlecone przez POST /jobs
+type fitJob struct {
+	ID        string    `json:"id"`
+	Status    string    `json:"status"`
+	Rows      int       `json:"rows"`
+	Submitted time.Time `json:"submitted"`
+	Finished  time.Time `json:"finished,omitzero"`
+	Error     string    `json:"error,omitempty"`
+	Fit       *fitReply `json:"fit,omitempty"`
+	// Adres wykresu dopasowania, gdy udało się go narysować
+	Plot string `json:"plot,omitempty"`
+
+	points   []data.Point
+	opts     lppl.FitOptions
+	plotPath string
+}
+
+// jobQueue wykonuje zadania w stałej liczbie wątków i przechowuje wyniki przez ttl
+type jobQueue struct {
+	dir     string
+	ttl     time.Duration
+	queue   chan *fitJob
+	metrics *fitMetrics
+
+	mu   sync.Mutex
+	jobs map[string]*fitJob
+}
+
+func newJobQueue(ctx context.Context, workers int, ttl time.Duration, metrics *fitMetrics) (*jobQueue, error) {
+	dir, err := os.MkdirTemp("", "lppl-jobs-")
+	if err != nil {
+		return nil, err
+	}
+	q := &jobQueue{dir: dir, ttl: ttl, queue: make(chan *fitJob, 1024), metrics: metrics, jobs: map[string]*fitJob{}}
+	for range workers {
+		go q.work(ctx)
+	}
+	return q, nil
+}
+
+// close usuwa wykresy zadań
+func (q *jobQueue) close() {
+	os.RemoveAll(q.dir)
+}
+
+// submit dodaje zadanie do kolejki; zwraca błąd, gdy kolejka jest pełna
+func (q *jobQueue) submit(points []data.Point, opts lppl.FitOptions) (fitJob, error) {
+	id := make([]byte, 8)
+	rand.Read(id)
+	job := &fitJob{ID: hex.EncodeToString(id), Status: jobQueued, Rows: len(points), Submitted: time.Now().UTC(), points: points, opts: opts}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.expire()
+	select {
+	case q.queue <- job:
+	default:
+		return fitJob{}, errors.New("kolejka zadań jest pełna")
+	}
+	q.jobs[job.ID] = job
+	return *job, nil
+}
+
+// get zwraca kopię stanu zadania
+func (q *jobQueue) get(id string) (fitJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return fitJob{}, false
+	}
+	return *job, true
+}
+
+// expire usuwa zakończone zadania starsze niż ttl; wymaga blokady q.mu
+func (q *jobQueue) expire() {
+	for id, job := range q.jobs {
+		if !job.Finished.IsZero() && time.Since(job.Finished) > q.ttl {
+			if job.plotPath != "" {
+				os.Remove(job.plotPath)
+			}
+			delete(q.jobs, id)
+		}
+	}
+}
+
+func (q *jobQueue) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-q.queue:
+			q.run(job)
+		}
+	}
+}
+
+func (q *jobQueue) run(job *fitJob) {
+	q.mu.Lock()
+	job.Status = jobRunning
+	q.mu.Unlock()
+
+	reply, err := fitReplyFor(job.points, job.opts, "http")
+	q.metrics.count(apiAsset, err)
+	var plotPath string
+	if err == nil {
+		plotPath = filepath.Join(q.dir, job.ID+".png")
+		meta := plot.Meta{Start: job.points[0].Date, End: job.points[len(job.points)-1].Date}
+		if perr := plot.Results(job.points, reply.best.Params, plot.Extras{Meta: meta}, plotPath); perr != nil {
+			log.Printf("Zadanie %s: wykres nieudany: %v", job.ID, perr)
+			plotPath = ""
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job.Finished = time.Now().UTC()
+	job.points = nil
+	if err != nil {
+		job.Status, job.Error = jobFailed, err.Error()
+		return
+	}
+	job.Status, job.Fit = jobDone, reply
+	if plotPath != "" {
+		job.plotPath, job.Plot = plotPath, "/jobs/"+job.ID+"/plot.png"
+	}
+}
+
+// handleSubmit przyjmuje dane jak POST /fit i od razu zwraca identyfikator zadania
+func (s *apiServer) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	points, opts, err := s.readFitInput(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	job, err := s.jobs.submit(points, opts)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+func (s *apiServer) handleJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.jobs.get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("nie ma takiego zadania"))
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (s *apiServer) handleJobPlot(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.jobs.get(r.PathValue("id"))
+	if !ok || job.plotPath == "" {
+		writeError(w, http.StatusNotFound, errors.New("brak wykresu zadania"))
+		return
+	}
+	http.ServeFile(w, r, job.plotPath)
+}
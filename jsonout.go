@@ -0,0 +1,105 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"cw3/data"
+	"cw3/lppl"
+)
+
+// Formaty wyniku na standardowym wyjściu (-format)
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+// paramNames to nazwy parametrów w kolejności lppl.Result.Params
+var paramNames = [lppl.ParamCount]string{"tc", "m", "omega", "A", "B", "C", "phi"}
+
+// fitDocument to wynik analizy jednego szeregu dla -format json
+type fitDocument struct {
+	Symbol     string          `json:"symbol"`
+	Input      string          `json:"input"`
+	Params     []paramValue    `json:"params"`
+	Cost       float64         `json:"cost"`
+	Iterations int             `json:"iterations"`
+	Starts     int             `json:"starts"`
+	Qualified  bool            `json:"qualified"`
+	Violations []string        `json:"violations"`
+	Confidence float64         `json:"confidence"`
+	Data       dataRange       `json:"data"`
+	Crash      crashPrediction `json:"crash"`
+	Provenance provenance      `json:"provenance"`
+}
+
+// paramValue to dopasowany parametr z przedziałem ufności bootstrapu, jeśli był liczony
+type paramValue struct {
+	Name     string      `json:"name"`
+	Value    float64     `json:"value"`
+	Interval *[2]float64 `json:"interval,omitempty"`
+}
+
+type dataRange struct {
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+	Observations int       `json:"observations"`
+}
+
+// crashPrediction to przewidywana data krytyczna i, dla kwalifikowanej bańki dodatniej,
+// szacunek spadku po niej
+type crashPrediction struct {
+	Date       time.Time `json:"date"`
+	DaysAhead  float64   `json:"days_ahead"`
+	Resolution string    `json:"resolution,omitempty"`
+	Expected   *float64  `json:"expected_drawdown,omitempty"`
+	Lower      *float64  `json:"drawdown_lower,omitempty"`
+	Upper      *float64  `json:"drawdown_upper,omitempty"`
+}
+
+func newFitDocument(rec runRecord, input string, points []data.Point, best lppl.Result, cal lppl.CrashCalibration, prov provenance) fitDocument {
+	doc := fitDocument{
+		Symbol: rec.Symbol, Input: input,
+		Cost: best.Cost, Iterations: best.Iterations, Starts: best.Starts,
+		Qualified: best.Qualified(), Violations: best.Violations, Confidence: rec.Confidence,
+		Data: dataRange{From: points[0].Date, To: rec.End, Observations: len(points)},
+		Crash: crashPrediction{
+			Date:       rec.Tc.UTC().Round(time.Second),
+			DaysAhead:  rec.Tc.Sub(rec.End).Hours() / 24,
+			Resolution: lppl.ClassifyResolution(best),
+		},
+		Provenance: prov,
+	}
+	if doc.Violations == nil {
+		doc.Violations = []string{}
+	}
+	for i, v := range best.Params {
+		p := paramValue{Name: paramNames[i], Value: v}
+		if b := best.Bootstrap; b != nil {
+			p.Interval = &b.Intervals[i]
+		}
+		doc.Params = append(doc.Params, p)
+	}
+	if crash, err := lppl.EstimateCrash(best, points, cal); err == nil {
+		doc.Crash.Expected, doc.Crash.Lower, doc.Crash.Upper = &crash.Expected, &crash.Lower, &crash.Upper
+	}
+	return doc
+}
+
+// writeFitDocument zapisuje dokument w jednym wierszu, więc kolejne szeregi tworzą JSON Lines
+func writeFitDocument(w io.Writer, doc fitDocument) error {
+	return json.NewEncoder(w).Encode(doc)
+}
+
+// checkFormat sprawdza wartość flagi -format
+func checkFormat(v string) error {
+	switch v {
+	case formatText, formatJSON:
+		return nil
+	}
+	return fmt.Errorf("nieznany format %q (dostępne: %s, %s)", v, formatText, formatJSON)
+}
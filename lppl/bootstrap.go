@@ -0,0 +1,135 @@
+package lppl
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+
+	"gonum.org/v1/gonum/stat"
+
+	"cw3/data"
+)
+
+// BootstrapOptions opisuje blokowy bootstrap reszt dopasowania
+type BootstrapOptions struct {
+	// Liczba ponownych dopasowań (0 wyłącza bootstrap)
+	Samples int
+	// Długość bloku reszt w obserwacjach; 0 oznacza n^(1/3) zaokrąglone w górę
+	BlockLength int
+	// Poziom ufności przedziałów percentylowych, np. 0.9
+	Level float64
+	Seed  int64
+}
+
+func DefaultBootstrapOptions() BootstrapOptions {
+	return BootstrapOptions{Level: 0.9}
+}
+
+// BootstrapResult zawiera percentylowe przedziały ufności parametrów
+type BootstrapResult struct {
+	Samples, Failed int
+	Level           float64
+	// Ziarno główne, z którego wyprowadzono ziarna próbek
+	Seed int64
+	// Przedziały [dolny, górny] kolejnych parametrów (tc, m, omega, A, B, C, phi)
+	Intervals [ParamCount][2]float64
+}
+
+// replicaSeed wyprowadza ziarno próbki z ziarna głównego funkcją mieszającą SplitMix64.
+// Proste seed+replica sprawiałoby, że kolejne ziarna główne dzielą prawie wszystkie
+// próbki (próbka 1 dla ziarna 0 to próbka 0 dla ziarna 1).
+func replicaSeed(master int64, replica int) int64 {
+	z := uint64(master) + uint64(replica+1)*0x9e3779b97f4a7c15
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return int64(z ^ z>>31)
+}
+
+// minBootstrapSamples to najmniejsza liczba udanych dopasowań, z której liczone są przedziały
+const minBootstrapSamples = 10
+
+// Bootstrap szacuje niepewność dopasowania best: reszty ln(ceny) są losowane blokami
+// (co zachowuje ich autokorelację), dodawane do wartości modelu, a powstałe szeregi
+// dopasowywane ponownie z ustawieniami opts, startując z parametrów best. Każda próbka
+// ma własne ziarno wyprowadzone z bopts.Seed i numeru próbki (replicaSeed), więc dla
+// danego ziarna głównego wynik nie zależy od liczby równoległych dopasowań.
+func Bootstrap(points []data.Point, best Result, opts FitOptions, bopts BootstrapOptions) (BootstrapResult, error) {
+	timeIndex := data.TimeIndex(points)
+	p := best.Params
+	model := make([]float64, len(points))
+	residuals := make([]float64, len(points))
+	for i, point := range points {
+		model[i] = Model(timeIndex[i], p[0], p[1], p[2], p[3], p[4], p[5], p[6])
+		residuals[i] = math.Log(point.Price) - model[i]
+	}
+	block := bopts.BlockLength
+	if block <= 0 {
+		block = int(math.Ceil(math.Cbrt(float64(len(points)))))
+	}
+	block = min(block, len(points))
+
+	refit := opts
+	refit.StartM, refit.StartOmega = []float64{p[1]}, []float64{p[2]}
+	refit.RandomStarts, refit.Workers = 0, 1
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	samples := make([][]float64, bopts.Samples)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, bopts.Samples) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range jobs {
+				rng := rand.New(rand.NewSource(replicaSeed(bopts.Seed, s)))
+				synthetic := make([]data.Point, len(points))
+				for i := 0; i < len(points); i += block {
+					from := rng.Intn(len(points) - block + 1)
+					for j := 0; j < block && i+j < len(points); j++ {
+						synthetic[i+j] = data.Point{Date: points[i+j].Date, Price: math.Exp(model[i+j] + residuals[from+j])}
+					}
+				}
+				if r, err := Fit(synthetic, refit); err == nil {
+					samples[s] = r.Params
+				}
+			}
+		}()
+	}
+	for s := range samples {
+		jobs <- s
+	}
+	close(jobs)
+	wg.Wait()
+
+	result := BootstrapResult{Samples: bopts.Samples, Level: bopts.Level, Seed: bopts.Seed}
+	var ok [][]float64
+	for _, s := range samples {
+		if s == nil {
+			result.Failed++
+			continue
+		}
+		ok = append(ok, s)
+	}
+	if len(ok) < minBootstrapSamples {
+		return result, errors.New("za mało udanych dopasowań bootstrapowych do wyznaczenia przedziałów")
+	}
+	tail := (1 - bopts.Level) / 2
+	values := make([]float64, len(ok))
+	for k := range ParamCount {
+		for i, s := range ok {
+			values[i] = s[k]
+		}
+		sort.Float64s(values)
+		result.Intervals[k] = [2]float64{
+			stat.Quantile(tail, stat.Empirical, values, nil),
+			stat.Quantile(1-tail, stat.Empirical, values, nil),
+		}
+	}
+	return result, nil
+}
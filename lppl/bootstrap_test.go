@@ -0,0 +1,40 @@
+package lppl
+
+import "testing"
+
+// Każda próbka ma własne ziarno, więc przedziały nie mogą zależeć od liczby wątków
+func TestBootstrapIndependentOfWorkers(t *testing.T) {
+	points := noisyBubble(100, 0.01, 3)
+	opts := DefaultFitOptions()
+	opts.MaxIterations = 500
+	opts.Workers = 1
+	best, _, err := FitAll(points, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bopts := DefaultBootstrapOptions()
+	bopts.Samples, bopts.Seed = 24, 42
+
+	var results []BootstrapResult
+	for _, workers := range []int{1, 8} {
+		opts.Workers = workers
+		r, err := Bootstrap(points, best, opts, bopts)
+		if err != nil {
+			t.Fatalf("Workers=%d: %v", workers, err)
+		}
+		results = append(results, r)
+	}
+	if results[0] != results[1] {
+		t.Errorf("Workers=1: %+v\nWorkers=8: %+v", results[0], results[1])
+	}
+
+	// Inne ziarno główne losuje inne bloki reszt
+	bopts.Seed = 43
+	other, err := Bootstrap(points, best, opts, bopts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Intervals == results[0].Intervals {
+		t.Error("różne ziarna dały identyczne przedziały")
+	}
+}
@@ -0,0 +1,59 @@
+package lppl
+
+import (
+	"fmt"
+	"math"
+)
+
+// Bounds to ograniczenia przedziałowe m i omega (tc ograniczają TcMinFrac i TcMaxFrac).
+// Optymalizator działa bez ograniczeń, więc tc, m i omega są mapowane sigmoidą na
+// przedziały otwarte i wynik nigdy nie wychodzi poza granice.
+type Bounds struct {
+	M     [2]float64
+	Omega [2]float64
+}
+
+// DefaultBounds dopuszcza przyspieszający wzrost (0 < m < 1) i od kilku do kilkunastu
+// oscylacji na dekadę ln(tc-t) (2 < omega < 25)
+func DefaultBounds() Bounds {
+	return Bounds{M: [2]float64{0, 1}, Omega: [2]float64{2, 25}}
+}
+
+func (b Bounds) check() error {
+	if !(b.M[0] < b.M[1]) {
+		return fmt.Errorf("nieprawidłowy zakres m: [%g, %g]", b.M[0], b.M[1])
+	}
+	if !(b.Omega[0] < b.Omega[1]) {
+		return fmt.Errorf("nieprawidłowy zakres omega: [%g, %g]", b.Omega[0], b.Omega[1])
+	}
+	return nil
+}
+
+// bound odwzorowuje u z całej prostej na przedział (lo, hi)
+func bound(u, lo, hi float64) float64 {
+	return lo + (hi-lo)/(1+math.Exp(-u))
+}
+
+// unbound odwraca bound; wartości spoza przedziału (np. start podany przez użytkownika)
+// są przesuwane tuż do jego wnętrza
+func unbound(x, lo, hi float64) float64 {
+	p := math.Min(math.Max((x-lo)/(hi-lo), boundMargin), 1-boundMargin)
+	return math.Log(p / (1 - p))
+}
+
+// boundMargin to najmniejsza względna odległość startu od granicy przedziału
+const boundMargin = 1e-3
+
+// boundedPosition zwraca położenie x w przedziale (lo, hi) jako ułamek jego szerokości
+func boundedPosition(x, lo, hi float64) float64 {
+	return (x - lo) / (hi - lo)
+}
+
+// overlap zwraca część wspólną przedziałów a i b albo b, gdy są rozłączne
+func overlap(a, b [2]float64) [2]float64 {
+	lo, hi := math.Max(a[0], b[0]), math.Min(a[1], b[1])
+	if lo >= hi {
+		return b
+	}
+	return [2]float64{lo, hi}
+}
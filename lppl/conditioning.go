@@ -0,0 +1,75 @@
+package lppl
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+
+	"cw3/data"
+)
+
+// Progi, poniżej których parametr uznajemy za praktycznie nieidentyfikowalny
+const (
+	maxConditionNumber = 1e8
+	minAmplitudeC      = 1e-3
+	minAmplitudeB      = 1e-6
+	minExponentM       = 1e-2
+)
+
+type ConditionReport struct {
+	// Wskaźnik uwarunkowania macierzy [1, f, g, h] podproblemu liniowego dla A, B, C1, C2
+	Cond float64
+	// Uwagi do poszczególnych parametrów, według nazwy (tc, m, omega, A, B, C, phi)
+	Unidentifiable map[string]string
+	Warnings       []string
+}
+
+// Conditioning ocenia, na ile dopasowane parametry są wyznaczone przez dane. Dla
+// ustalonych tc, m i omega model jest liniowy w A, B, C1 = BC·cos(phi) i C2 = -BC·sin(phi).
+func Conditioning(points []data.Point, params []float64) ConditionReport {
+	tc, m, omega, _, B, C := params[0], params[1], params[2], params[3], params[4], params[5]
+	timeIndex := data.TimeIndex(points)
+
+	var rows []float64
+	for _, t := range timeIndex {
+		dt := tc - t
+		if dt <= 0 {
+			continue
+		}
+		f := math.Pow(dt, m)
+		rows = append(rows, 1, f, f*math.Cos(omega*math.Log(dt)), f*math.Sin(omega*math.Log(dt)))
+	}
+
+	r := ConditionReport{Unidentifiable: map[string]string{}}
+	if len(rows) >= 4*4 {
+		r.Cond = mat.Cond(mat.NewDense(len(rows)/4, 4, rows), 2)
+	} else {
+		r.Cond = math.Inf(1)
+	}
+	if r.Cond > maxConditionNumber || math.IsNaN(r.Cond) {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("podproblem liniowy źle uwarunkowany (cond=%.3g), A, B i C są silnie skorelowane", r.Cond))
+	}
+
+	if math.Abs(C) < minAmplitudeC {
+		r.Unidentifiable["phi"] = "C ≈ 0, faza oscylacji nie ma znaczenia"
+		r.Unidentifiable["omega"] = "C ≈ 0, częstość oscylacji słabo wyznaczona"
+	}
+	if math.Abs(B) < minAmplitudeB {
+		r.Unidentifiable["m"] = "B ≈ 0, wykładnik nie wpływa na model"
+		r.Unidentifiable["C"] = "B ≈ 0, amplituda oscylacji nie wpływa na model"
+	}
+	if math.Abs(m) < minExponentM {
+		r.Unidentifiable["A"] = "m ≈ 0, (tc-t)^m jest stałe i A miesza się z B"
+		r.Unidentifiable["B"] = "m ≈ 0, (tc-t)^m jest stałe i B miesza się z A"
+	}
+	return r
+}
+
+// Annotate zwraca dopisek do parametru oznaczonego jako nieidentyfikowalny
+func (r ConditionReport) Annotate(name string) string {
+	if reason, ok := r.Unidentifiable[name]; ok {
+		return " [nieidentyfikowalny: " + reason + "]"
+	}
+	return ""
+}
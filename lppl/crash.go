@@ -0,0 +1,53 @@
+package lppl
+
+import (
+	"errors"
+	"math"
+
+	"cw3/data"
+)
+
+// CrashCalibration opisuje, jaką część wzrostu bańki (w log-cenie) znosi krach. Kappa
+// i jej odchylenie standardowe ustala się na historycznych bańkach; wartości domyślne
+// odpowiadają krachom, które zniosły średnio połowę wzrostu od początku okna.
+type CrashCalibration struct {
+	Kappa   float64
+	KappaSD float64
+}
+
+func DefaultCrashCalibration() CrashCalibration {
+	return CrashCalibration{Kappa: 0.5, KappaSD: 0.2}
+}
+
+// CrashEstimate to oczekiwany spadek ceny po tc (ułamek ceny) z przedziałem kappa ± 1 odch. std.
+type CrashEstimate struct {
+	// Gain to wzrost log-ceny modelu od początku okna do tc, |B|(tc-t1)^m
+	Gain         float64
+	Expected     float64
+	Lower, Upper float64
+}
+
+// EstimateCrash szacuje wielkość krachu dla kwalifikowanego dopasowania bańki dodatniej.
+// W modelu LPPL log-cena dąży do A, a wzrost od początku okna wynosi |B|(tc-t1)^m
+// (bez składnika oscylacyjnego); krach znosi ułamek kappa tego wzrostu.
+func EstimateCrash(best Result, points []data.Point, cal CrashCalibration) (CrashEstimate, error) {
+	if !best.Qualified() {
+		return CrashEstimate{}, errors.New("dopasowanie nie spełnia filtrów")
+	}
+	tc, m, B := best.Params[0], best.Params[1], best.Params[4]
+	if B >= 0 {
+		return CrashEstimate{}, errors.New("dopasowanie opisuje bańkę ujemną (B >= 0)")
+	}
+	t1 := data.TimeIndex(points)[0]
+	gain := -B * math.Pow(tc-t1, m)
+
+	drop := func(kappa float64) float64 {
+		return 1 - math.Exp(-math.Max(kappa, 0)*gain)
+	}
+	return CrashEstimate{
+		Gain:     gain,
+		Expected: drop(cal.Kappa),
+		Lower:    drop(cal.Kappa - cal.KappaSD),
+		Upper:    drop(cal.Kappa + cal.KappaSD),
+	}, nil
+}
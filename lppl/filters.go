@@ -0,0 +1,89 @@
+package lppl
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"cw3/data"
+)
+
+// FilterConfig opisuje warunki kwalifikacji dopasowania LPPL; wartość 0 przy
+// MinOscillations, MinDamping i MaxRelError wyłącza dany warunek
+type FilterConfig struct {
+	MinM, MaxM         float64
+	MinOmega, MaxOmega float64
+	MinDamping         float64
+	MinOscillations    float64
+	MaxRelError        float64
+}
+
+// Zestawy progów z literatury
+var FilterPresets = map[string]FilterConfig{
+	"default": {
+		MinM: 0.1, MaxM: 0.9,
+		MinOmega: 6, MaxOmega: 13,
+		MinDamping: 0.8,
+	},
+	// Sornette i in. (2015), bańka na giełdzie w Szanghaju
+	"sornette2015": {
+		MinM: 0.01, MaxM: 1.2,
+		MinOmega: 2, MaxOmega: 25,
+		MinDamping:      0.5,
+		MinOscillations: 2.5,
+		MaxRelError:     0.05,
+	},
+	// Filimonov, Demos, Sornette (2017)
+	"filimonov2017": {
+		MinM: 0.01, MaxM: 0.99,
+		MinOmega: 2, MaxOmega: 15,
+		MinDamping:      0.8,
+		MinOscillations: 2.5,
+	},
+}
+
+func FilterPresetNames() string {
+	names := make([]string, 0, len(FilterPresets))
+	for name := range FilterPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// CheckFilters zwraca listę naruszonych warunków; pusta lista oznacza dopasowanie kwalifikowane
+func CheckFilters(params []float64, points []data.Point, timeIndex []float64, fc FilterConfig) []string {
+	tc, m, omega, A, B, C, phi := params[0], params[1], params[2], params[3], params[4], params[5], params[6]
+
+	var violations []string
+	if m < fc.MinM || m > fc.MaxM {
+		violations = append(violations, fmt.Sprintf("m=%.3f poza [%.2f, %.2f]", m, fc.MinM, fc.MaxM))
+	}
+	if omega < fc.MinOmega || omega > fc.MaxOmega {
+		violations = append(violations, fmt.Sprintf("omega=%.3f poza [%.2f, %.2f]", omega, fc.MinOmega, fc.MaxOmega))
+	}
+	if fc.MinDamping > 0 {
+		if damping := m * math.Abs(B) / (omega * math.Abs(C)); damping < fc.MinDamping {
+			violations = append(violations, fmt.Sprintf("tłumienie=%.3f < %.2f", damping, fc.MinDamping))
+		}
+	}
+	if fc.MinOscillations > 0 {
+		t1, t2 := timeIndex[0], timeIndex[len(timeIndex)-1]
+		oscillations := omega / (2 * math.Pi) * math.Log((tc-t1)/(tc-t2))
+		if !(oscillations >= fc.MinOscillations) {
+			violations = append(violations, fmt.Sprintf("oscylacje=%.2f < %.2f", oscillations, fc.MinOscillations))
+		}
+	}
+	if fc.MaxRelError > 0 {
+		var maxErr float64
+		for i, point := range points {
+			predicted := math.Exp(Model(timeIndex[i], tc, m, omega, A, B, C, phi))
+			maxErr = math.Max(maxErr, math.Abs(predicted-point.Price)/point.Price)
+		}
+		if maxErr > fc.MaxRelError {
+			violations = append(violations, fmt.Sprintf("błąd względny=%.3f > %.3f", maxErr, fc.MaxRelError))
+		}
+	}
+	return violations
+}
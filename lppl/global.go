@@ -0,0 +1,48 @@
+package lppl
+
+import (
+	"fmt"
+	"math/rand/v2"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/optimize"
+)
+
+// Odchylenia początkowego rozkładu CMA-ES dla tc, m i omega przed odwzorowaniem
+// sigmoidą na ich przedziały; 1.5 obejmuje większość przedziału
+var globalScales = []float64{1.5, 1.5, 1.5}
+
+// globalMaxEvaluations ogranicza liczbę ocen kosztu w jednym przebiegu CMA-ES
+const globalMaxEvaluations = 20000
+
+// globalStallGenerations to liczba pokoleń bez poprawy kosztu kończąca przebieg
+const globalStallGenerations = 100
+
+// globalSearch przeszukuje przestrzeń parametrów nieliniowych strategią CMA-ES,
+// zaczynając od rozkładu wokół initial, i zwraca najlepszy punkt do dopracowania
+// metodą lokalną (nil, gdy przebieg się nie powiódł) oraz opis przebiegu
+func globalSearch(problem optimize.Problem, initial []float64, seed int64) ([]float64, string) {
+	cov := mat.NewSymDense(len(globalScales), nil)
+	for i, s := range globalScales {
+		cov.SetSym(i, i, s*s)
+	}
+	var chol mat.Cholesky
+	if !chol.Factorize(cov) {
+		return nil, "CMA-ES: nieprawidłowa macierz kowariancji startowej"
+	}
+	method := &optimize.CmaEsChol{
+		InitStepSize: 1,
+		InitCholesky: &chol,
+		Src:          rand.NewPCG(uint64(seed), 0),
+	}
+	settings := &optimize.Settings{
+		FuncEvaluations: globalMaxEvaluations,
+		Converger:       &optimize.FunctionConverge{Absolute: 1e-10, Relative: 1e-10, Iterations: globalStallGenerations},
+	}
+
+	result, err := safeMinimize(problem, initial, settings, method)
+	if result == nil {
+		return nil, fmt.Sprintf("CMA-ES: %v", err)
+	}
+	return result.X, fmt.Sprintf("CMA-ES: koszt %.6f po %d ocenach (%s)", result.F, result.Stats.FuncEvaluations, result.Status)
+}
@@ -0,0 +1,349 @@
+package lppl
+
+import (
+	"errors"
+	"math"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+
+	"gonum.org/v1/gonum/mat"
+
+	"cw3/data"
+)
+
+// GridAxis to N równo rozłożonych wartości parametru od Lo do Hi włącznie
+type GridAxis struct {
+	Lo, Hi float64
+	N      int
+}
+
+func (a GridAxis) Value(i int) float64 {
+	if a.N <= 1 {
+		return a.Lo
+	}
+	return a.Lo + (a.Hi-a.Lo)*float64(i)/float64(a.N-1)
+}
+
+// GridSpec to siatka przeszukiwania (tc, m, omega); tc w dniach od pierwszej obserwacji,
+// jak w Result.Params. Komórki są numerowane od 0 z omega zmieniającą się najszybciej.
+type GridSpec struct {
+	Tc, M, Omega GridAxis
+}
+
+func (g GridSpec) Cells() int {
+	return g.Tc.N * g.M.N * g.Omega.N
+}
+
+func (g GridSpec) check() error {
+	for _, a := range []GridAxis{g.Tc, g.M, g.Omega} {
+		if a.N < 1 || a.Hi < a.Lo || math.IsNaN(a.Lo) || math.IsNaN(a.Hi) {
+			return errors.New("każda oś siatki wymaga co najmniej jednej wartości i lo <= hi")
+		}
+	}
+	return nil
+}
+
+// Cell zwraca wartości (tc, m, omega) komórki o numerze i
+func (g GridSpec) Cell(i int) (tc, m, omega float64) {
+	o := i % g.Omega.N
+	i /= g.Omega.N
+	return g.Tc.Value(i / g.M.N), g.M.Value(i % g.M.N), g.Omega.Value(o)
+}
+
+// GridCell to oceniona komórka siatki z parametrami liniowymi wyznaczonymi dokładnie
+type GridCell struct {
+	Index  int       `json:"index"`
+	Params []float64 `json:"params"`
+	Cost   float64   `json:"cost"`
+}
+
+// GridEvaluate ocenia komórki siatki o numerach [from, to) bez optymalizacji nieliniowej:
+// dla każdej trójki (tc, m, omega) parametry A, B, C i phi wyznacza metoda najmniejszych
+// kwadratów. Zwraca keep komórek o najniższym koszcie w kolejności rosnącego kosztu
+// (przy równym koszcie - numeru komórki), więc wynik nie zależy od liczby wątków.
+func GridEvaluate(points []data.Point, spec GridSpec, opts FitOptions, from, to, keep int) ([]GridCell, error) {
+	if err := spec.check(); err != nil {
+		return nil, err
+	}
+	if err := validateWindow(points, opts); err != nil {
+		return nil, err
+	}
+	from, to = max(from, 0), min(to, spec.Cells())
+	g := gridData{
+		points:    points,
+		timeIndex: data.TimeIndex(points),
+		logP:      data.LogPrices(points),
+		weights:   volatilityWeights(points, opts.VolWindow),
+		opts:      opts,
+	}
+	g.table = newPowTable(g.timeIndex)
+
+	// Paczka to kolejne komórki o wspólnym tc, oceniane razem
+	type batch struct{ lo, hi int }
+	var batches []batch
+	perTc := spec.M.N * spec.Omega.N
+	for lo := from; lo < to; {
+		hi := min(to, lo+gridBatch, (lo/perTc+1)*perTc)
+		batches = append(batches, batch{lo, hi})
+		lo = hi
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = max(1, min(workers, len(batches)))
+	partial := make([][]GridCell, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var best []GridCell
+			for b := w; b < len(batches); b += workers {
+				for _, c := range g.evaluate(spec, batches[b].lo, batches[b].hi) {
+					if len(best) < keep || c.Cost < best[len(best)-1].Cost {
+						best = keepBest(append(best, c), keep)
+					}
+				}
+			}
+			partial[w] = best
+		}()
+	}
+	wg.Wait()
+
+	var all []GridCell
+	for _, p := range partial {
+		all = append(all, p...)
+	}
+	return keepBest(all, keep), nil
+}
+
+// gridBatch to największa liczba komórek, których układy normalne powstają z jednego
+// mnożenia macierzy
+const gridBatch = 256
+
+// gridData to dane okna wspólne dla wszystkich komórek siatki
+type gridData struct {
+	points          []data.Point
+	timeIndex, logP []float64
+	weights         []float64
+	opts            FitOptions
+	table           *powTable
+}
+
+// evaluate ocenia komórki [lo, hi) o wspólnym tc. Gdy tc leży za ostatnią obserwacją,
+// wszystkie sumy układów normalnych 4x4 paczki wyznacza jedno mnożenie macierzy cech
+// (n x 9 na komórkę) przez [w, w·ln p] (BLAS dgemm), a koszt liczony jest z gotowych
+// kolumn f, f·cos i f·sin bez ponownego potęgowania. Pozostałe komórki (tc w oknie,
+// gdzie część obserwacji wypada z dopasowania) ocenia solveLinearTable i residualCost.
+// ln(tc-t) i (tc-t)^m pochodzą w obu przypadkach z g.table.
+func (g gridData) evaluate(spec GridSpec, lo, hi int) []GridCell {
+	var cells []GridCell
+	add := func(i int, params []float64, cost float64) {
+		if !math.IsNaN(cost) && !math.IsInf(cost, 0) {
+			cells = append(cells, GridCell{Index: i, Params: params, Cost: cost})
+		}
+	}
+	n := len(g.timeIndex)
+	tc, _, _ := spec.Cell(lo)
+	logDt := g.table.log(tc)
+	if tc <= g.timeIndex[n-1] {
+		for i := lo; i < hi; i++ {
+			tc, m, omega := spec.Cell(i)
+			pow := g.table.pow(tc, m)
+			params, ok := solveLinearTable(tc, m, omega, logDt, pow, g.logP, g.weights)
+			if !ok {
+				continue
+			}
+			A, B, C, phi := params[3], params[4], params[5], params[6]
+			add(i, params, residualCost(g.points, g.timeIndex, g.weights, g.opts, tc, A, func(i int) float64 {
+				return A + B*pow[i]*(1+C*math.Cos(omega*logDt[i]+phi))
+			}))
+		}
+		return cells
+	}
+
+	weighted := mat.NewDense(n, 2, nil)
+	var sumW, sumWY float64
+	for i := range n {
+		w := 1.0
+		if g.weights != nil {
+			w = g.weights[i]
+		}
+		weighted.Set(i, 0, w)
+		weighted.Set(i, 1, w*g.logP[i])
+		sumW += w
+		sumWY += w * g.logP[i]
+	}
+
+	// Kolumny komórki k: f, f·c, f·s i ich iloczyny parami (c = cos(omega ln dt), s = sin);
+	// f = dt^m jest dzielone przez największą wartość, żeby układ był lepiej uwarunkowany
+	const width = 9
+	features := mat.NewDense(n, width*(hi-lo), nil)
+	raw := features.RawMatrix()
+	scale := make([]float64, hi-lo)
+	f := make([]float64, n)
+	lastM := math.NaN()
+	for k := range hi - lo {
+		_, m, omega := spec.Cell(lo + k)
+		if m != lastM {
+			lastM = m
+			// Najdalej od tc leży pierwsza obserwacja
+			pow := g.table.pow(tc, m)
+			scale[k] = pow[0]
+			for i := range f {
+				f[i] = pow[i] / scale[k]
+			}
+		} else {
+			scale[k] = scale[k-1]
+		}
+		for i := range n {
+			sin, cos := math.Sincos(omega * logDt[i])
+			fc, fs := f[i]*cos, f[i]*sin
+			row := raw.Data[i*raw.Stride+width*k : i*raw.Stride+width*(k+1)]
+			row[0], row[1], row[2] = f[i], fc, fs
+			row[3], row[4], row[5] = f[i]*f[i], f[i]*fc, f[i]*fs
+			row[6], row[7], row[8] = fc*fc, fc*fs, fs*fs
+		}
+	}
+	var sums mat.Dense
+	sums.Mul(features.T(), weighted)
+
+	normal := mat.NewSymDense(4, nil)
+	rhs := mat.NewVecDense(4, nil)
+	var chol mat.Cholesky
+	var x mat.VecDense
+	for k := range hi - lo {
+		s := func(j, col int) float64 { return sums.At(width*k+j, col) }
+		normal.SetSym(0, 0, sumW)
+		normal.SetSym(0, 1, s(0, 0))
+		normal.SetSym(0, 2, s(1, 0))
+		normal.SetSym(0, 3, s(2, 0))
+		normal.SetSym(1, 1, s(3, 0))
+		normal.SetSym(1, 2, s(4, 0))
+		normal.SetSym(1, 3, s(5, 0))
+		normal.SetSym(2, 2, s(6, 0))
+		normal.SetSym(2, 3, s(7, 0))
+		normal.SetSym(3, 3, s(8, 0))
+		rhs.SetVec(0, sumWY)
+		rhs.SetVec(1, s(0, 1))
+		rhs.SetVec(2, s(1, 1))
+		rhs.SetVec(3, s(2, 1))
+		if !chol.Factorize(normal) || chol.SolveVecTo(&x, rhs) != nil {
+			continue
+		}
+		A, B, C1, C2 := x.AtVec(0), x.AtVec(1), x.AtVec(2), x.AtVec(3)
+		var cost float64
+		for i := range n {
+			row := raw.Data[i*raw.Stride+width*k:]
+			r := clampResidual(g.logP[i] - A - B*row[0] - C1*row[1] - C2*row[2])
+			cost += weighted.At(i, 0) * r * r
+		}
+		tc, m, omega := spec.Cell(lo + k)
+		if params, ok := linearParams(tc, m, omega, A, B/scale[k], C1/scale[k], C2/scale[k]); ok {
+			add(lo+k, params, cost)
+		}
+	}
+	return cells
+}
+
+// MergeGridCells łączy najlepsze komórki z kilku przebiegów w keep najlepszych
+func MergeGridCells(keep int, sets ...[]GridCell) []GridCell {
+	var all []GridCell
+	seen := map[int]bool{}
+	for _, set := range sets {
+		for _, c := range set {
+			if !seen[c.Index] {
+				seen[c.Index] = true
+				all = append(all, c)
+			}
+		}
+	}
+	return keepBest(all, keep)
+}
+
+// GridRefine zagęszcza siatkę wokół obiecujących obszarów: na każdym z levels poziomów
+// wokół top najlepszych dotąd komórek ocenia lokalną siatkę sięgającą o jeden krok
+// poprzedniego poziomu w każdą stronę, z krokiem factor razy mniejszym, nie wychodząc poza
+// zakres spec. Zwraca keep najlepszych komórek z best i wszystkich poziomów (komórki spoza
+// siatki spec mają Index -1) oraz liczbę ocenionych komórek.
+func GridRefine(points []data.Point, spec GridSpec, best []GridCell, opts FitOptions, levels, factor, top, keep int) ([]GridCell, int, error) {
+	if factor < 2 || top < 1 {
+		return nil, 0, errors.New("doprecyzowanie siatki wymaga factor >= 2 i top >= 1")
+	}
+	step := func(a GridAxis) float64 {
+		if a.N <= 1 {
+			return 0
+		}
+		return (a.Hi - a.Lo) / float64(a.N-1)
+	}
+	steps := [3]float64{step(spec.Tc), step(spec.M), step(spec.Omega)}
+	local := func(a GridAxis, step, v float64) GridAxis {
+		if step == 0 {
+			return GridAxis{Lo: v, Hi: v, N: 1}
+		}
+		lo, hi := math.Max(a.Lo, v-step), math.Min(a.Hi, v+step)
+		return GridAxis{Lo: lo, Hi: hi, N: int(math.Round((hi-lo)/step*float64(factor))) + 1}
+	}
+
+	cells := slices.Clone(best)
+	evaluated := 0
+	for range levels {
+		var found [][]GridCell
+		for _, c := range cells[:min(top, len(cells))] {
+			p := c.Params
+			region := GridSpec{
+				Tc:    local(spec.Tc, steps[0], p[0]),
+				M:     local(spec.M, steps[1], p[1]),
+				Omega: local(spec.Omega, steps[2], p[2]),
+			}
+			refined, err := GridEvaluate(points, region, opts, 0, region.Cells(), keep)
+			if err != nil {
+				return nil, evaluated, err
+			}
+			for i := range refined {
+				refined[i].Index = -1
+			}
+			evaluated += region.Cells()
+			found = append(found, refined)
+		}
+		cells = mergeByParams(keep, append([][]GridCell{cells}, found...)...)
+		for i := range steps {
+			steps[i] /= float64(factor)
+		}
+	}
+	return cells, evaluated, nil
+}
+
+// mergeByParams łączy komórki, utożsamiając te o tych samych (tc, m, omega)
+func mergeByParams(keep int, sets ...[]GridCell) []GridCell {
+	var all []GridCell
+	seen := map[[3]float64]bool{}
+	for _, set := range sets {
+		for _, c := range set {
+			key := [3]float64{c.Params[0], c.Params[1], c.Params[2]}
+			if !seen[key] {
+				seen[key] = true
+				all = append(all, c)
+			}
+		}
+	}
+	return keepBest(all, keep)
+}
+
+// keepBest sortuje komórki według kosztu i obcina listę do keep
+func keepBest(cells []GridCell, keep int) []GridCell {
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].Cost != cells[j].Cost {
+			return cells[i].Cost < cells[j].Cost
+		}
+		return cells[i].Index < cells[j].Index
+	})
+	if len(cells) > keep {
+		cells = cells[:keep]
+	}
+	return cells
+}
@@ -0,0 +1,74 @@
+package lppl
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"cw3/data"
+)
+
+// noisyBubble to syntheticBubble z szumem log-ceny o odchyleniu sd, żeby koszty komórek
+// były wyraźnie dodatnie
+func noisyBubble(n int, sd float64, seed int64) []data.Point {
+	points := syntheticBubble(n, float64(n)+40, 0.5, 8, 10, -0.05, 0.05, 1)
+	rng := rand.New(rand.NewSource(seed))
+	for i := range points {
+		points[i].Price *= math.Exp(sd * rng.NormFloat64())
+	}
+	return points
+}
+
+// Paczki z mnożeniem macierzy (tc za danymi) i tablice potęg (tc w oknie) muszą dawać ten
+// sam koszt co solveLinear i fitCost liczone dla każdej komórki osobno
+func TestGridEvaluateMatchesScalarCost(t *testing.T) {
+	const n = 120
+	points := noisyBubble(n, 0.02, 1)
+	rng := rand.New(rand.NewSource(2))
+	for trial := range 4 {
+		// Losowa siatka, której oś tc obejmuje obserwacje z okna i czas za danymi
+		lo := float64(n) * (0.6 + 0.3*rng.Float64())
+		spec := GridSpec{
+			Tc:    GridAxis{Lo: lo, Hi: float64(n) * (1.1 + 0.4*rng.Float64()), N: 6},
+			M:     GridAxis{Lo: 0.05 + 0.2*rng.Float64(), Hi: 0.7 + 0.25*rng.Float64(), N: 4},
+			Omega: GridAxis{Lo: 2 + 3*rng.Float64(), Hi: 10 + 8*rng.Float64(), N: 5},
+		}
+		opts := DefaultFitOptions()
+		opts.Workers = 2
+		if trial%2 == 1 {
+			opts.VolWindow = 10
+		}
+		timeIndex := data.TimeIndex(points)
+		weights := volatilityWeights(points, opts.VolWindow)
+
+		cells, err := GridEvaluate(points, spec, opts, 0, spec.Cells(), spec.Cells())
+		if err != nil {
+			t.Fatal(err)
+		}
+		inside, beyond := 0, 0
+		for _, c := range cells {
+			tc, m, omega := spec.Cell(c.Index)
+			params, ok := solveLinear(tc, m, omega, points, timeIndex, weights)
+			if !ok {
+				t.Errorf("komórka %d: solveLinear nie rozwiązał podproblemu, a siatka tak", c.Index)
+				continue
+			}
+			want := fitCost(params, points, timeIndex, weights, opts)
+			if math.Abs(c.Cost-want) > 1e-6*math.Max(1, want) {
+				t.Errorf("próba %d, komórka %d (tc %.2f, m %.3f, omega %.2f): koszt siatki %.10g, skalarny %.10g",
+					trial, c.Index, tc, m, omega, c.Cost, want)
+			}
+			if got := fitCost(c.Params, points, timeIndex, weights, opts); math.Abs(got-c.Cost) > 1e-6*math.Max(1, c.Cost) {
+				t.Errorf("komórka %d: koszt z Params %.10g, zwrócony %.10g", c.Index, got, c.Cost)
+			}
+			if tc > timeIndex[n-1] {
+				beyond++
+			} else {
+				inside++
+			}
+		}
+		if inside == 0 || beyond == 0 {
+			t.Fatalf("próba %d: siatka powinna sprawdzić obie ścieżki (w oknie %d, za danymi %d)", trial, inside, beyond)
+		}
+	}
+}
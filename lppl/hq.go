@@ -0,0 +1,161 @@
+package lppl
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"cw3/data"
+	"cw3/internal/stats"
+)
+
+// Siatka analizy (H,q) i zakres przeszukiwanych częstości kątowych w ln(tc-t)
+var (
+	hqExponents = []float64{-0.8, -0.6, -0.4, -0.2, 0, 0.2, 0.4, 0.6, 0.8}
+	hqScales    = []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9}
+)
+
+const (
+	hqOmegaMin  = 2
+	hqOmegaMax  = 25
+	hqOmegaStep = 0.05
+
+	// hqMinSamples to minimalna liczba punktów pochodnej (H,q), przy której liczony jest periodogram
+	hqMinSamples = 10
+	// hqMinPower odrzuca piki periodogramu Lomba nieodróżnialne od szumu
+	hqMinPower = 3
+	// hqTolerance to maksymalna różnica omeg uznawana za potwierdzenie dopasowania
+	hqTolerance = 1.5
+)
+
+// HQPeak to najsilniejsza częstość log-periodyczna dla jednej pary (H,q)
+type HQPeak struct {
+	H, Q  float64
+	Omega float64
+	Power float64
+}
+
+// HQResult podsumowuje analizę (H,q): mediana omeg z istotnych pików i ich liczba
+type HQResult struct {
+	Omega float64
+	Peaks []HQPeak
+	Pairs int
+}
+
+// Confirms sprawdza, czy nieparametryczna omega zgadza się z dopasowaną
+func (r HQResult) Confirms(omega float64) bool {
+	return math.Abs(r.Omega-omega) <= hqTolerance
+}
+
+// HQAnalysis wykrywa oscylacje log-periodyczne bez dopasowywania modelu (Zhou i Sornette).
+// Dla każdej pary (H,q) liczona jest pochodna (H,q) logarytmu ceny względem x = tc-t,
+// a następnie periodogram Lomba tej pochodnej jako funkcji ln x. Pochodna usuwa trend
+// potęgowy, więc pik periodogramu wskazuje częstość kątową omega niezależnie od dopasowania.
+func HQAnalysis(points []data.Point, tc float64) (HQResult, error) {
+	timeIndex := data.TimeIndex(points)
+	res := HQResult{Pairs: len(hqExponents) * len(hqScales)}
+	if tc <= timeIndex[len(timeIndex)-1] {
+		return res, errors.New("tc nie leży za ostatnią obserwacją")
+	}
+	logP := data.LogPrices(points)
+
+	var omegas []float64
+	for _, h := range hqExponents {
+		for _, q := range hqScales {
+			lnX, d := hqDerivative(timeIndex, logP, tc, h, q)
+			if len(d) < hqMinSamples {
+				continue
+			}
+			omega, power := lombPeak(lnX, d, hqOmegaMin, hqOmegaMax, hqOmegaStep)
+			if power < hqMinPower {
+				continue
+			}
+			res.Peaks = append(res.Peaks, HQPeak{H: h, Q: q, Omega: omega, Power: power})
+			omegas = append(omegas, omega)
+		}
+	}
+	if len(omegas) == 0 {
+		return res, errors.New("brak istotnych oscylacji log-periodycznych")
+	}
+	res.Omega = stats.Median(omegas)
+	return res, nil
+}
+
+// hqDerivative liczy D_q^H f(x) = (f(x) - f(qx)) / ((1-q)x)^H dla f(x) = ln p(tc-x).
+// Wartość f(qx) jest interpolowana liniowo; punkty, dla których tc-qx wypada za
+// ostatnią obserwacją, są pomijane.
+func hqDerivative(t, y []float64, tc, h, q float64) (lnX, d []float64) {
+	last := t[len(t)-1]
+	for i := range t {
+		x := tc - t[i]
+		tq := tc - q*x
+		if tq > last {
+			continue
+		}
+		d = append(d, (y[i]-interpolate(t, y, tq))/math.Pow((1-q)*x, h))
+		lnX = append(lnX, math.Log(x))
+	}
+	return lnX, d
+}
+
+// interpolate zwraca liniowo interpolowaną wartość y w punkcie x0 z przedziału [t[0], t[n-1]]
+func interpolate(t, y []float64, x0 float64) float64 {
+	j := sort.SearchFloat64s(t, x0)
+	switch {
+	case j == 0:
+		return y[0]
+	case j >= len(t):
+		return y[len(y)-1]
+	}
+	w := (x0 - t[j-1]) / (t[j] - t[j-1])
+	return y[j-1] + w*(y[j]-y[j-1])
+}
+
+// lombPeak zwraca częstość kątową o największej znormalizowanej mocy periodogramu Lomba
+func lombPeak(x, y []float64, omegaMin, omegaMax, step float64) (omega, power float64) {
+	for w := omegaMin; w <= omegaMax; w += step {
+		if p := lombPower(x, y, w); p > power {
+			omega, power = w, p
+		}
+	}
+	return omega, power
+}
+
+// lombPower liczy znormalizowany periodogram Lomba dla niejednorodnie rozłożonych próbek
+func lombPower(x, y []float64, omega float64) float64 {
+	n := float64(len(y))
+	var mean float64
+	for _, v := range y {
+		mean += v
+	}
+	mean /= n
+	var variance float64
+	for _, v := range y {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= n - 1
+	if variance == 0 {
+		return 0
+	}
+
+	var s2, c2 float64
+	for _, xi := range x {
+		s2 += math.Sin(2 * omega * xi)
+		c2 += math.Cos(2 * omega * xi)
+	}
+	tau := math.Atan2(s2, c2) / (2 * omega)
+
+	var yc, ys, cc, ss float64
+	for i, xi := range x {
+		c := math.Cos(omega * (xi - tau))
+		s := math.Sin(omega * (xi - tau))
+		yc += (y[i] - mean) * c
+		ys += (y[i] - mean) * s
+		cc += c * c
+		ss += s * s
+	}
+	if cc == 0 || ss == 0 {
+		return 0
+	}
+	return (yc*yc/cc + ys*ys/ss) / (2 * variance)
+}
@@ -0,0 +1,84 @@
+package lppl
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+
+	"cw3/data"
+)
+
+// solveLinear wyznacza A, B, C1, C2 dla ustalonych tc, m i omega (Filimonov i Sornette,
+// 2013): przy ln p = A + B·f + C1·f·cos(omega ln dt) + C2·f·sin(omega ln dt), gdzie
+// f = dt^m, są one rozwiązaniem liniowego zadania najmniejszych kwadratów, więc
+// optymalizator przeszukuje tylko trzy parametry nieliniowe. Obserwacje z t >= tc są
+// pomijane, weights to wagi kwadratów reszt (nil oznacza równe). Zwraca pełny wektor
+// parametrów (tc, m, omega, A, B, C, phi) albo false, gdy podproblem jest osobliwy.
+func solveLinear(tc, m, omega float64, points []data.Point, timeIndex, weights []float64) ([]float64, bool) {
+	var rows, ys []float64
+	for i, t := range timeIndex {
+		dt := tc - t
+		if dt <= 0 {
+			continue
+		}
+		w := 1.0
+		if weights != nil {
+			w = math.Sqrt(weights[i])
+		}
+		f := math.Pow(dt, m)
+		lw := omega * math.Log(dt)
+		rows = append(rows, w, w*f, w*f*math.Cos(lw), w*f*math.Sin(lw))
+		ys = append(ys, w*math.Log(points[i].Price))
+	}
+	if len(ys) < 4 {
+		return nil, false
+	}
+
+	var x mat.VecDense
+	if err := x.SolveVec(mat.NewDense(len(ys), 4, rows), mat.NewVecDense(len(ys), ys)); err != nil {
+		return nil, false
+	}
+	return linearParams(tc, m, omega, x.AtVec(0), x.AtVec(1), x.AtVec(2), x.AtVec(3))
+}
+
+// solveLinearTable to solveLinear z ln(tc-t) i (tc-t)^m wziętymi z powTable (NaN dla t >= tc)
+func solveLinearTable(tc, m, omega float64, logDt, pow, logP, weights []float64) ([]float64, bool) {
+	var rows, ys []float64
+	for i, l := range logDt {
+		if math.IsNaN(l) {
+			continue
+		}
+		w := 1.0
+		if weights != nil {
+			w = math.Sqrt(weights[i])
+		}
+		f := pow[i]
+		lw := omega * l
+		rows = append(rows, w, w*f, w*f*math.Cos(lw), w*f*math.Sin(lw))
+		ys = append(ys, w*logP[i])
+	}
+	if len(ys) < 4 {
+		return nil, false
+	}
+
+	var x mat.VecDense
+	if err := x.SolveVec(mat.NewDense(len(ys), 4, rows), mat.NewVecDense(len(ys), ys)); err != nil {
+		return nil, false
+	}
+	return linearParams(tc, m, omega, x.AtVec(0), x.AtVec(1), x.AtVec(2), x.AtVec(3))
+}
+
+// linearParams przelicza rozwiązanie (A, B, C1, C2) na pełny wektor parametrów
+func linearParams(tc, m, omega, A, B, C1, C2 float64) ([]float64, bool) {
+	if B == 0 || math.IsNaN(B) {
+		return nil, false
+	}
+	// C1 = BC·cos(phi), C2 = -BC·sin(phi)
+	amp := math.Hypot(C1, C2)
+	C := amp / B
+	phi := math.Atan2(-C2/(B*C), C1/(B*C))
+	if amp == 0 {
+		C, phi = 0, 0
+	}
+	return []float64{tc, m, omega, A, B, C, phi}, true
+}
@@ -0,0 +1,435 @@
+// Package lppl dopasowuje model LPPL (log-periodic power law) do szeregu cen i ocenia
+// wynik dopasowania: filtry, uwarunkowanie, analizę (H,q), widma reszt i szacunek krachu.
+package lppl
+
+import (
+	"errors"
+	"log"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+
+	"gonum.org/v1/gonum/optimize"
+
+	"cw3/data"
+	"cw3/internal/stats"
+)
+
+type FitOptions struct {
+	// Dopuszczalny zakres tc jako ułamek długości okna (t2-t1) za ostatnią obserwacją
+	TcMinFrac float64
+	TcMaxFrac float64
+	// Ograniczenia przedziałowe m i omega
+	Bounds Bounds
+
+	// Wagi kar dodawanych do funkcji kosztu (0 wyłącza daną karę)
+	PenaltyC     float64
+	CLimit       float64
+	PenaltyOmega float64
+	PriorOmega   float64
+
+	Filters FilterConfig
+
+	// Limit iteracji pojedynczego przebiegu optymalizatora
+	MaxIterations int
+
+	// Wartości startowe m i omega; każda para daje osobny start optymalizacji.
+	// Puste listy oznaczają domyślną siatkę.
+	StartM     []float64
+	StartOmega []float64
+
+	// Metoda optymalizacji: MethodNelderMead albo MethodCMAES
+	Method string
+
+	// Liczba dodatkowych startów z losowych punktów i ziarno ich generatora
+	RandomStarts int
+	Seed         int64
+	// Liczba równoległych optymalizacji (0 oznacza liczbę procesorów)
+	Workers int
+
+	// Minimalna liczba stopni swobody (obserwacje minus parametry) w oknie
+	MinDOF int
+
+	// Traktowanie obserwacji z t >= tc: BeyondTcExclude, BeyondTcPenalty lub BeyondTcClamp
+	BeyondTc string
+	// Waga kary BeyondTcPenalty: mnoży sumę ((t-tc)/(t2-t1))^2 po obserwacjach za tc, więc
+	// kara jest bezwymiarowa jak kwadraty reszt log-ceny i nie zależy od skali czasu danych
+	BeyondTcWeight float64
+
+	// Liczba stóp zwrotu do oceny lokalnej zmienności przy ważeniu reszt (0 wyłącza ważenie)
+	VolWindow int
+}
+
+const (
+	// Punkty za tc są pomijane, a suma reszt przeskalowana do liczby wszystkich obserwacji
+	BeyondTcExclude = "exclude"
+	// Model za tc jest równy A, a do kosztu dochodzi kara rosnąca z kwadratem odległości od tc
+	// wyrażonej w długościach okna
+	BeyondTcPenalty = "penalty"
+	// Dawne zachowanie: model za tc jest równy A bez żadnej kary
+	BeyondTcClamp = "clamp"
+)
+
+const (
+	// Lokalna minimalizacja Nelder-Mead z każdego punktu startowego
+	MethodNelderMead = "nelder-mead"
+	// Globalne przeszukiwanie CMA-ES z każdego punktu startowego, dopracowane metodą Nelder-Mead
+	MethodCMAES = "cmaes"
+)
+
+func DefaultFitOptions() FitOptions {
+	return FitOptions{
+		TcMinFrac: 0,
+		TcMaxFrac: 0.5,
+		Bounds:    DefaultBounds(),
+		CLimit:    1,
+		Filters:   FilterPresets["default"],
+
+		MaxIterations: 5000,
+		MinDOF:        5,
+
+		BeyondTc:       BeyondTcExclude,
+		BeyondTcWeight: 1,
+
+		Method: MethodNelderMead,
+	}
+}
+
+func Model(t, tc, m, omega, A, B, C, phi float64) float64 {
+	dt := tc - t
+	if dt <= 0 {
+		return A
+	}
+	return A + B*math.Pow(dt, m)*(1+C*math.Cos(omega*math.Log(dt)+phi))
+}
+
+func Cost(params []float64, points []data.Point, timeIndex []float64) float64 {
+	tc, m, omega, A, B, C, phi := params[0], params[1], params[2], params[3], params[4], params[5], params[6]
+
+	var sum float64
+	for i, point := range points {
+		t := timeIndex[i]
+		predicted := Model(t, tc, m, omega, A, B, C, phi)
+		actual := math.Log(point.Price)
+		sum += math.Pow(clampResidual(actual-predicted), 2)
+	}
+	return sum
+}
+
+// fitCost liczy koszt z uwzględnieniem wybranego sposobu traktowania obserwacji za tc.
+// weights to wagi kwadratów reszt z volatilityWeights; nil oznacza wagi równe 1.
+func fitCost(params []float64, points []data.Point, timeIndex, weights []float64, opts FitOptions) float64 {
+	tc, m, omega, A, B, C, phi := params[0], params[1], params[2], params[3], params[4], params[5], params[6]
+	return residualCost(points, timeIndex, weights, opts, tc, A, func(i int) float64 {
+		return Model(timeIndex[i], tc, m, omega, A, B, C, phi)
+	})
+}
+
+// residualCost to fitCost z wartością modelu w obserwacji i (przed tc) podaną przez model
+func residualCost(points []data.Point, timeIndex, weights []float64, opts FitOptions, tc, A float64, model func(i int) float64) float64 {
+	var sum, beyond float64
+	used := 0
+	// Odległość od tc w długościach okna, żeby kara nie zależała od jednostek czasu
+	width := timeIndex[len(timeIndex)-1] - timeIndex[0]
+	if width <= 0 {
+		width = 1
+	}
+	for i, point := range points {
+		w := 1.0
+		if weights != nil {
+			w = weights[i]
+		}
+		t := timeIndex[i]
+		actual := math.Log(point.Price)
+		if t >= tc && opts.BeyondTc != BeyondTcClamp {
+			if opts.BeyondTc == BeyondTcPenalty {
+				sum += w * math.Pow(clampResidual(actual-A), 2)
+				d := (t - tc) / width
+				beyond += d * d
+			}
+			continue
+		}
+		used++
+		sum += w * math.Pow(clampResidual(actual-model(i)), 2)
+	}
+
+	switch {
+	case opts.BeyondTc == BeyondTcPenalty:
+		return sum + opts.BeyondTcWeight*beyond
+	case used == 0:
+		return float64(len(points)) * maxResidual * maxResidual
+	}
+	return sum * float64(len(points)) / float64(used)
+}
+
+// volatilityWeights zwraca wagi reszt odwrotnie proporcjonalne do kwadratu lokalnej
+// zmienności (odchylenia standardowego window ostatnich log-stóp zwrotu), unormowane
+// tak, by ich średnia wynosiła 1. Dzięki temu spokojne i burzliwe okresy wpływają na
+// dopasowanie porównywalnie. Dla window < 2 zwraca nil.
+func volatilityWeights(points []data.Point, window int) []float64 {
+	if window < 2 || len(points) <= window {
+		return nil
+	}
+	logP := data.LogPrices(points)
+	returns := make([]float64, len(logP)-1)
+	for i := range returns {
+		returns[i] = logP[i+1] - logP[i]
+	}
+
+	sigma := make([]float64, len(points))
+	var total float64
+	for i := range points {
+		// Zwroty kończące się najpóźniej w obserwacji i; początek korzysta z pierwszego pełnego okna
+		end := max(i, window)
+		sigma[i] = math.Max(math.Sqrt(stats.Variance(returns[end-window:end])), minVolatility)
+		total += 1 / (sigma[i] * sigma[i])
+	}
+	weights := make([]float64, len(points))
+	for i := range sigma {
+		weights[i] = float64(len(points)) / (sigma[i] * sigma[i] * total)
+	}
+	return weights
+}
+
+// minVolatility chroni wagi przed dzieleniem przez zero na odcinkach stałej ceny
+const minVolatility = 1e-6
+
+// Dla dużych dt i m potęga (tc-t)^m przepełnia się do Inf, a Inf-Inf daje NaN, co psuje
+// porównania w simpleksie Neldera-Meada; reszty są więc obcinane do skończonej wartości
+const maxResidual = 1e3
+
+func clampResidual(r float64) float64 {
+	switch {
+	case math.IsNaN(r):
+		return maxResidual
+	case r > maxResidual:
+		return maxResidual
+	case r < -maxResidual:
+		return -maxResidual
+	}
+	return r
+}
+
+// penalty karze |C| powyżej CLimit oraz odejście omega od wartości z poprzednich dopasowań
+func penalty(params []float64, opts FitOptions) float64 {
+	omega, C := params[2], params[5]
+
+	var sum float64
+	if excess := math.Abs(C) - opts.CLimit; opts.PenaltyC > 0 && excess > 0 {
+		sum += opts.PenaltyC * excess * excess
+	}
+	if opts.PenaltyOmega > 0 && opts.PriorOmega > 0 {
+		drift := omega - opts.PriorOmega
+		sum += opts.PenaltyOmega * drift * drift
+	}
+	return sum
+}
+
+// TcRange zwraca przedział [t2 + min·(t2-t1), t2 + max·(t2-t1)] dla tc
+func TcRange(timeIndex []float64, opts FitOptions) (float64, float64) {
+	t1, t2 := timeIndex[0], timeIndex[len(timeIndex)-1]
+	width := t2 - t1
+	return t2 + opts.TcMinFrac*width, t2 + opts.TcMaxFrac*width
+}
+
+type Result struct {
+	Params     []float64
+	Cost       float64
+	Violations []string
+	// Przebieg ponownych prób optymalizacji, jeśli pierwsza budziła wątpliwości
+	Notes []string
+	// Liczba startów optymalizacji, z których wybrano to dopasowanie (0 dla alternatyw)
+	Starts int
+	// Liczba iteracji optymalizatora w starcie, który dał to dopasowanie
+	Iterations int
+	// Przedziały ufności z Bootstrap, jeśli zostały wyznaczone
+	Bootstrap *BootstrapResult
+}
+
+func (r Result) Qualified() bool {
+	return len(r.Violations) == 0
+}
+
+// Domyślne punkty startowe dla m i omega; każda kombinacja daje osobne minimum lokalne
+var (
+	startM     = []float64{0.3, 0.7}
+	startOmega = []float64{6.0, 8.0, 10.0, 12.0}
+)
+
+// Zakresy losowania punktów startowych; tc losowane jest z wnętrza dozwolonego przedziału
+var (
+	randomM     = [2]float64{0.1, 0.9}
+	randomOmega = [2]float64{4, 15}
+)
+
+// GridStarts zwraca liczbę startów z siatki m x omega, bez startów losowych
+func (o FitOptions) GridStarts() int {
+	return len(orDefault(o.StartM, startM)) * len(orDefault(o.StartOmega, startOmega))
+}
+
+func orDefault(values, def []float64) []float64 {
+	if len(values) == 0 {
+		return def
+	}
+	return values
+}
+
+// FitAll szuka minimów z kilku punktów startowych i wybiera najlepsze pod względem
+// zgodności z filtrami, a dopiero potem kosztu. Zwraca też odrzucone alternatywy.
+func FitAll(points []data.Point, opts FitOptions) (Result, []Result, error) {
+	if err := opts.Bounds.check(); err != nil {
+		return Result{}, nil, err
+	}
+	if err := validateWindow(points, opts); err != nil {
+		return Result{}, nil, err
+	}
+	timeIndex := data.TimeIndex(points)
+	weights := volatilityWeights(points, opts.VolWindow)
+
+	tcLo, tcHi := TcRange(timeIndex, opts)
+	// Optymalizator przeszukuje tylko tc, m i omega, mapowane sigmoidą na przedziały
+	// limits; A, B, C i phi wyznacza dla każdego kandydata solveLinear
+	limits := [][2]float64{{tcLo, tcHi}, opts.Bounds.M, opts.Bounds.Omega}
+	toModel := func(x []float64) ([]float64, bool) {
+		var nonlinear [3]float64
+		for i, l := range limits {
+			nonlinear[i] = bound(x[i], l[0], l[1])
+		}
+		return solveLinear(nonlinear[0], nonlinear[1], nonlinear[2], points, timeIndex, weights)
+	}
+
+	problem := optimize.Problem{
+		Func: func(x []float64) float64 {
+			params, ok := toModel(x)
+			if !ok {
+				return math.Inf(1)
+			}
+			return fitCost(params, points, timeIndex, weights, opts) + penalty(params, opts)
+		},
+	}
+
+	settings := &optimize.Settings{MajorIterations: opts.MaxIterations}
+	suspicious := func(r *optimize.Result) string {
+		if math.IsNaN(r.F) || math.IsInf(r.F, 0) {
+			return "koszt NaN/Inf"
+		}
+		switch r.Status {
+		case optimize.IterationLimit, optimize.FunctionEvaluationLimit:
+			return "osiągnięto limit iteracji"
+		}
+		if math.IsNaN(r.X[1]) || math.IsNaN(r.X[2]) {
+			return "parametry NaN"
+		}
+		for i, name := range []string{"tc", "m", "omega"} {
+			l := limits[i]
+			if pos := boundedPosition(bound(r.X[i], l[0], l[1]), l[0], l[1]); pos < 1e-4 || pos > 1-1e-4 {
+				return name + " na granicy dozwolonego przedziału"
+			}
+		}
+		return ""
+	}
+
+	// Początkowe wartości parametrów: siatka m x omega i ewentualne starty losowe
+	start := func(tc, m, omega float64) []float64 {
+		return []float64{
+			unbound(tc, tcLo, tcHi),
+			unbound(m, opts.Bounds.M[0], opts.Bounds.M[1]),
+			unbound(omega, opts.Bounds.Omega[0], opts.Bounds.Omega[1]),
+		}
+	}
+	var starts [][]float64
+	for _, m := range orDefault(opts.StartM, startM) {
+		for _, omega := range orDefault(opts.StartOmega, startOmega) {
+			starts = append(starts, start((tcLo+tcHi)/2, m, omega))
+		}
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	rm, ro := overlap(randomM, opts.Bounds.M), overlap(randomOmega, opts.Bounds.Omega)
+	for range opts.RandomStarts {
+		tc := tcLo + (tcHi-tcLo)*(0.05+0.9*rng.Float64())
+		m := rm[0] + (rm[1]-rm[0])*rng.Float64()
+		omega := ro[0] + (ro[1]-ro[0])*rng.Float64()
+		starts = append(starts, start(tc, m, omega))
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	found := make([]*Result, len(starts))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(starts)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				initial := starts[i]
+				var notes []string
+				if opts.Method == MethodCMAES {
+					global, note := globalSearch(problem, initial, opts.Seed+int64(i))
+					if global != nil {
+						initial = global
+					}
+					notes = append(notes, note)
+				}
+				s := *settings
+				result, refine, err := minimizeWithRetry(problem, initial, &s, suspicious)
+				notes = append(notes, refine...)
+				if err != nil {
+					log.Printf("Start m=%.2f omega=%.2f: %v", bound(starts[i][1], limits[1][0], limits[1][1]),
+						bound(starts[i][2], limits[2][0], limits[2][1]), err)
+					continue
+				}
+				params, ok := toModel(result.X)
+				if !ok {
+					log.Printf("Start %d: osobliwy podproblem liniowy w znalezionym minimum", i)
+					continue
+				}
+				found[i] = &Result{
+					Params:     params,
+					Cost:       fitCost(params, points, timeIndex, weights, opts),
+					Violations: CheckFilters(params, points, timeIndex, opts.Filters),
+					Notes:      notes,
+					Iterations: result.Stats.MajorIterations,
+				}
+			}
+		}()
+	}
+	for i := range starts {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var results []Result
+	for _, r := range found {
+		if r != nil {
+			results = append(results, *r)
+		}
+	}
+	if len(results) == 0 {
+		return Result{}, nil, errors.New("żaden start optymalizacji nie zakończył się powodzeniem")
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if len(results[i].Violations) != len(results[j].Violations) {
+			return len(results[i].Violations) < len(results[j].Violations)
+		}
+		return results[i].Cost < results[j].Cost
+	})
+
+	results[0].Starts = len(starts)
+	return results[0], results[1:], nil
+}
+
+// Liczba parametrów modelu LPPL, używana do normalizacji kosztu
+const ParamCount = 7
+
+// Fit zwraca najlepsze dopasowanie okna bez odrzuconych alternatyw
+func Fit(points []data.Point, opts FitOptions) (Result, error) {
+	best, _, err := FitAll(points, opts)
+	return best, err
+}
@@ -0,0 +1,78 @@
+package lppl
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"cw3/data"
+)
+
+// syntheticBubble zwraca dzienne ceny exp(Model) bez szumu
+func syntheticBubble(n int, tc, m, omega, A, B, C, phi float64) []data.Point {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := make([]data.Point, n)
+	for i := range points {
+		points[i] = data.Point{Date: start.AddDate(0, 0, i), Price: math.Exp(Model(float64(i), tc, m, omega, A, B, C, phi))}
+	}
+	return points
+}
+
+func TestFitAllSynthetic(t *testing.T) {
+	tests := []struct {
+		name                  string
+		n                     int
+		tc, m, omega, A, B, C float64
+		phi                   float64
+		method                string
+	}{
+		{name: "nelder-mead", n: 200, tc: 230, m: 0.5, omega: 8, A: 10, B: -0.05, C: 0.05, phi: 1, method: MethodNelderMead},
+		{name: "krótkie okno", n: 120, tc: 140, m: 0.6, omega: 10, A: 8, B: -0.08, C: 0.04, phi: 0.5, method: MethodNelderMead},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points := syntheticBubble(tt.n, tt.tc, tt.m, tt.omega, tt.A, tt.B, tt.C, tt.phi)
+			opts := DefaultFitOptions()
+			opts.Method = tt.method
+			opts.Workers = 1
+			best, _, err := FitAll(points, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Na szeregu bez szumu tc powinno wyjść z dokładnością kilku procent okna
+			if d := math.Abs(best.Params[0] - tt.tc); d > 0.05*float64(tt.n) {
+				t.Errorf("tc = %.1f, oczekiwano %.1f", best.Params[0], tt.tc)
+			}
+			if best.Cost > 1e-3 {
+				t.Errorf("koszt %g, oczekiwano dopasowania bliskiego idealnemu", best.Cost)
+			}
+		})
+	}
+}
+
+func TestFitAllRejectsInvalidBounds(t *testing.T) {
+	points := syntheticBubble(100, 120, 0.5, 8, 10, -0.05, 0.05, 1)
+	opts := DefaultFitOptions()
+	opts.Bounds.M = [2]float64{1, 0}
+	if _, _, err := FitAll(points, opts); err == nil {
+		t.Error("oczekiwano błędu dla pustego zakresu m")
+	}
+}
+
+func TestBeyondTcPenaltyIsScaleFree(t *testing.T) {
+	points := syntheticBubble(100, 120, 0.5, 8, 10, -0.05, 0.05, 1)
+	days := data.TimeIndex(points)
+	hours := make([]float64, len(days))
+	for i, d := range days {
+		hours[i] = 24 * d
+	}
+	opts := DefaultFitOptions()
+	opts.BeyondTc = BeyondTcPenalty
+	// tc przed ostatnimi 20 obserwacjami; model przed tc jest stały, bo liczy się tylko kara
+	cost := func(timeIndex []float64, tc float64) float64 {
+		return residualCost(points, timeIndex, nil, opts, tc, 10, func(int) float64 { return 10 })
+	}
+	if d, h := cost(days, 80), cost(hours, 24*80); math.Abs(d-h) > 1e-9*d {
+		t.Errorf("koszt w dniach %g, w godzinach %g; kara powinna nie zależeć od jednostek czasu", d, h)
+	}
+}
@@ -0,0 +1,82 @@
+package lppl
+
+import (
+	"math"
+	"sync"
+)
+
+// powTableLimit ogranicza liczbę wartości zapamiętanych w powTable (32 MB); po jego
+// przekroczeniu tablica jest czyszczona, bo komórki siatki są oceniane w kolejności tc.
+// Zmienna, żeby testy mogły sprawdzić czyszczenie, a testy wydajności wyłączyć pamięć (0).
+var powTableLimit = 1 << 22
+
+// powTable zapamiętuje ln(tc-t) dla każdego tc i (tc-t)^m dla każdej pary (tc, m)
+// jednego okna, żeby komórki gęstej siatki różniące się tylko omega nie liczyły ich od
+// nowa. Dla obserwacji z t >= tc obie tablice zawierają NaN. Bezpieczna dla wielu wątków.
+type powTable struct {
+	timeIndex []float64
+
+	mu   sync.Mutex
+	logs map[float64][]float64
+	pows map[[2]float64][]float64
+	size int
+}
+
+func newPowTable(timeIndex []float64) *powTable {
+	return &powTable{timeIndex: timeIndex, logs: map[float64][]float64{}, pows: map[[2]float64][]float64{}}
+}
+
+// log zwraca ln(tc-t) dla każdej obserwacji
+func (p *powTable) log(tc float64) []float64 {
+	p.mu.Lock()
+	v, ok := p.logs[tc]
+	p.mu.Unlock()
+	if ok {
+		return v
+	}
+	v = make([]float64, len(p.timeIndex))
+	for i, t := range p.timeIndex {
+		v[i] = math.NaN()
+		if dt := tc - t; dt > 0 {
+			v[i] = math.Log(dt)
+		}
+	}
+	p.store(func() { p.logs[tc] = v })
+	return v
+}
+
+// pow zwraca (tc-t)^m dla każdej obserwacji
+func (p *powTable) pow(tc, m float64) []float64 {
+	key := [2]float64{tc, m}
+	p.mu.Lock()
+	v, ok := p.pows[key]
+	p.mu.Unlock()
+	if ok {
+		return v
+	}
+	v = make([]float64, len(p.timeIndex))
+	for i, t := range p.timeIndex {
+		v[i] = math.NaN()
+		if dt := tc - t; dt > 0 {
+			v[i] = math.Pow(dt, m)
+		}
+	}
+	p.store(func() { p.pows[key] = v })
+	return v
+}
+
+// store dopisuje tablicę, a gdy zabrakłoby miejsca, najpierw czyści całą pamięć
+func (p *powTable) store(add func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if powTableLimit == 0 {
+		return
+	}
+	if p.size+len(p.timeIndex) > powTableLimit {
+		clear(p.logs)
+		clear(p.pows)
+		p.size = 0
+	}
+	p.size += len(p.timeIndex)
+	add()
+}
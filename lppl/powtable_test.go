@@ -0,0 +1,117 @@
+package lppl
+
+import (
+	"math"
+	"testing"
+
+	"cw3/data"
+)
+
+func TestPowTable(t *testing.T) {
+	timeIndex := []float64{0, 1, 2.5, 10, 20}
+	p := newPowTable(timeIndex)
+	tests := []struct{ tc, m float64 }{{25, 0.5}, {25, 0.9}, {12, 0.3}, {2.5, 0.7}}
+	for _, tt := range tests {
+		logs, pows := p.log(tt.tc), p.pow(tt.tc, tt.m)
+		for i, ti := range timeIndex {
+			dt := tt.tc - ti
+			if dt <= 0 {
+				if !math.IsNaN(logs[i]) || !math.IsNaN(pows[i]) {
+					t.Errorf("tc %g, t %g: oczekiwano NaN za tc, otrzymano %g, %g", tt.tc, ti, logs[i], pows[i])
+				}
+				continue
+			}
+			if logs[i] != math.Log(dt) || pows[i] != math.Pow(dt, tt.m) {
+				t.Errorf("tc %g, m %g, t %g: %g, %g; oczekiwano %g, %g", tt.tc, tt.m, ti, logs[i], pows[i], math.Log(dt), math.Pow(dt, tt.m))
+			}
+		}
+		// Trafienie zwraca zapamiętaną tablicę, a nie nową
+		if &p.log(tt.tc)[0] != &logs[0] || &p.pow(tt.tc, tt.m)[0] != &pows[0] {
+			t.Errorf("tc %g, m %g: ponowne zapytanie przeliczyło tablicę", tt.tc, tt.m)
+		}
+	}
+	if len(p.logs) != 3 || len(p.pows) != 4 || p.size != 7*len(timeIndex) {
+		t.Errorf("zapamiętano %d tablic logarytmów i %d potęg (%d wartości), oczekiwano 3, 4 i %d",
+			len(p.logs), len(p.pows), p.size, 7*len(timeIndex))
+	}
+}
+
+func TestPowTableEviction(t *testing.T) {
+	defer func(limit int) { powTableLimit = limit }(powTableLimit)
+	timeIndex := []float64{0, 1, 2, 3}
+	// Miejsce na trzy tablice
+	powTableLimit = 3 * len(timeIndex)
+	p := newPowTable(timeIndex)
+	first := p.pow(10, 0.1)
+	p.pow(10, 0.2)
+	p.pow(10, 0.3)
+	if &p.pow(10, 0.1)[0] != &first[0] {
+		t.Fatal("tablica zniknęła przed przekroczeniem limitu")
+	}
+	// Czwarta tablica czyści pamięć i zostaje jedyną zapamiętaną
+	p.pow(10, 0.4)
+	if len(p.pows) != 1 || p.size != len(timeIndex) {
+		t.Fatalf("po przekroczeniu limitu zapamiętano %d tablic (%d wartości), oczekiwano 1", len(p.pows), p.size)
+	}
+	again := p.pow(10, 0.1)
+	if &again[0] == &first[0] || again[0] != first[0] {
+		t.Error("po wyczyszczeniu tablica powinna zostać przeliczona z tym samym wynikiem")
+	}
+
+	// Zerowy limit wyłącza pamięć
+	powTableLimit = 0
+	p = newPowTable(timeIndex)
+	if a, b := p.pow(10, 0.5), p.pow(10, 0.5); &a[0] == &b[0] || len(p.pows) != 0 {
+		t.Error("przy zerowym limicie tablice nie powinny być zapamiętywane")
+	}
+}
+
+// BenchmarkGrid mierzy ocenę siatki 15 x 20 x 40 na 240 obserwacjach: osobno komórki z tc
+// za danymi (paczki z mnożeniem macierzy) i w oknie, z tablicami potęg i bez nich, oraz
+// ocenę komórka po komórce przez solveLinear i fitCost. Uruchomienie:
+//
+//	go test -run '^$' -bench Grid ./lppl
+func BenchmarkGrid(b *testing.B) {
+	const n = 240
+	points := noisyBubble(n, 0.02, 1)
+	axes := GridSpec{M: GridAxis{Lo: 0.1, Hi: 0.9, N: 20}, Omega: GridAxis{Lo: 4, Hi: 16, N: 40}}
+	regions := []struct {
+		name string
+		tc   GridAxis
+	}{
+		{"za danymi", GridAxis{Lo: n + 1, Hi: 1.5 * n, N: 15}},
+		{"w oknie", GridAxis{Lo: 0.8 * n, Hi: n - 1, N: 15}},
+	}
+	opts := DefaultFitOptions()
+	opts.Workers = 1
+	for _, r := range regions {
+		spec := axes
+		spec.Tc = r.tc
+		for _, limit := range []int{powTableLimit, 0} {
+			name := r.name + "/tablica"
+			if limit == 0 {
+				name = r.name + "/bez tablicy"
+			}
+			b.Run(name, func(b *testing.B) {
+				defer func(l int) { powTableLimit = l }(powTableLimit)
+				powTableLimit = limit
+				for range b.N {
+					if _, err := GridEvaluate(points, spec, opts, 0, spec.Cells(), 10); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+		b.Run(r.name+"/komórka po komórce", func(b *testing.B) {
+			timeIndex := data.TimeIndex(points)
+			for range b.N {
+				for i := range spec.Cells() {
+					tc, m, omega := spec.Cell(i)
+					if params, ok := solveLinear(tc, m, omega, points, timeIndex, nil); ok {
+						fitCost(params, points, timeIndex, nil, opts)
+					}
+				}
+			}
+		})
+	}
+}
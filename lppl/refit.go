@@ -0,0 +1,91 @@
+package lppl
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/diff/fd"
+	"gonum.org/v1/gonum/optimize"
+
+	"cw3/internal/safe"
+)
+
+type attempt struct {
+	name    string
+	method  optimize.Method
+	initial []float64
+}
+
+// perturb przesuwa punkt startowy, żeby wyjść z obszaru, w którym utknął poprzedni
+// przebieg; współrzędne to tc, m i omega przed odwzorowaniem sigmoidą
+func perturb(x []float64) []float64 {
+	out := append([]float64(nil), x...)
+	out[0] -= 1   // tc bliżej początku przedziału
+	out[1] -= 0.5 // mniejsze m
+	out[2] += 0.5 // większe omega
+	return out
+}
+
+// minimizeWithRetry uruchamia Nelder-Mead, a gdy wynik wygląda podejrzanie (limit iteracji,
+// koszt NaN, parametry na granicy), ponawia próbę z przesuniętego startu i metodą BFGS
+// z gradientem numerycznym. Zwraca najlepszy wynik i opis przebiegu prób.
+func minimizeWithRetry(problem optimize.Problem, initial []float64, settings *optimize.Settings, suspicious func(*optimize.Result) string) (*optimize.Result, []string, error) {
+	withGrad := problem
+	withGrad.Grad = func(grad, x []float64) {
+		fd.Gradient(grad, problem.Func, x, nil)
+	}
+
+	attempts := []attempt{
+		{"Nelder-Mead", &optimize.NelderMead{}, initial},
+		{"Nelder-Mead (przesunięty start)", &optimize.NelderMead{}, perturb(initial)},
+		{"BFGS", &optimize.BFGS{}, initial},
+	}
+
+	var (
+		best  *optimize.Result
+		notes []string
+	)
+	for i, a := range attempts {
+		p := problem
+		if _, ok := a.method.(*optimize.BFGS); ok {
+			p = withGrad
+		}
+
+		result, err := safeMinimize(p, a.initial, settings, a.method)
+		if result == nil {
+			notes = append(notes, fmt.Sprintf("%s: %v", a.name, err))
+			continue
+		}
+
+		reason := suspicious(result)
+		if reason == "" && err != nil {
+			reason = result.Status.String()
+		}
+		if reason == "" {
+			if i > 0 {
+				notes = append(notes, a.name+": wynik zaakceptowany")
+			}
+			return result, notes, nil
+		}
+
+		notes = append(notes, fmt.Sprintf("%s: %s", a.name, reason))
+		if best == nil || result.F < best.F || math.IsNaN(best.F) {
+			best = result
+		}
+	}
+	if best == nil {
+		return nil, notes, errors.New("wszystkie próby optymalizacji zakończyły się błędem")
+	}
+	notes = append(notes, "brak poprawnej zbieżności, użyto najlepszego z podejrzanych wyników")
+	return best, notes, nil
+}
+
+func safeMinimize(p optimize.Problem, initial []float64, settings *optimize.Settings, method optimize.Method) (result *optimize.Result, err error) {
+	err = safe.Guard("optymalizacja", func() error {
+		var minErr error
+		result, minErr = optimize.Minimize(p, initial, settings, method)
+		return minErr
+	})
+	return result, err
+}
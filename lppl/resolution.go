@@ -0,0 +1,35 @@
+package lppl
+
+import (
+	"math"
+)
+
+// Sposób zakończenia bańki przewidywany na podstawie obszaru parametrów dopasowania
+const (
+	ResolutionCrash   = "krach"
+	ResolutionPlateau = "plateau"
+)
+
+// Granice obszaru parametrów typowego dla baniek zakończonych gwałtownym krachem
+const (
+	crashMaxM       = 0.5
+	crashMinDamping = 1.0
+)
+
+// ClassifyResolution ocenia, czy kwalifikowana bańka dodatnia skończy się raczej ostrym
+// krachem, czy zmiennym plateau. Małe m oznacza gwałtowne przyspieszenie tuż przed tc
+// (stopa hazardu rośnie jak (tc-t)^(m-1)), a tłumienie m|B|/(omega|C|) powyżej 1 -
+// przewagę trendu nad oscylacjami. Duże m albo dominujące oscylacje odpowiadają
+// łagodnemu przejściu w okres wysokiej zmienności bez wyraźnego załamania.
+// Dla bańki ujemnej lub dopasowania niekwalifikowanego zwraca pusty napis.
+func ClassifyResolution(best Result) string {
+	m, omega, B, C := best.Params[1], best.Params[2], best.Params[4], best.Params[5]
+	if !best.Qualified() || B >= 0 {
+		return ""
+	}
+	damping := m * math.Abs(B) / (omega * math.Abs(C))
+	if m <= crashMaxM && damping >= crashMinDamping {
+		return ResolutionCrash
+	}
+	return ResolutionPlateau
+}
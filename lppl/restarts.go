@@ -0,0 +1,52 @@
+package lppl
+
+import (
+	"fmt"
+	"math"
+
+	"cw3/internal/stats"
+)
+
+// RestartSummary opisuje rozrzut minimów znalezionych z różnych startów optymalizacji
+type RestartSummary struct {
+	Starts    int
+	Failed    int
+	Qualified int
+
+	MinCost, MedianCost, MaxCost float64
+	// Rozstęp tc wśród dopasowań zgodnych z filtrami (NaN, gdy żadne nie jest zgodne)
+	TcSpread float64
+}
+
+// Summarize zestawia wynik FitAll: najlepsze dopasowanie i odrzucone alternatywy
+func Summarize(best Result, rejected []Result) RestartSummary {
+	all := append([]Result{best}, rejected...)
+	sum := RestartSummary{Starts: best.Starts, Failed: best.Starts - len(all), TcSpread: math.NaN()}
+	costs := make([]float64, len(all))
+	tcLo, tcHi := math.Inf(1), math.Inf(-1)
+	for i, r := range all {
+		costs[i] = r.Cost
+		if r.Qualified() {
+			sum.Qualified++
+			tcLo, tcHi = math.Min(tcLo, r.Params[0]), math.Max(tcHi, r.Params[0])
+		}
+	}
+	if sum.Qualified > 0 {
+		sum.TcSpread = tcHi - tcLo
+	}
+	sum.MinCost, sum.MaxCost = costs[0], costs[0]
+	for _, c := range costs {
+		sum.MinCost, sum.MaxCost = math.Min(sum.MinCost, c), math.Max(sum.MaxCost, c)
+	}
+	sum.MedianCost = stats.Median(costs)
+	return sum
+}
+
+func (s RestartSummary) String() string {
+	out := fmt.Sprintf("%d startów (nieudane: %d), zgodne z filtrami: %d, koszt min/mediana/maks: %.6f/%.6f/%.6f",
+		s.Starts, s.Failed, s.Qualified, s.MinCost, s.MedianCost, s.MaxCost)
+	if s.Qualified > 1 {
+		out += fmt.Sprintf(", rozstęp tc zgodnych: %.2f dni", s.TcSpread)
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package lppl
+
+import (
+	"errors"
+	"math"
+
+	"cw3/data"
+)
+
+// spectrumSteps to liczba częstości, dla których liczony jest periodogram reszt
+const spectrumSteps = 400
+
+// Spectrum to periodogram Lomba reszt dopasowania: Freq w cyklach na dzień (czas liniowy)
+// albo częstość kątowa omega (czas ln(tc-t))
+type Spectrum struct {
+	Freq  []float64
+	Power []float64
+}
+
+// Peak zwraca częstość o największej mocy
+func (s Spectrum) Peak() (freq, power float64) {
+	for i, p := range s.Power {
+		if p > power {
+			freq, power = s.Freq[i], p
+		}
+	}
+	return freq, power
+}
+
+// Residuals zwraca reszty ln p - model dla obserwacji przed tc
+func Residuals(points []data.Point, params []float64) (t, r []float64) {
+	timeIndex := data.TimeIndex(points)
+	for i, ti := range timeIndex {
+		if ti >= params[0] {
+			break
+		}
+		m := Model(ti, params[0], params[1], params[2], params[3], params[4], params[5], params[6])
+		t = append(t, ti)
+		r = append(r, math.Log(points[i].Price)-m)
+	}
+	return t, r
+}
+
+// ResidualSpectra liczy periodogramy reszt w czasie liniowym i w czasie ln(tc-t).
+// Periodogram Lomba nie wymaga równych odstępów, więc luki w notowaniach i
+// nierównomierna siatka ln(tc-t) nie wymagają interpolacji. Wyraźny pik w czasie
+// logarytmicznym oznacza składową log-periodyczną, której model nie wyjaśnił.
+func ResidualSpectra(points []data.Point, params []float64) (linear, logTime Spectrum, err error) {
+	t, r := Residuals(points, params)
+	if len(r) < hqMinSamples {
+		return linear, logTime, errors.New("za mało reszt do analizy widmowej")
+	}
+
+	// Od jednego cyklu na długość okna do częstości Nyquista dla mediany kroku
+	span := t[len(t)-1] - t[0]
+	fMin, fMax := 1/span, 0.5/medianStep(t)
+	for i := 0; i < spectrumSteps; i++ {
+		f := fMin + (fMax-fMin)*float64(i)/(spectrumSteps-1)
+		linear.Freq = append(linear.Freq, f)
+		linear.Power = append(linear.Power, lombPower(t, r, 2*math.Pi*f))
+	}
+
+	lnX := make([]float64, len(t))
+	for i, ti := range t {
+		lnX[i] = math.Log(params[0] - ti)
+	}
+	for i := 0; i < spectrumSteps; i++ {
+		w := hqOmegaMin + (hqOmegaMax-hqOmegaMin)*float64(i)/(spectrumSteps-1)
+		logTime.Freq = append(logTime.Freq, w)
+		logTime.Power = append(logTime.Power, lombPower(lnX, r, w))
+	}
+	return linear, logTime, nil
+}
@@ -0,0 +1,61 @@
+package lppl
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"cw3/data"
+)
+
+// validateWindow sprawdza przed dopasowaniem, czy okno ma dość obserwacji względem
+// liczby parametrów i czy może pomieścić wystarczająco wiele oscylacji, by dało się
+// zidentyfikować omega. Bez tego optymalizator zwraca formalnie poprawny, ale
+// bezwartościowy wynik.
+func validateWindow(points []data.Point, opts FitOptions) error {
+	for _, point := range points {
+		if !data.ValidPrice(point.Price) {
+			return fmt.Errorf("nieprawidłowa cena %v z dnia %s: wymagana dodatnia liczba skończona",
+				point.Price, point.Date.Format("2006-01-02"))
+		}
+	}
+
+	if dof := len(points) - ParamCount; dof < opts.MinDOF {
+		return fmt.Errorf("za mało obserwacji w oknie: %d przy %d parametrach (wymagane co najmniej %d stopni swobody)",
+			len(points), ParamCount, opts.MinDOF)
+	}
+
+	timeIndex := data.TimeIndex(points)
+	t1, t2 := timeIndex[0], timeIndex[len(timeIndex)-1]
+	if t2 <= t1 {
+		return fmt.Errorf("okno ma zerową długość (%s)", points[0].Date.Format("2006-01-02"))
+	}
+
+	required := opts.Filters.MinOscillations
+	if required <= 0 {
+		required = minIdentifiableOscillations
+	}
+
+	// Najwięcej oscylacji mieści się przy tc najbliżej t2, ale nie bliżej niż jeden krok
+	// próbkowania, bo szybszych oscylacji dane i tak nie rozróżnią
+	tcLo, _ := TcRange(timeIndex, opts)
+	tcLo = math.Max(tcLo, t2+medianStep(timeIndex))
+	best := opts.Filters.MaxOmega / (2 * math.Pi) * math.Log((tcLo-t1)/(tcLo-t2))
+	if best < required {
+		return fmt.Errorf("okno mieści najwyżej %.2f oscylacji przy omega <= %.1f, wymagane %.2f",
+			best, opts.Filters.MaxOmega, required)
+	}
+	return nil
+}
+
+// Minimalna liczba oscylacji, gdy filtr oscylacji jest wyłączony
+const minIdentifiableOscillations = 1.0
+
+func medianStep(timeIndex []float64) float64 {
+	steps := make([]float64, 0, len(timeIndex)-1)
+	for i := 1; i < len(timeIndex); i++ {
+		steps = append(steps, timeIndex[i]-timeIndex[i-1])
+	}
+	sort.Float64s(steps)
+	return steps[len(steps)/2]
+}
@@ -0,0 +1,610 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: lppl.proto
+
+package lpplpb
+
+import (
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Observation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Date          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=date,proto3" json:"date,omitempty"`
+	Price         float64                `protobuf:"fixed64,2,opt,name=price,proto3" json:"price,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Observation) Reset() {
+	*x = Observation{}
+	mi := &file_lppl_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Observation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Observation) ProtoMessage() {}
+
+func (x *Observation) ProtoReflect() protoreflect.Message {
+	mi := &file_lppl_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Observation.ProtoReflect.Descriptor instead.
+func (*Observation) Descriptor() ([]byte, []int) {
+	return file_lppl_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Observation) GetDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *Observation) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+type Series struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Observations  []*Observation         `protobuf:"bytes,2,rep,name=observations,proto3" json:"observations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Series) Reset() {
+	*x = Series{}
+	mi := &file_lppl_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Series) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Series) ProtoMessage() {}
+
+func (x *Series) ProtoReflect() protoreflect.Message {
+	mi := &file_lppl_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Series.ProtoReflect.Descriptor instead.
+func (*Series) Descriptor() ([]byte, []int) {
+	return file_lppl_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Series) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Series) GetObservations() []*Observation {
+	if x != nil {
+		return x.Observations
+	}
+	return nil
+}
+
+type FitResult struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	WindowStart *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=window_start,json=windowStart,proto3" json:"window_start,omitempty"`
+	WindowEnd   *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=window_end,json=windowEnd,proto3" json:"window_end,omitempty"`
+	// tc w dniach od początku okna oraz jako data
+	Tc            float64                `protobuf:"fixed64,3,opt,name=tc,proto3" json:"tc,omitempty"`
+	TcDate        *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=tc_date,json=tcDate,proto3" json:"tc_date,omitempty"`
+	M             float64                `protobuf:"fixed64,5,opt,name=m,proto3" json:"m,omitempty"`
+	Omega         float64                `protobuf:"fixed64,6,opt,name=omega,proto3" json:"omega,omitempty"`
+	A             float64                `protobuf:"fixed64,7,opt,name=a,proto3" json:"a,omitempty"`
+	B             float64                `protobuf:"fixed64,8,opt,name=b,proto3" json:"b,omitempty"`
+	C             float64                `protobuf:"fixed64,9,opt,name=c,proto3" json:"c,omitempty"`
+	Phi           float64                `protobuf:"fixed64,10,opt,name=phi,proto3" json:"phi,omitempty"`
+	Cost          float64                `protobuf:"fixed64,11,opt,name=cost,proto3" json:"cost,omitempty"`
+	Qualified     bool                   `protobuf:"varint,12,opt,name=qualified,proto3" json:"qualified,omitempty"`
+	Violations    []string               `protobuf:"bytes,13,rep,name=violations,proto3" json:"violations,omitempty"`
+	Notes         []string               `protobuf:"bytes,14,rep,name=notes,proto3" json:"notes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FitResult) Reset() {
+	*x = FitResult{}
+	mi := &file_lppl_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FitResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FitResult) ProtoMessage() {}
+
+func (x *FitResult) ProtoReflect() protoreflect.Message {
+	mi := &file_lppl_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FitResult.ProtoReflect.Descriptor instead.
+func (*FitResult) Descriptor() ([]byte, []int) {
+	return file_lppl_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *FitResult) GetWindowStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.WindowStart
+	}
+	return nil
+}
+
+func (x *FitResult) GetWindowEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.WindowEnd
+	}
+	return nil
+}
+
+func (x *FitResult) GetTc() float64 {
+	if x != nil {
+		return x.Tc
+	}
+	return 0
+}
+
+func (x *FitResult) GetTcDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.TcDate
+	}
+	return nil
+}
+
+func (x *FitResult) GetM() float64 {
+	if x != nil {
+		return x.M
+	}
+	return 0
+}
+
+func (x *FitResult) GetOmega() float64 {
+	if x != nil {
+		return x.Omega
+	}
+	return 0
+}
+
+func (x *FitResult) GetA() float64 {
+	if x != nil {
+		return x.A
+	}
+	return 0
+}
+
+func (x *FitResult) GetB() float64 {
+	if x != nil {
+		return x.B
+	}
+	return 0
+}
+
+func (x *FitResult) GetC() float64 {
+	if x != nil {
+		return x.C
+	}
+	return 0
+}
+
+func (x *FitResult) GetPhi() float64 {
+	if x != nil {
+		return x.Phi
+	}
+	return 0
+}
+
+func (x *FitResult) GetCost() float64 {
+	if x != nil {
+		return x.Cost
+	}
+	return 0
+}
+
+func (x *FitResult) GetQualified() bool {
+	if x != nil {
+		return x.Qualified
+	}
+	return false
+}
+
+func (x *FitResult) GetViolations() []string {
+	if x != nil {
+		return x.Violations
+	}
+	return nil
+}
+
+func (x *FitResult) GetNotes() []string {
+	if x != nil {
+		return x.Notes
+	}
+	return nil
+}
+
+type ScanRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Series *Series                `protobuf:"bytes,1,opt,name=series,proto3" json:"series,omitempty"`
+	// Minimalna liczba obserwacji w oknie; 0 oznacza wartość domyślną serwera
+	MinWindow     int32 `protobuf:"varint,2,opt,name=min_window,json=minWindow,proto3" json:"min_window,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanRequest) Reset() {
+	*x = ScanRequest{}
+	mi := &file_lppl_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRequest) ProtoMessage() {}
+
+func (x *ScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lppl_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRequest.ProtoReflect.Descriptor instead.
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return file_lppl_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ScanRequest) GetSeries() *Series {
+	if x != nil {
+		return x.Series
+	}
+	return nil
+}
+
+func (x *ScanRequest) GetMinWindow() int32 {
+	if x != nil {
+		return x.MinWindow
+	}
+	return 0
+}
+
+type ScanSummary struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Windows          int32                  `protobuf:"varint,1,opt,name=windows,proto3" json:"windows,omitempty"`
+	Qualified        int32                  `protobuf:"varint,2,opt,name=qualified,proto3" json:"qualified,omitempty"`
+	PositiveFraction float64                `protobuf:"fixed64,3,opt,name=positive_fraction,json=positiveFraction,proto3" json:"positive_fraction,omitempty"`
+	NegativeFraction float64                `protobuf:"fixed64,4,opt,name=negative_fraction,json=negativeFraction,proto3" json:"negative_fraction,omitempty"`
+	Best             *FitResult             `protobuf:"bytes,5,opt,name=best,proto3" json:"best,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ScanSummary) Reset() {
+	*x = ScanSummary{}
+	mi := &file_lppl_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanSummary) ProtoMessage() {}
+
+func (x *ScanSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_lppl_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanSummary.ProtoReflect.Descriptor instead.
+func (*ScanSummary) Descriptor() ([]byte, []int) {
+	return file_lppl_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ScanSummary) GetWindows() int32 {
+	if x != nil {
+		return x.Windows
+	}
+	return 0
+}
+
+func (x *ScanSummary) GetQualified() int32 {
+	if x != nil {
+		return x.Qualified
+	}
+	return 0
+}
+
+func (x *ScanSummary) GetPositiveFraction() float64 {
+	if x != nil {
+		return x.PositiveFraction
+	}
+	return 0
+}
+
+func (x *ScanSummary) GetNegativeFraction() float64 {
+	if x != nil {
+		return x.NegativeFraction
+	}
+	return 0
+}
+
+func (x *ScanSummary) GetBest() *FitResult {
+	if x != nil {
+		return x.Best
+	}
+	return nil
+}
+
+type ScanUpdate struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Update:
+	//
+	//	*ScanUpdate_Fit
+	//	*ScanUpdate_Summary
+	Update        isScanUpdate_Update `protobuf_oneof:"update"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanUpdate) Reset() {
+	*x = ScanUpdate{}
+	mi := &file_lppl_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanUpdate) ProtoMessage() {}
+
+func (x *ScanUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_lppl_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanUpdate.ProtoReflect.Descriptor instead.
+func (*ScanUpdate) Descriptor() ([]byte, []int) {
+	return file_lppl_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ScanUpdate) GetUpdate() isScanUpdate_Update {
+	if x != nil {
+		return x.Update
+	}
+	return nil
+}
+
+func (x *ScanUpdate) GetFit() *FitResult {
+	if x != nil {
+		if x, ok := x.Update.(*ScanUpdate_Fit); ok {
+			return x.Fit
+		}
+	}
+	return nil
+}
+
+func (x *ScanUpdate) GetSummary() *ScanSummary {
+	if x != nil {
+		if x, ok := x.Update.(*ScanUpdate_Summary); ok {
+			return x.Summary
+		}
+	}
+	return nil
+}
+
+type isScanUpdate_Update interface {
+	isScanUpdate_Update()
+}
+
+type ScanUpdate_Fit struct {
+	Fit *FitResult `protobuf:"bytes,1,opt,name=fit,proto3,oneof"`
+}
+
+type ScanUpdate_Summary struct {
+	Summary *ScanSummary `protobuf:"bytes,2,opt,name=summary,proto3,oneof"`
+}
+
+func (*ScanUpdate_Fit) isScanUpdate_Update() {}
+
+func (*ScanUpdate_Summary) isScanUpdate_Update() {}
+
+var File_lppl_proto protoreflect.FileDescriptor
+
+const file_lppl_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"lppl.proto\x12\x04lppl\x1a\x1fgoogle/protobuf/timestamp.proto\"S\n" +
+	"\vObservation\x12.\n" +
+	"\x04date\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04date\x12\x14\n" +
+	"\x05price\x18\x02 \x01(\x01R\x05price\"W\n" +
+	"\x06Series\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x125\n" +
+	"\fobservations\x18\x02 \x03(\v2\x11.lppl.ObservationR\fobservations\"\x92\x03\n" +
+	"\tFitResult\x12=\n" +
+	"\fwindow_start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\vwindowStart\x129\n" +
+	"\n" +
+	"window_end\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\twindowEnd\x12\x0e\n" +
+	"\x02tc\x18\x03 \x01(\x01R\x02tc\x123\n" +
+	"\atc_date\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x06tcDate\x12\f\n" +
+	"\x01m\x18\x05 \x01(\x01R\x01m\x12\x14\n" +
+	"\x05omega\x18\x06 \x01(\x01R\x05omega\x12\f\n" +
+	"\x01a\x18\a \x01(\x01R\x01a\x12\f\n" +
+	"\x01b\x18\b \x01(\x01R\x01b\x12\f\n" +
+	"\x01c\x18\t \x01(\x01R\x01c\x12\x10\n" +
+	"\x03phi\x18\n" +
+	" \x01(\x01R\x03phi\x12\x12\n" +
+	"\x04cost\x18\v \x01(\x01R\x04cost\x12\x1c\n" +
+	"\tqualified\x18\f \x01(\bR\tqualified\x12\x1e\n" +
+	"\n" +
+	"violations\x18\r \x03(\tR\n" +
+	"violations\x12\x14\n" +
+	"\x05notes\x18\x0e \x03(\tR\x05notes\"R\n" +
+	"\vScanRequest\x12$\n" +
+	"\x06series\x18\x01 \x01(\v2\f.lppl.SeriesR\x06series\x12\x1d\n" +
+	"\n" +
+	"min_window\x18\x02 \x01(\x05R\tminWindow\"\xc4\x01\n" +
+	"\vScanSummary\x12\x18\n" +
+	"\awindows\x18\x01 \x01(\x05R\awindows\x12\x1c\n" +
+	"\tqualified\x18\x02 \x01(\x05R\tqualified\x12+\n" +
+	"\x11positive_fraction\x18\x03 \x01(\x01R\x10positiveFraction\x12+\n" +
+	"\x11negative_fraction\x18\x04 \x01(\x01R\x10negativeFraction\x12#\n" +
+	"\x04best\x18\x05 \x01(\v2\x0f.lppl.FitResultR\x04best\"j\n" +
+	"\n" +
+	"ScanUpdate\x12#\n" +
+	"\x03fit\x18\x01 \x01(\v2\x0f.lppl.FitResultH\x00R\x03fit\x12-\n" +
+	"\asummary\x18\x02 \x01(\v2\x11.lppl.ScanSummaryH\x00R\asummaryB\b\n" +
+	"\x06update2[\n" +
+	"\x04LPPL\x12$\n" +
+	"\x03Fit\x12\f.lppl.Series\x1a\x0f.lppl.FitResult\x12-\n" +
+	"\x04Scan\x12\x11.lppl.ScanRequest\x1a\x10.lppl.ScanUpdate0\x01B\fZ\n" +
+	"cw3/lpplpbb\x06proto3"
+
+var (
+	file_lppl_proto_rawDescOnce sync.Once
+	file_lppl_proto_rawDescData []byte
+)
+
+func file_lppl_proto_rawDescGZIP() []byte {
+	file_lppl_proto_rawDescOnce.Do(func() {
+		file_lppl_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_lppl_proto_rawDesc), len(file_lppl_proto_rawDesc)))
+	})
+	return file_lppl_proto_rawDescData
+}
+
+var file_lppl_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_lppl_proto_goTypes = []any{
+	(*Observation)(nil),           // 0: lppl.Observation
+	(*Series)(nil),                // 1: lppl.Series
+	(*FitResult)(nil),             // 2: lppl.FitResult
+	(*ScanRequest)(nil),           // 3: lppl.ScanRequest
+	(*ScanSummary)(nil),           // 4: lppl.ScanSummary
+	(*ScanUpdate)(nil),            // 5: lppl.ScanUpdate
+	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+}
+var file_lppl_proto_depIdxs = []int32{
+	6,  // 0: lppl.Observation.date:type_name -> google.protobuf.Timestamp
+	0,  // 1: lppl.Series.observations:type_name -> lppl.Observation
+	6,  // 2: lppl.FitResult.window_start:type_name -> google.protobuf.Timestamp
+	6,  // 3: lppl.FitResult.window_end:type_name -> google.protobuf.Timestamp
+	6,  // 4: lppl.FitResult.tc_date:type_name -> google.protobuf.Timestamp
+	1,  // 5: lppl.ScanRequest.series:type_name -> lppl.Series
+	2,  // 6: lppl.ScanSummary.best:type_name -> lppl.FitResult
+	2,  // 7: lppl.ScanUpdate.fit:type_name -> lppl.FitResult
+	4,  // 8: lppl.ScanUpdate.summary:type_name -> lppl.ScanSummary
+	1,  // 9: lppl.LPPL.Fit:input_type -> lppl.Series
+	3,  // 10: lppl.LPPL.Scan:input_type -> lppl.ScanRequest
+	2,  // 11: lppl.LPPL.Fit:output_type -> lppl.FitResult
+	5,  // 12: lppl.LPPL.Scan:output_type -> lppl.ScanUpdate
+	11, // [11:13] is the sub-list for method output_type
+	9,  // [9:11] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_lppl_proto_init() }
+func file_lppl_proto_init() {
+	if File_lppl_proto != nil {
+		return
+	}
+	file_lppl_proto_msgTypes[5].OneofWrappers = []any{
+		(*ScanUpdate_Fit)(nil),
+		(*ScanUpdate_Summary)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_lppl_proto_rawDesc), len(file_lppl_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_lppl_proto_goTypes,
+		DependencyIndexes: file_lppl_proto_depIdxs,
+		MessageInfos:      file_lppl_proto_msgTypes,
+	}.Build()
+	File_lppl_proto = out.File
+	file_lppl_proto_goTypes = nil
+	file_lppl_proto_depIdxs = nil
+}
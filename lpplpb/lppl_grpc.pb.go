@@ -0,0 +1,168 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: lppl.proto
+
+package lpplpb
+
+import (
+	context "context"
+
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	LPPL_Fit_FullMethodName  = "/lppl.LPPL/Fit"
+	LPPL_Scan_FullMethodName = "/lppl.LPPL/Scan"
+)
+
+// LPPLClient is the client API for LPPL service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LPPLClient interface {
+	Fit(ctx context.Context, in *Series, opts ...grpc.CallOption) (*FitResult, error)
+	// Scan dopasowuje model w kurczących się oknach i przesyła każdy wynik od razu
+	// po jego obliczeniu, a na końcu podsumowanie
+	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ScanUpdate], error)
+}
+
+type lPPLClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLPPLClient(cc grpc.ClientConnInterface) LPPLClient {
+	return &lPPLClient{cc}
+}
+
+func (c *lPPLClient) Fit(ctx context.Context, in *Series, opts ...grpc.CallOption) (*FitResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FitResult)
+	err := c.cc.Invoke(ctx, LPPL_Fit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lPPLClient) Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ScanUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LPPL_ServiceDesc.Streams[0], LPPL_Scan_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ScanRequest, ScanUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LPPL_ScanClient = grpc.ServerStreamingClient[ScanUpdate]
+
+// LPPLServer is the server API for LPPL service.
+// All implementations must embed UnimplementedLPPLServer
+// for forward compatibility.
+type LPPLServer interface {
+	Fit(context.Context, *Series) (*FitResult, error)
+	// Scan dopasowuje model w kurczących się oknach i przesyła każdy wynik od razu
+	// po jego obliczeniu, a na końcu podsumowanie
+	Scan(*ScanRequest, grpc.ServerStreamingServer[ScanUpdate]) error
+	mustEmbedUnimplementedLPPLServer()
+}
+
+// UnimplementedLPPLServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLPPLServer struct{}
+
+func (UnimplementedLPPLServer) Fit(context.Context, *Series) (*FitResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Fit not implemented")
+}
+func (UnimplementedLPPLServer) Scan(*ScanRequest, grpc.ServerStreamingServer[ScanUpdate]) error {
+	return status.Errorf(codes.Unimplemented, "method Scan not implemented")
+}
+func (UnimplementedLPPLServer) mustEmbedUnimplementedLPPLServer() {}
+func (UnimplementedLPPLServer) testEmbeddedByValue()              {}
+
+// UnsafeLPPLServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LPPLServer will
+// result in compilation errors.
+type UnsafeLPPLServer interface {
+	mustEmbedUnimplementedLPPLServer()
+}
+
+func RegisterLPPLServer(s grpc.ServiceRegistrar, srv LPPLServer) {
+	// If the following call pancis, it indicates UnimplementedLPPLServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&LPPL_ServiceDesc, srv)
+}
+
+func _LPPL_Fit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Series)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LPPLServer).Fit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LPPL_Fit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LPPLServer).Fit(ctx, req.(*Series))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LPPL_Scan_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ScanRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LPPLServer).Scan(m, &grpc.GenericServerStream[ScanRequest, ScanUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LPPL_ScanServer = grpc.ServerStreamingServer[ScanUpdate]
+
+// LPPL_ServiceDesc is the grpc.ServiceDesc for LPPL service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LPPL_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "lppl.LPPL",
+	HandlerType: (*LPPLServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Fit",
+			Handler:    _LPPL_Fit_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Scan",
+			Handler:       _LPPL_Scan_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "lppl.proto",
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"time"
+
+	"cw3/data"
+	"cw3/internal/atomicfile"
+	"cw3/lppl"
+)
+
+// Struktury zgodne z wynikiem compute_nested_fits z pythonowego pakietu lppls.
+// Czas jest tam liczbą porządkową dnia (date.toordinal()), a ceny są logarytmami.
+type lpplsFit struct {
+	TcD string  `json:"tc_d"`
+	Tc  float64 `json:"tc"`
+	M   float64 `json:"m"`
+	W   float64 `json:"w"`
+	A   float64 `json:"a"`
+	B   float64 `json:"b"`
+	C   float64 `json:"c"`
+	C1  float64 `json:"c1"`
+	C2  float64 `json:"c2"`
+	T1D string  `json:"t1_d"`
+	T2D string  `json:"t2_d"`
+	T1  float64 `json:"t1"`
+	T2  float64 `json:"t2"`
+	O   float64 `json:"O"`
+	D   float64 `json:"D"`
+}
+
+type lpplsNested struct {
+	T1  float64    `json:"t1"`
+	T2  float64    `json:"t2"`
+	P2  float64    `json:"p2"`
+	Res []lpplsFit `json:"res"`
+}
+
+// Numer porządkowy 1970-01-01 w kalendarzu Pythona (0001-01-01 ma numer 1)
+const unixEpochOrdinal = 719163
+
+func toOrdinal(t time.Time) float64 {
+	return unixEpochOrdinal + float64(t.Unix())/86400
+}
+
+func fromOrdinal(ord float64) time.Time {
+	return time.Unix(int64(math.Round((ord-unixEpochOrdinal)*86400)), 0).UTC()
+}
+
+// toLppls przelicza parametry na postać liniową lppls:
+// a + (tc-t)^m [b + c1 cos(w ln(tc-t)) + c2 sin(w ln(tc-t))]
+func toLppls(window []data.Point, params []float64) lpplsFit {
+	tc, m, omega, A, B, C, phi := params[0], params[1], params[2], params[3], params[4], params[5], params[6]
+	c1 := B * C * math.Cos(phi)
+	c2 := -B * C * math.Sin(phi)
+	c := c1 / math.Cos(math.Atan(c2/c1))
+
+	t1 := toOrdinal(window[0].Date)
+	t2 := toOrdinal(window[len(window)-1].Date)
+	tcOrd := t1 + tc
+
+	return lpplsFit{
+		TcD: fromOrdinal(tcOrd).Format("2006-01-02"),
+		Tc:  tcOrd,
+		M:   m,
+		W:   omega,
+		A:   A,
+		B:   B,
+		C:   c,
+		C1:  c1,
+		C2:  c2,
+		T1D: window[0].Date.Format("2006-01-02"),
+		T2D: window[len(window)-1].Date.Format("2006-01-02"),
+		T1:  t1,
+		T2:  t2,
+		O:   omega / (2 * math.Pi) * math.Log((tcOrd-t1)/(tcOrd-t2)),
+		D:   m * math.Abs(B) / (omega * math.Abs(c)),
+	}
+}
+
+// fromLppls odtwarza parametry modelu, z tc liczonym w dniach od początku okna lppls
+func fromLppls(f lpplsFit) []float64 {
+	amplitude := math.Hypot(f.C1, f.C2)
+	return []float64{
+		f.Tc - f.T1,
+		f.M,
+		f.W,
+		f.A,
+		f.B,
+		amplitude / f.B,
+		math.Atan2(-f.C2, f.C1),
+	}
+}
+
+// nestedFits dopasowuje model w kurczących się oknach kończących się na ostatniej
+// obserwacji, tak jak jeden krok compute_nested_fits w lppls
+func nestedFits(points []data.Point, opts lppl.FitOptions, minPoints int) lpplsNested {
+	last := points[len(points)-1]
+	nested := lpplsNested{
+		T1: toOrdinal(points[0].Date),
+		T2: toOrdinal(last.Date),
+		P2: math.Log(last.Price),
+	}
+	for start := 0; start+minPoints <= len(points); start++ {
+		window := points[start:]
+		best, err := lppl.Fit(window, opts)
+		if err != nil {
+			continue
+		}
+		nested.Res = append(nested.Res, toLppls(window, best.Params))
+	}
+	return nested
+}
+
+func writeLppls(path string, nested []lpplsNested) error {
+	file, err := atomicfile.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+
+	enc := json.NewEncoder(file)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(nested); err != nil {
+		return err
+	}
+	return file.Commit()
+}
+
+func readLppls(path string) ([]lpplsNested, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var nested []lpplsNested
+	if err := json.Unmarshal(content, &nested); err != nil {
+		return nil, err
+	}
+	return nested, nil
+}
+
+// windowFrom zwraca obserwacje z przedziału [t1, t2] opisanego datami lppls
+func windowFrom(points []data.Point, f lpplsFit) []data.Point {
+	from, to := fromOrdinal(f.T1), fromOrdinal(f.T2)
+	var window []data.Point
+	for _, point := range points {
+		if !point.Date.Before(from) && !point.Date.After(to) {
+			window = append(window, point)
+		}
+	}
+	return window
+}
@@ -0,0 +1,121 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cw3/data"
+	"cw3/fit"
+	"cw3/lppl"
+)
+
+// confidenceConfig określa, czy i w jakich oknach tryby serwera liczą wskaźnik pewności
+// bańki; bez niego pewność to 1 dla kwalifikowanej bańki dodatniej i 0 w innym razie
+type confidenceConfig struct {
+	enabled              bool
+	minWindow, maxWindow int
+	windowStep           int
+}
+
+func confidenceFlags(fs *flag.FlagSet, c *confidenceConfig) {
+	fs.BoolVar(&c.enabled, "confidence", false, "po każdym dopasowaniu licz wskaźnik pewności bańki dla ostatniej obserwacji")
+	fs.IntVar(&c.minWindow, "min-window", 30, "minimalna liczba obserwacji w oknie wskaźnika pewności bańki")
+	fs.IntVar(&c.maxWindow, "max-window", 0, "maksymalna liczba obserwacji w oknie wskaźnika pewności bańki (0 - pełna historia)")
+	fs.IntVar(&c.windowStep, "window-step", 5, "krok długości okna wskaźnika pewności bańki")
+}
+
+// confidence zwraca pewność bańki dodatniej dla dopasowania best do points
+func (c confidenceConfig) confidence(points []data.Point, opts lppl.FitOptions, best lppl.Result) float64 {
+	if c.enabled {
+		window := opts
+		window.Workers = 1
+		if f, ok := fit.LatestFraction(points, window, c.minWindow, c.maxWindow, c.windowStep); ok {
+			return f.Positive
+		}
+	}
+	if best.Qualified() && best.Params[4] < 0 {
+		return 1
+	}
+	return 0
+}
+
+// assetGauges to wartości ostatniego udanego dopasowania aktywa
+type assetGauges struct {
+	tcDays, sse, confidence float64
+	fitted                  time.Time
+}
+
+// fitMetrics zbiera metryki dopasowań udostępniane w formacie Prometheus pod /metrics
+type fitMetrics struct {
+	mu       sync.Mutex
+	gauges   map[string]assetGauges
+	fits     map[string]int
+	failures map[string]int
+}
+
+func newFitMetrics() *fitMetrics {
+	return &fitMetrics{gauges: map[string]assetGauges{}, fits: map[string]int{}, failures: map[string]int{}}
+}
+
+// apiAsset to etykieta liczników dopasowań zleconych przez POST /fit i POST /jobs
+const apiAsset = "api"
+
+// observe zapisuje wartości udanego dopasowania aktywa; tc jest liczone w dniach od
+// ostatniej obserwacji
+func (m *fitMetrics) observe(asset string, points []data.Point, best lppl.Result, confidence float64) {
+	timeIndex := data.TimeIndex(points)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gauges[asset] = assetGauges{
+		tcDays:     best.Params[0] - timeIndex[len(timeIndex)-1],
+		sse:        best.Cost,
+		confidence: confidence,
+		fitted:     time.Now(),
+	}
+}
+
+// count zlicza dopasowanie, nieudane, gdy err != nil
+func (m *fitMetrics) count(asset string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fits[asset]++
+	if err != nil {
+		m.failures[asset]++
+	}
+}
+
+func (m *fitMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var b strings.Builder
+	gauge := func(name, help string, value func(assetGauges) float64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		for _, asset := range slices.Sorted(maps.Keys(m.gauges)) {
+			fmt.Fprintf(&b, "%s{asset=%s} %s\n", name, strconv.Quote(asset), strconv.FormatFloat(value(m.gauges[asset]), 'g', -1, 64))
+		}
+	}
+	counter := func(name, help string, values map[string]int) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, asset := range slices.Sorted(maps.Keys(m.fits)) {
+			fmt.Fprintf(&b, "%s{asset=%s} %d\n", name, strconv.Quote(asset), values[asset])
+		}
+	}
+	gauge("lppl_tc_days_ahead", "Przewidywany czas krytyczny w dniach od ostatniej obserwacji.", func(g assetGauges) float64 { return g.tcDays })
+	gauge("lppl_fit_sse", "Suma kwadratów reszt logarytmu ceny ostatniego dopasowania.", func(g assetGauges) float64 { return g.sse })
+	gauge("lppl_bubble_confidence", "Wskaźnik pewności bańki dodatniej ostatniego dopasowania.", func(g assetGauges) float64 { return g.confidence })
+	gauge("lppl_last_fit_timestamp_seconds", "Czas uniksowy ostatniego udanego dopasowania.", func(g assetGauges) float64 { return float64(g.fitted.UnixMilli()) / 1000 })
+	counter("lppl_fits_total", "Liczba dopasowań.", m.fits)
+	counter("lppl_fit_failures_total", "Liczba nieudanych dopasowań.", m.failures)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
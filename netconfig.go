@@ -0,0 +1,188 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// netConfig to ustawienia połączeń wychodzących do dostawców danych i zasobników
+type netConfig struct {
+	// Adres serwera pośredniczącego; pusty oznacza zmienne HTTPS_PROXY, HTTP_PROXY i NO_PROXY
+	proxy string
+	// Plik PEM z dodatkowymi certyfikatami urzędów, dołączanymi do systemowych
+	caFile  string
+	timeout time.Duration
+	// Tryb offline: każde połączenie wychodzące kończy się błędem errOffline
+	offline bool
+}
+
+var errOffline = errors.New("tryb offline (-offline): połączenia sieciowe są wyłączone")
+
+// offlineTransport odrzuca wszystkie zapytania w trybie offline
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return nil, errOffline
+}
+
+func netFlags(fs *flag.FlagSet, n *netConfig) {
+	fs.StringVar(&n.proxy, "proxy", "", "serwer pośredniczący połączeń wychodzących, np. http://proxy:3128 (domyślnie ze zmiennych HTTPS_PROXY i HTTP_PROXY)")
+	fs.StringVar(&n.caFile, "ca-file", "", "plik PEM z dodatkowymi certyfikatami urzędów do weryfikacji połączeń TLS")
+	fs.DurationVar(&n.timeout, "http-timeout", 30*time.Second, "limit czasu zapytania HTTP do dostawcy danych")
+	fs.BoolVar(&n.offline, "offline", false, "nie łącz się z siecią; bez plików wejściowych analizowany jest wbudowany zbiór danych")
+}
+
+// proxyFunc, tlsConfig i offline to ustawienia zastosowane przez apply, używane także przy
+// połączeniach WebSocket
+var (
+	proxyFunc = http.ProxyFromEnvironment
+	tlsConfig *tls.Config
+	offline   bool
+)
+
+// apply ustawia serwer pośredniczący, certyfikaty i limit czasu klienta httpClient
+func (n netConfig) apply() error {
+	if n.proxy != "" {
+		u, err := url.Parse(n.proxy)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("nieprawidłowy adres -proxy %q", n.proxy)
+		}
+		proxyFunc = http.ProxyURL(u)
+	}
+	if n.caFile != "" {
+		pem, err := os.ReadFile(n.caFile)
+		if err != nil {
+			return err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("%s: brak certyfikatów PEM", n.caFile)
+		}
+		tlsConfig = &tls.Config{RootCAs: pool}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyFunc
+	transport.TLSClientConfig = tlsConfig
+	httpClient.Timeout = n.timeout
+	httpClient.Transport.(*limitedTransport).base = transport
+	if n.offline {
+		httpClient.Transport = offlineTransport{}
+		offline = true
+	}
+	return nil
+}
+
+// dialWebSocket łączy się z serwerem WebSocket (ws:// lub wss://) przez serwer
+// pośredniczący i z certyfikatami ustawionymi przez apply
+func dialWebSocket(rawURL, origin string) (*websocket.Conn, error) {
+	if offline {
+		return nil, errOffline
+	}
+	config, err := websocket.NewConfig(rawURL, origin)
+	if err != nil {
+		return nil, err
+	}
+	config.TlsConfig = tlsConfig
+	target := config.Location
+	port := target.Port()
+	if port == "" {
+		port = "80"
+		if target.Scheme == "wss" {
+			port = "443"
+		}
+	}
+	addr := net.JoinHostPort(target.Hostname(), port)
+
+	// ProxyFromEnvironment rozpoznaje tylko schematy http i https
+	probe := &http.Request{URL: &url.URL{Scheme: "https", Host: addr}}
+	if target.Scheme == "ws" {
+		probe.URL.Scheme = "http"
+	}
+	proxy, err := proxyFunc(probe)
+	if err != nil {
+		return nil, err
+	}
+
+	var conn net.Conn
+	if proxy == nil {
+		conn, err = net.DialTimeout("tcp", addr, httpClient.Timeout)
+	} else {
+		conn, err = dialThroughProxy(proxy, addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme == "wss" {
+		tc := config.TlsConfig.Clone()
+		if tc == nil {
+			tc = &tls.Config{}
+		}
+		tc.ServerName = target.Hostname()
+		tlsConn := tls.Client(conn, tc)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	ws, err := websocket.NewClient(config, conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ws, nil
+}
+
+// dialThroughProxy otwiera tunel CONNECT do addr przez serwer pośredniczący HTTP
+func dialThroughProxy(proxy *url.URL, addr string) (net.Conn, error) {
+	proxyAddr := proxy.Host
+	if proxy.Port() == "" {
+		proxyAddr = net.JoinHostPort(proxy.Hostname(), "80")
+	}
+	conn, err := net.DialTimeout("tcp", proxyAddr, httpClient.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	req := &http.Request{Method: http.MethodConnect, URL: &url.URL{Opaque: addr}, Host: addr, Header: http.Header{}}
+	if user := proxy.User; user != nil {
+		password, _ := user.Password()
+		req.SetBasicAuth(user.Username(), password)
+		req.Header["Proxy-Authorization"] = req.Header["Authorization"]
+		req.Header.Del("Authorization")
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// Treści odpowiedzi 200 nie czytamy: po niej strumień należy już do tunelu
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, errors.New("serwer pośredniczący: " + resp.Status)
+	}
+	return conn, nil
+}
@@ -0,0 +1,245 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Adresy API komunikatorów
+const (
+	telegramAPI = "https://api.telegram.org"
+	slackAPI    = "https://slack.com/api"
+)
+
+// Zmienne środowiskowe z ustawieniami powiadomień, używane, gdy flagi są puste
+const (
+	telegramTokenEnv = "TELEGRAM_BOT_TOKEN"
+	telegramChatEnv  = "TELEGRAM_CHAT_ID"
+	slackTokenEnv    = "SLACK_BOT_TOKEN"
+	slackChannelEnv  = "SLACK_CHANNEL"
+)
+
+// fitNotice to podsumowanie dopasowania wysyłane do komunikatorów
+type fitNotice struct {
+	Symbol     string
+	From, To   time.Time
+	Tc         time.Time
+	M, Omega   float64
+	Cost       float64
+	Qualified  bool
+	Confidence float64
+	// Ścieżka wykresu; pusta, gdy wykresu nie udało się zapisać
+	Chart string
+}
+
+// Text zwraca podsumowanie jako kilka wierszy zwykłego tekstu
+func (n fitNotice) Text() string {
+	filters := "nie spełnia"
+	if n.Qualified {
+		filters = "spełnia"
+	}
+	return fmt.Sprintf("LPPL %s (%s - %s)\ntc: %s (%.0f dni po ostatniej obserwacji)\nm: %.3f, omega: %.3f\nkoszt: %.6f, filtry: %s\npewność bańki: %.2f",
+		n.Symbol, n.From.Format(time.DateOnly), n.To.Format(time.DateOnly),
+		n.Tc.Format(time.DateOnly), n.Tc.Sub(n.To).Hours()/24,
+		n.M, n.Omega, n.Cost, filters, n.Confidence)
+}
+
+// notifier wysyła podsumowanie dopasowania do jednego kanału
+type notifier interface {
+	Name() string
+	Notify(n fitNotice) error
+}
+
+// notifyConfig to ustawienia komunikatorów; puste tokeny wyłączają dany kanał
+type notifyConfig struct {
+	telegramToken, telegramChat string
+	slackToken, slackChannel    string
+}
+
+func notifyFlags(fs *flag.FlagSet, c *notifyConfig) {
+	fs.StringVar(&c.telegramToken, "telegram-token", "", "token bota Telegram, który wysyła podsumowanie dopasowania z wykresem (domyślnie ze zmiennej "+telegramTokenEnv+")")
+	fs.StringVar(&c.telegramChat, "telegram-chat", "", "identyfikator czatu Telegram dla -telegram-token (domyślnie ze zmiennej "+telegramChatEnv+")")
+	fs.StringVar(&c.slackToken, "slack-token", "", "token bota Slack, który wysyła podsumowanie dopasowania z wykresem (domyślnie ze zmiennej "+slackTokenEnv+")")
+	fs.StringVar(&c.slackChannel, "slack-channel", "", "identyfikator kanału Slack dla -slack-token (domyślnie ze zmiennej "+slackChannelEnv+")")
+}
+
+// notifiers zwraca skonfigurowane kanały, uzupełniając puste flagi ze zmiennych środowiskowych
+func (c notifyConfig) notifiers() ([]notifier, error) {
+	orEnv := func(v, env string) string {
+		if v == "" {
+			return os.Getenv(env)
+		}
+		return v
+	}
+	var out []notifier
+	if token := orEnv(c.telegramToken, telegramTokenEnv); token != "" {
+		chat := orEnv(c.telegramChat, telegramChatEnv)
+		if chat == "" {
+			return nil, fmt.Errorf("brak identyfikatora czatu Telegram (-telegram-chat lub %s)", telegramChatEnv)
+		}
+		out = append(out, telegramNotifier{token: token, chat: chat})
+	}
+	if token := orEnv(c.slackToken, slackTokenEnv); token != "" {
+		channel := orEnv(c.slackChannel, slackChannelEnv)
+		if channel == "" {
+			return nil, fmt.Errorf("brak kanału Slack (-slack-channel lub %s)", slackChannelEnv)
+		}
+		out = append(out, slackNotifier{token: token, channel: channel})
+	}
+	return out, nil
+}
+
+// telegramNotifier wysyła wykres z podsumowaniem w podpisie (sendPhoto lub sendDocument)
+// albo sam tekst
+type telegramNotifier struct {
+	token, chat string
+}
+
+func (telegramNotifier) Name() string { return "Telegram" }
+
+func (t telegramNotifier) Notify(n fitNotice) error {
+	if n.Chart == "" {
+		return t.call("sendMessage", "application/x-www-form-urlencoded",
+			strings.NewReader(url.Values{"chat_id": {t.chat}, "text": {n.Text()}}.Encode()))
+	}
+	photo, err := os.Open(n.Chart)
+	if err != nil {
+		return err
+	}
+	defer photo.Close()
+	// sendPhoto przyjmuje tylko obrazy rastrowe; wykres SVG lub PDF idzie jako dokument
+	method, field := "sendPhoto", "photo"
+	switch strings.ToLower(filepath.Ext(n.Chart)) {
+	case ".png", ".jpg", ".jpeg":
+	default:
+		method, field = "sendDocument", "document"
+	}
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("chat_id", t.chat)
+	w.WriteField("caption", n.Text())
+	part, err := w.CreateFormFile(field, filepath.Base(n.Chart))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, photo); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return t.call(method, w.FormDataContentType(), &body)
+}
+
+func (t telegramNotifier) call(method, contentType string, body io.Reader) error {
+	resp, err := httpClient.Post(telegramAPI+"/bot"+t.token+"/"+method, contentType, body)
+	if err != nil {
+		// Adres zawiera token, więc nie trafia do komunikatu błędu
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	var reply struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return fmt.Errorf("%s: %s", method, resp.Status)
+	}
+	if !reply.OK {
+		return fmt.Errorf("%s: %s", method, reply.Description)
+	}
+	return nil
+}
+
+// slackNotifier wysyła wykres z podsumowaniem jako komentarzem (files.getUploadURLExternal
+// i files.completeUploadExternal) albo sam tekst (chat.postMessage)
+type slackNotifier struct {
+	token, channel string
+}
+
+func (slackNotifier) Name() string { return "Slack" }
+
+func (s slackNotifier) Notify(n fitNotice) error {
+	if n.Chart == "" {
+		return s.call("chat.postMessage", url.Values{"channel": {s.channel}, "text": {n.Text()}}, nil)
+	}
+	chart, err := os.ReadFile(n.Chart)
+	if err != nil {
+		return err
+	}
+	name := filepath.Base(n.Chart)
+	var upload struct {
+		UploadURL string `json:"upload_url"`
+		FileID    string `json:"file_id"`
+	}
+	params := url.Values{"filename": {name}, "length": {strconv.Itoa(len(chart))}}
+	if err := s.call("files.getUploadURLExternal", params, &upload); err != nil {
+		return err
+	}
+	resp, err := httpClient.Post(upload.UploadURL, "application/octet-stream", bytes.NewReader(chart))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("wysyłka wykresu: %s", resp.Status)
+	}
+	files, err := json.Marshal([]map[string]string{{"id": upload.FileID, "title": n.Symbol + " LPPL"}})
+	if err != nil {
+		return err
+	}
+	return s.call("files.completeUploadExternal", url.Values{
+		"files": {string(files)}, "channel_id": {s.channel}, "initial_comment": {n.Text()},
+	}, nil)
+}
+
+// call wywołuje metodę Web API Slacka i dekoduje odpowiedź do out (może być nil)
+func (s slackNotifier) call(method string, params url.Values, out any) error {
+	req, err := http.NewRequest(http.MethodPost, slackAPI+"/"+method, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var reply struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(content, &reply); err != nil {
+		return fmt.Errorf("%s: %s", method, resp.Status)
+	}
+	if !reply.OK {
+		return fmt.Errorf("%s: %s", method, reply.Error)
+	}
+	if out != nil {
+		return json.Unmarshal(content, out)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	"cw3/data"
+	"cw3/internal/stats"
+)
+
+// onchainAPI to publiczne API wykresów blockchain.com; nazwy metryk to np.
+// n-unique-addresses (aktywne adresy) i hash-rate
+const onchainAPI = "https://api.blockchain.info/charts/"
+
+var httpClient = &http.Client{Timeout: 30 * time.Second, Transport: &limitedTransport{base: http.DefaultTransport}}
+
+// fetchOnchain pobiera dzienne wartości metryki z przedziału [from, to]
+func fetchOnchain(metric string, from, to time.Time) ([]data.Metric, error) {
+	q := url.Values{
+		"start":    {from.Format("2006-01-02")},
+		"timespan": {fmt.Sprintf("%ddays", int(to.Sub(from).Hours()/24)+1)},
+		"format":   {"json"},
+		"sampled":  {"false"},
+	}
+	resp, err := httpClient.Get(onchainAPI + url.PathEscape(metric) + "?" + q.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metryka %s: %s", metric, resp.Status)
+	}
+
+	var body struct {
+		Values []struct {
+			X int64   `json:"x"`
+			Y float64 `json:"y"`
+		} `json:"values"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("metryka %s: %w", metric, err)
+	}
+	var metrics []data.Metric
+	for _, v := range body.Values {
+		d := time.Unix(v.X, 0).UTC()
+		if d.Before(from.Truncate(24*time.Hour)) || d.After(to) {
+			continue
+		}
+		metrics = append(metrics, data.Metric{Date: d, Value: v.Y})
+	}
+	return metrics, nil
+}
+
+// correlateOnchain liczy korelację Pearsona między log-ceną a logarytmem metryki
+// w dniach obecnych w obu szeregach
+func correlateOnchain(points []data.Point, metric []data.Metric) (r float64, n int) {
+	byDay := make(map[string]float64, len(metric))
+	for _, m := range metric {
+		if m.Value > 0 {
+			byDay[m.Date.Format("2006-01-02")] = math.Log(m.Value)
+		}
+	}
+	var x, y []float64
+	for _, d := range points {
+		if v, ok := byDay[d.Date.Format("2006-01-02")]; ok {
+			x = append(x, math.Log(d.Price))
+			y = append(y, v)
+		}
+	}
+	if len(x) < 3 {
+		return math.NaN(), len(x)
+	}
+	mx, my := stats.Mean(x), stats.Mean(y)
+	var sxy, sxx, syy float64
+	for i := range x {
+		sxy += (x[i] - mx) * (y[i] - my)
+		sxx += (x[i] - mx) * (x[i] - mx)
+		syy += (y[i] - my) * (y[i] - my)
+	}
+	return sxy / math.Sqrt(sxx*syy), len(x)
+}
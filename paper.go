@@ -0,0 +1,130 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// binanceTestnetAPI to sieć testowa rynku spot Binance; zlecenia nie angażują prawdziwych środków
+const binanceTestnetAPI = "https://testnet.binance.vision"
+
+// Zmienne środowiskowe z kluczami API sieci testowej
+const (
+	testnetKeyEnv    = "BINANCE_TESTNET_KEY"
+	testnetSecretEnv = "BINANCE_TESTNET_SECRET"
+)
+
+// paperTrader zamienia zmiany ekspozycji z sygnałów na zlecenia rynkowe w sieci testowej.
+// Notional to wartość pozycji (w walucie kwotowanej) przy pełnej ekspozycji.
+type paperTrader struct {
+	Symbol   string
+	Notional float64
+	LogPath  string
+
+	key, secret string
+}
+
+func newPaperTrader(symbol string, notional float64, logPath string) (*paperTrader, error) {
+	key, secret := os.Getenv(testnetKeyEnv), os.Getenv(testnetSecretEnv)
+	if key == "" || secret == "" {
+		return nil, fmt.Errorf("brak kluczy sieci testowej w zmiennych %s i %s", testnetKeyEnv, testnetSecretEnv)
+	}
+	if notional <= 0 {
+		return nil, errors.New("wartość pozycji musi być dodatnia")
+	}
+	return &paperTrader{Symbol: symbol, Notional: notional, LogPath: logPath, key: key, secret: secret}, nil
+}
+
+// paperFill to zapis wykonanego zlecenia w dzienniku transakcji
+type paperFill struct {
+	Time     time.Time `json:"time"`
+	Signal   string    `json:"signal"`
+	Symbol   string    `json:"symbol"`
+	Side     string    `json:"side"`
+	OrderID  int64     `json:"order_id"`
+	Quantity float64   `json:"quantity"`
+	Quote    float64   `json:"quote"`
+	Price    float64   `json:"price"`
+}
+
+// Trade wysyła zlecenie odpowiadające przejściu z ekspozycji prev do sygnału s
+// i dopisuje wykonanie do dziennika
+func (pt *paperTrader) Trade(s signal, prev float64) (paperFill, error) {
+	delta := (s.Exposure - prev) * pt.Notional
+	side := "BUY"
+	if delta < 0 {
+		side = "SELL"
+	}
+	q := url.Values{
+		"symbol":        {pt.Symbol},
+		"side":          {side},
+		"type":          {"MARKET"},
+		"quoteOrderQty": {strconv.FormatFloat(math.Abs(delta), 'f', 2, 64)},
+		"timestamp":     {strconv.FormatInt(time.Now().UnixMilli(), 10)},
+	}
+	mac := hmac.New(sha256.New, []byte(pt.secret))
+	mac.Write([]byte(q.Encode()))
+	q.Set("signature", hex.EncodeToString(mac.Sum(nil)))
+
+	req, err := http.NewRequest(http.MethodPost, binanceTestnetAPI+"/api/v3/order", strings.NewReader(q.Encode()))
+	if err != nil {
+		return paperFill{}, err
+	}
+	req.Header.Set("X-MBX-APIKEY", pt.key)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return paperFill{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return paperFill{}, fmt.Errorf("zlecenie %s %s: %s: %s", side, pt.Symbol, resp.Status, body)
+	}
+
+	var order struct {
+		OrderID     int64  `json:"orderId"`
+		ExecutedQty string `json:"executedQty"`
+		QuoteQty    string `json:"cummulativeQuoteQty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&order); err != nil {
+		return paperFill{}, err
+	}
+	fill := paperFill{Time: time.Now().UTC(), Signal: s.Kind, Symbol: pt.Symbol, Side: side, OrderID: order.OrderID}
+	fill.Quantity, _ = strconv.ParseFloat(order.ExecutedQty, 64)
+	fill.Quote, _ = strconv.ParseFloat(order.QuoteQty, 64)
+	if fill.Quantity > 0 {
+		fill.Price = fill.Quote / fill.Quantity
+	}
+	return fill, pt.record(fill)
+}
+
+// record dopisuje wykonanie jako wiersz JSON do dziennika transakcji
+func (pt *paperTrader) record(fill paperFill) error {
+	if pt.LogPath == "" {
+		return nil
+	}
+	f, err := os.OpenFile(pt.LogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(fill); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
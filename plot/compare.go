@@ -0,0 +1,88 @@
+package plot
+
+import (
+	"image/color"
+	"math"
+
+	gplot "gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+
+	"cw3/data"
+	"cw3/internal/safe"
+	"cw3/lppl"
+)
+
+// Curve to dopasowanie jednej z porównywanych konfiguracji
+type Curve struct {
+	Label  string
+	Params []float64
+}
+
+// Kolory kolejnych krzywych w porównaniu
+var curveColors = []color.Color{
+	color.RGBA{R: 255, A: 255},
+	color.RGBA{G: 160, A: 255},
+	color.RGBA{R: 160, B: 200, A: 255},
+	color.RGBA{R: 230, G: 140, A: 255},
+}
+
+// Compare rysuje dane z krzywymi modelu kilku konfiguracji, a pod nimi reszty
+// logarytmu ceny każdej z nich
+func Compare(points []data.Point, curves []Curve, meta Meta, footer, path string) error {
+	return safe.Guard("wykres porównania", func() error {
+		return drawCompare(points, curves, meta, footer, path)
+	})
+}
+
+func drawCompare(points []data.Point, curves []Curve, meta Meta, footer, path string) error {
+	timeIndex := data.TimeIndex(points)
+	pts := make(plotter.XYs, len(points))
+	for i := range points {
+		pts[i].X = timeIndex[i]
+		pts[i].Y = points[i].Price
+	}
+	scatter, err := plotter.NewScatter(pts)
+	if err != nil {
+		return err
+	}
+	scatter.GlyphStyle.Color = color.RGBA{B: 255, A: 255}
+
+	p := gplot.New()
+	p.Title.Text = meta.title(meta.Text("compare"))
+	p.Y.Label.Text = meta.priceLabel()
+	priceAxis(p)
+	p.Add(scatter)
+	p.Legend.Add(meta.Text("data"), scatter)
+
+	r := gplot.New()
+	r.Y.Label.Text = meta.Text("residual")
+	r.Add(plotter.NewGrid())
+
+	for k, c := range curves {
+		params := c.Params
+		col := curveColors[k%len(curveColors)]
+		line := plotter.NewFunction(func(x float64) float64 {
+			return math.Exp(lppl.Model(x, params[0], params[1], params[2], params[3], params[4], params[5], params[6]))
+		})
+		line.Color = col
+		p.Add(line)
+		p.Legend.Add(c.Label, line)
+
+		res := make(plotter.XYs, len(points))
+		for i, t := range timeIndex {
+			res[i].X = t
+			res[i].Y = math.Log(points[i].Price) - lppl.Model(t, params[0], params[1], params[2], params[3], params[4], params[5], params[6])
+		}
+		rl, err := plotter.NewLine(res)
+		if err != nil {
+			return err
+		}
+		rl.Color = col
+		r.Add(rl)
+	}
+	r.X.Min, r.X.Max = p.X.Min, p.X.Max
+	dateAxis(p, meta, points[0].Date)
+	dateAxis(r, meta, points[0].Date)
+
+	return savePlots([]*gplot.Plot{p, r}, plotWidth, plotHeight+panelHeight, footer, path)
+}
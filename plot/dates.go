@@ -0,0 +1,87 @@
+package plot
+
+import (
+	"time"
+
+	gplot "gonum.org/v1/plot"
+)
+
+// calendarStep to odstęp głównych podziałek osi czasu: dni albo miesiące
+type calendarStep struct {
+	days, months int
+	format       string
+}
+
+// calendarSteps to dostępne odstępy podziałek od najdrobniejszego
+var calendarSteps = []calendarStep{
+	{days: 1, format: time.DateOnly},
+	{days: 2, format: time.DateOnly},
+	{days: 7, format: time.DateOnly},
+	{days: 14, format: time.DateOnly},
+	{months: 1, format: "2006-01"},
+	{months: 2, format: "2006-01"},
+	{months: 3, format: "2006-01"},
+	{months: 6, format: "2006-01"},
+	{months: 12, format: "2006"},
+	{months: 24, format: "2006"},
+	{months: 60, format: "2006"},
+	{months: 120, format: "2006"},
+}
+
+// maxDateTicks to największa liczba opisanych podziałek osi czasu
+const maxDateTicks = 10
+
+// calendarTicks wyznacza podziałki osi X w dniach od start w pełnych dniach, poniedziałkach,
+// pierwszych dniach miesięcy lub latach
+type calendarTicks struct {
+	start time.Time
+	step  calendarStep
+}
+
+func (c calendarTicks) Ticks(min, max float64) []gplot.Tick {
+	lo, hi := dayTime(c.start, min), dayTime(c.start, max)
+	var t time.Time
+	if c.step.months > 0 {
+		t = time.Date(lo.Year(), lo.Month(), 1, 0, 0, 0, 0, lo.Location())
+		for (t.Year()*12+int(t.Month())-1)%c.step.months != 0 {
+			t = t.AddDate(0, -1, 0)
+		}
+	} else {
+		t = time.Date(lo.Year(), lo.Month(), lo.Day(), 0, 0, 0, 0, lo.Location())
+		if c.step.days == 7 || c.step.days == 14 {
+			t = t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+		}
+	}
+	var ticks []gplot.Tick
+	for ; !t.After(hi); t = t.AddDate(0, c.step.months, c.step.days) {
+		if !t.Before(lo) {
+			// TimeTicks zastępuje niepusty opis sformatowaną datą
+			ticks = append(ticks, gplot.Tick{Value: t.Sub(c.start).Hours() / 24, Label: "-"})
+		}
+	}
+	return ticks
+}
+
+// dayTime zamienia położenie na osi (dni od start) na datę
+func dayTime(start time.Time, days float64) time.Time {
+	return start.Add(time.Duration(days * 24 * float64(time.Hour)))
+}
+
+// dateAxis opisuje oś X wykresu w dniach od start datami kalendarzowymi, dobierając odstęp
+// podziałek do bieżącego zakresu osi; wywoływana po dodaniu danych
+func dateAxis(p *gplot.Plot, meta Meta, start time.Time) {
+	span := p.X.Max - p.X.Min
+	step := calendarSteps[len(calendarSteps)-1]
+	for _, s := range calendarSteps {
+		if span/(float64(s.days)+30.44*float64(s.months)) <= maxDateTicks {
+			step = s
+			break
+		}
+	}
+	p.X.Label.Text = meta.Text("date")
+	p.X.Tick.Marker = gplot.TimeTicks{
+		Ticker: calendarTicks{start: start, step: step},
+		Format: step.format,
+		Time:   func(days float64) time.Time { return dayTime(start, days) },
+	}
+}
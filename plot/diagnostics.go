@@ -0,0 +1,99 @@
+package plot
+
+import (
+	"errors"
+	"image/color"
+	"math"
+	"slices"
+
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+	gplot "gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+
+	"cw3/data"
+	"cw3/internal/safe"
+	"cw3/lppl"
+)
+
+// Diagnostics rysuje reszty ln(ceny) w czasie, ich histogram z gęstością rozkładu
+// normalnego i wykres kwantyl-kwantyl względem rozkładu normalnego. Fale reszt w czasie
+// świadczą o nieuchwyconej oscylacji, ciężkie ogony w QQ - o skokach cen.
+func Diagnostics(points []data.Point, params []float64, meta Meta, footer, path string) error {
+	return safe.Guard("diagnostyka reszt", func() error {
+		return drawDiagnostics(points, params, meta, footer, path)
+	})
+}
+
+func drawDiagnostics(points []data.Point, params []float64, meta Meta, footer, path string) error {
+	t, r := lppl.Residuals(points, params)
+	if len(r) < 3 {
+		return errors.New("za mało obserwacji przed tc do diagnostyki reszt")
+	}
+	mean, std := stat.MeanStdDev(r, nil)
+	gray := color.Gray{Y: 120}
+
+	p := gplot.New()
+	p.Title.Text = meta.title(meta.Text("diagnostics"))
+	p.Y.Label.Text = meta.Text("residual")
+	p.Add(plotter.NewGrid())
+	res := make(plotter.XYs, len(r))
+	for i := range r {
+		res[i].X, res[i].Y = t[i], r[i]
+	}
+	line, scatter, err := plotter.NewLinePoints(res)
+	if err != nil {
+		return err
+	}
+	line.Color = color.RGBA{B: 255, A: 255}
+	scatter.GlyphStyle.Color = line.Color
+	scatter.GlyphStyle.Radius = vg.Points(1.5)
+	zero := plotter.NewFunction(func(float64) float64 { return 0 })
+	zero.Color = gray
+	p.Add(line, scatter, zero)
+	dateAxis(p, meta, points[0].Date)
+
+	h := gplot.New()
+	h.X.Label.Text = meta.Text("residual")
+	h.Y.Label.Text = meta.Text("density")
+	bins := min(max(int(math.Sqrt(float64(len(r)))), 5), 50)
+	hist, err := plotter.NewHist(plotter.Values(r), bins)
+	if err != nil {
+		return err
+	}
+	hist.Normalize(1)
+	hist.FillColor = color.RGBA{R: 150, G: 180, B: 230, A: 255}
+	normal := distuv.Normal{Mu: mean, Sigma: std}
+	density := plotter.NewFunction(normal.Prob)
+	density.Color = color.RGBA{R: 255, A: 255}
+	h.Add(hist, density)
+	h.Legend.Add(meta.Text("normal"), density)
+	h.Legend.Top = true
+
+	q := gplot.New()
+	q.X.Label.Text = meta.Text("normalQuantile")
+	q.Y.Label.Text = meta.Text("standardResidual")
+	q.Add(plotter.NewGrid())
+	z := make([]float64, len(r))
+	for i, v := range r {
+		z[i] = (v - mean) / std
+	}
+	slices.Sort(z)
+	qq := make(plotter.XYs, len(z))
+	for i := range z {
+		qq[i].X = distuv.UnitNormal.Quantile((float64(i) + 0.5) / float64(len(z)))
+		qq[i].Y = z[i]
+	}
+	quantiles, err := plotter.NewScatter(qq)
+	if err != nil {
+		return err
+	}
+	quantiles.GlyphStyle.Color = line.Color
+	quantiles.GlyphStyle.Radius = vg.Points(1.5)
+	diagonal := plotter.NewFunction(func(x float64) float64 { return x })
+	diagonal.Color = color.RGBA{R: 255, A: 255}
+	q.Add(quantiles, diagonal)
+
+	return savePlots([]*gplot.Plot{p, h, q}, 8*vg.Inch, 12*vg.Inch, footer, path)
+}
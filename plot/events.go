@@ -0,0 +1,100 @@
+package plot
+
+import (
+	"encoding/csv"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	gplot "gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+
+	"cw3/data"
+)
+
+// Event to zewnętrzne wydarzenie zaznaczane na wykresie, np. zatwierdzenie ETF
+type Event struct {
+	Date  time.Time
+	Label string
+}
+
+// LoadEvents wczytuje wydarzenia z pliku CSV o kolumnach data,opis; daty w formatach
+// data.DefaultDateLayouts, wiersz nagłówka z niepoprawną datą jest pomijany
+func LoadEvents(path string) ([]Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = 2
+	var events []Event
+	var layout string
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		date, l, err := data.ParseDate(strings.TrimSpace(record[0]), data.DefaultDateLayouts, layout)
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		layout = l
+		events = append(events, Event{Date: date, Label: strings.TrimSpace(record[1])})
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Date.Before(events[j].Date) })
+	return events, nil
+}
+
+// eventMarkers rysuje wydarzenia jako pionowe przerywane linie z opisem u góry wykresu;
+// położenie na osi X to dni od start, jak na wykresie modelu
+type eventMarkers struct {
+	start  time.Time
+	events []Event
+}
+
+func (m eventMarkers) Plot(c draw.Canvas, plt *gplot.Plot) {
+	trX, _ := plt.Transforms(&c)
+	line := draw.LineStyle{
+		Color:  color.RGBA{R: 128, G: 64, B: 160, A: 255},
+		Width:  vg.Points(1),
+		Dashes: []vg.Length{vg.Points(4), vg.Points(2)},
+	}
+	sty := draw.TextStyle{
+		Color:   line.Color,
+		Font:    font.From(gplot.DefaultFont, 9),
+		Handler: gplot.DefaultTextHandler,
+		XAlign:  draw.XLeft,
+		YAlign:  draw.YTop,
+	}
+	for i, e := range m.events {
+		x := e.Date.Sub(m.start).Hours() / 24
+		if x < plt.X.Min || x > plt.X.Max {
+			continue
+		}
+		px := trX(x)
+		c.StrokeLine2(line, px, c.Min.Y, px, c.Max.Y)
+		// Kolejne opisy są przesuwane w dół, żeby bliskie wydarzenia się nie nakładały
+		offset := vg.Length(i%4) * sty.Font.Size * 1.3
+		// Opis, który nie zmieściłby się na prawo od linii, trafia na jej lewą stronę
+		label := sty
+		at := px + vg.Points(2)
+		if at+sty.Width(e.Label) > c.Max.X {
+			label.XAlign, at = draw.XRight, px-vg.Points(2)
+		}
+		c.FillText(label, vg.Point{X: at, Y: c.Max.Y - offset}, e.Label)
+	}
+}
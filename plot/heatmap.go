@@ -0,0 +1,34 @@
+package plot
+
+import (
+	"fmt"
+	"image/color"
+
+	gplot "gonum.org/v1/plot"
+	"gonum.org/v1/plot/palette/moreland"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+
+	"cw3/fit"
+	"cw3/internal/safe"
+)
+
+// Heatmap rysuje mapę stabilności parametru o nazwie param po siatce (t1, t2)
+func Heatmap(grid fit.Grid, param string, meta Meta, footer, path string) error {
+	return safe.Guard("mapa stabilności", func() error {
+		return drawHeatmap(grid, param, meta, footer, path)
+	})
+}
+
+func drawHeatmap(grid fit.Grid, param string, meta Meta, footer, path string) error {
+	p := gplot.New()
+	p.Title.Text = meta.title(fmt.Sprintf(meta.Text("stability"), param))
+	p.X.Label.Text = meta.daysLabel("windowStart")
+	p.Y.Label.Text = meta.daysLabel("windowEnd")
+
+	h := plotter.NewHeatMap(grid, moreland.SmoothBlueRed().Palette(255))
+	h.NaN = color.Transparent
+	p.Add(h)
+
+	return savePlots([]*gplot.Plot{p}, 8*vg.Inch, 6*vg.Inch, footer, path)
+}
@@ -0,0 +1,253 @@
+package plot
+
+import (
+	"html/template"
+	"math"
+	"time"
+
+	"cw3/data"
+	"cw3/internal/atomicfile"
+	"cw3/internal/safe"
+	"cw3/lppl"
+)
+
+// htmlTail to liczba punktów krzywej modelu między ostatnią obserwacją a tc
+const htmlTail = 400
+
+// htmlSeries to dane wykresu HTML; czasy w milisekundach od epoki Uniksa
+type htmlSeries struct {
+	Title      string      `json:"title"`
+	PriceLabel string      `json:"priceLabel"`
+	DataLabel  string      `json:"dataLabel"`
+	ModelLabel string      `json:"modelLabel"`
+	Footer     string      `json:"footer"`
+	Time       []int64     `json:"time"`
+	Price      []*float64  `json:"price"`
+	Model      []*float64  `json:"model"`
+	Tc         int64       `json:"tc"`
+	Events     []htmlEvent `json:"events"`
+}
+
+type htmlEvent struct {
+	Time  int64  `json:"time"`
+	Label string `json:"label"`
+}
+
+// HTML zapisuje interaktywny wykres danych i krzywej modelu jako samodzielną stronę:
+// przybliżanie kółkiem myszy, przesuwanie przeciąganiem, podpowiedź z datą, ceną
+// i wartością modelu oraz przełącznik krzywej. Model jest ekstrapolowany do tc.
+func HTML(points []data.Point, params []float64, extras Extras, path string) error {
+	return safe.Guard("wykres HTML", func() error {
+		return writeHTML(points, params, extras, path)
+	})
+}
+
+func writeHTML(points []data.Point, params []float64, extras Extras, path string) error {
+	meta := extras.Meta
+	p := params
+	model := func(t float64) *float64 {
+		v := math.Exp(lppl.Model(t, p[0], p[1], p[2], p[3], p[4], p[5], p[6]))
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+		return &v
+	}
+	start := points[0].Date
+	at := func(t float64) int64 {
+		return start.Add(time.Duration(t * 24 * float64(time.Hour))).UnixMilli()
+	}
+	s := htmlSeries{
+		Title:      meta.title(meta.Text("model")),
+		PriceLabel: meta.priceLabel(),
+		DataLabel:  meta.Text("data"),
+		ModelLabel: meta.Text("model"),
+		Footer:     extras.Footer,
+		Tc:         at(p[0]),
+	}
+	timeIndex := data.TimeIndex(points)
+	for i, point := range points {
+		price := point.Price
+		s.Time = append(s.Time, point.Date.UnixMilli())
+		s.Price = append(s.Price, &price)
+		s.Model = append(s.Model, model(timeIndex[i]))
+	}
+	if last := timeIndex[len(timeIndex)-1]; p[0] > last {
+		step := (p[0] - last) / htmlTail
+		// Ostatni punkt leży tuż przed tc, gdzie człon (tc-t)^m jest jeszcze określony
+		for i := 1; i < htmlTail; i++ {
+			t := last + step*float64(i)
+			s.Time = append(s.Time, at(t))
+			s.Price = append(s.Price, nil)
+			s.Model = append(s.Model, model(t))
+		}
+	}
+	end := points[len(points)-1].Date
+	for _, e := range extras.Events {
+		if !e.Date.Before(start) && !e.Date.After(end) {
+			s.Events = append(s.Events, htmlEvent{Time: e.Date.UnixMilli(), Label: e.Label})
+		}
+	}
+
+	f, err := atomicfile.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+	if err := htmlPage.Execute(f, s); err != nil {
+		return err
+	}
+	return f.Commit()
+}
+
+var htmlPage = template.Must(template.New("chart").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Title}}</title>
+<style>
+body{font-family:sans-serif;margin:1.5em}
+#chart{position:relative;width:100%;max-width:1200px}
+svg{width:100%;height:560px;cursor:crosshair;user-select:none}
+#tip{position:absolute;display:none;pointer-events:none;background:#fff;border:1px solid #999;padding:.3em .5em;font-size:13px;white-space:nowrap}
+.axis{font-size:12px;fill:#333}
+footer{color:#666;font-size:12px;margin-top:.5em}
+</style></head><body>
+<h2>{{.Title}}</h2>
+<p><label><input type="checkbox" id="showModel" checked> {{.ModelLabel}}</label>
+<button id="reset">Reset</button>
+<small>kółko myszy: przybliżenie, przeciąganie: przesunięcie, dwuklik: widok całości</small></p>
+<div id="chart"><svg id="svg"></svg><div id="tip"></div></div>
+{{if .Footer}}<footer>{{.Footer}}</footer>{{end}}
+<script>
+const S = {{.}};
+const svg = document.getElementById("svg"), tip = document.getElementById("tip");
+const showModel = document.getElementById("showModel");
+const NS = "http://www.w3.org/2000/svg", M = {l: 70, r: 20, t: 10, b: 30};
+const tMin = S.time[0], tMax = Math.max(S.time[S.time.length - 1], S.tc);
+let x0 = tMin, x1 = tMax, drag = null;
+
+function el(name, attrs, parent) {
+  const e = document.createElementNS(NS, name);
+  for (const k in attrs) e.setAttribute(k, attrs[k]);
+  (parent || svg).appendChild(e);
+  return e;
+}
+function day(t) { return new Date(t).toISOString().slice(0, 10); }
+function num(v) { return v == null ? "-" : v.toPrecision(6); }
+function size() { const r = svg.getBoundingClientRect(); return [r.width, r.height]; }
+// Pierwszy indeks o czasie >= t
+function lower(t) {
+  let lo = 0, hi = S.time.length;
+  while (lo < hi) { const mid = (lo + hi) >> 1; if (S.time[mid] < t) lo = mid + 1; else hi = mid; }
+  return lo;
+}
+function ticks(lo, hi, n) {
+  const step0 = (hi - lo) / n, mag = Math.pow(10, Math.floor(Math.log10(step0)));
+  const step = [1, 2, 5, 10].map(f => f * mag).find(s => s >= step0);
+  const out = [];
+  for (let v = Math.ceil(lo / step) * step; v <= hi; v += step) out.push(v);
+  return out;
+}
+
+function draw() {
+  svg.replaceChildren();
+  const [w, h] = size(), pw = w - M.l - M.r, ph = h - M.t - M.b;
+  const i0 = Math.max(0, lower(x0) - 1), i1 = Math.min(S.time.length, lower(x1) + 1);
+  let y0 = Infinity, y1 = -Infinity;
+  for (let i = i0; i < i1; i++) {
+    for (const v of [S.price[i], showModel.checked ? S.model[i] : null]) {
+      if (v != null) { y0 = Math.min(y0, v); y1 = Math.max(y1, v); }
+    }
+  }
+  if (!isFinite(y0)) { y0 = 0; y1 = 1; }
+  const pad = (y1 - y0) * 0.05 || 1;
+  y0 -= pad; y1 += pad;
+  const X = t => M.l + (t - x0) / (x1 - x0) * pw, Y = v => M.t + (y1 - v) / (y1 - y0) * ph;
+
+  const clip = el("clipPath", {id: "area"});
+  el("rect", {x: M.l, y: M.t, width: pw, height: ph}, clip);
+  el("rect", {x: M.l, y: M.t, width: pw, height: ph, fill: "none", stroke: "#999"});
+  for (const v of ticks(y0, y1, 6)) {
+    el("line", {x1: M.l, x2: M.l + pw, y1: Y(v), y2: Y(v), stroke: "#eee"});
+    el("text", {x: M.l - 5, y: Y(v) + 4, "text-anchor": "end", class: "axis"}).textContent = +v.toPrecision(6);
+  }
+  const dayMs = 864e5;
+  for (const d of ticks(x0 / dayMs, x1 / dayMs, 8)) {
+    el("text", {x: X(d * dayMs), y: h - 10, "text-anchor": "middle", class: "axis"}).textContent = day(d * dayMs);
+  }
+  el("text", {transform: "rotate(-90)", x: -(M.t + ph / 2), y: 14, "text-anchor": "middle", class: "axis"}).textContent = S.priceLabel;
+
+  const g = el("g", {"clip-path": "url(#area)"});
+  for (const e of (S.events || [])) {
+    const line = el("line", {x1: X(e.time), x2: X(e.time), y1: M.t, y2: M.t + ph, stroke: "#c80", "stroke-width": 3, "stroke-dasharray": "2 3"}, g);
+    el("title", {}, line).textContent = day(e.time) + ": " + e.label;
+  }
+  el("line", {x1: X(S.tc), x2: X(S.tc), y1: M.t, y2: M.t + ph, stroke: "#900", "stroke-dasharray": "6 4"}, g);
+  el("text", {x: X(S.tc) + 4, y: M.t + 14, fill: "#900", class: "axis"}, g).textContent = "tc " + day(S.tc);
+  let dots = "";
+  for (let i = i0; i < i1; i++) {
+    if (S.price[i] != null) dots += "M" + X(S.time[i]).toFixed(1) + " " + Y(S.price[i]).toFixed(1) + "h0.1";
+  }
+  el("path", {d: dots, stroke: "#00f", "stroke-width": 4, "stroke-linecap": "round", fill: "none"}, g);
+  if (showModel.checked) {
+    let line = "", pen = "M";
+    for (let i = i0; i < i1; i++) {
+      if (S.model[i] == null) { pen = "M"; continue; }
+      line += pen + X(S.time[i]).toFixed(1) + " " + Y(S.model[i]).toFixed(1);
+      pen = "L";
+    }
+    el("path", {d: line, stroke: "#f00", "stroke-width": 1.5, fill: "none"}, g);
+  }
+  svg.view = {X, Y, pw, ph};
+}
+
+function hover(ev) {
+  const r = svg.getBoundingClientRect(), px = ev.clientX - r.left;
+  const {X, Y, ph} = svg.view;
+  if (px < M.l || px > r.width - M.r) { tip.style.display = "none"; return; }
+  const t = x0 + (px - M.l) / (r.width - M.l - M.r) * (x1 - x0);
+  let i = Math.min(lower(t), S.time.length - 1);
+  if (i > 0 && t - S.time[i - 1] < S.time[i] - t) i--;
+  let html = "<b>" + day(S.time[i]) + "</b>";
+  if (S.price[i] != null) html += "<br>" + S.dataLabel + ": " + num(S.price[i]);
+  if (showModel.checked) html += "<br>" + S.modelLabel + ": " + num(S.model[i]);
+  tip.innerHTML = html;
+  tip.style.display = "block";
+  tip.style.left = (X(S.time[i]) + 12) + "px";
+  tip.style.top = (Y(S.price[i] != null ? S.price[i] : S.model[i] != null ? S.model[i] : 0) + 12) + "px";
+  const old = document.getElementById("cursor");
+  if (old) old.remove();
+  el("line", {id: "cursor", x1: X(S.time[i]), x2: X(S.time[i]), y1: M.t, y2: M.t + ph, stroke: "#888"});
+}
+
+function zoomTo(a, b) {
+  const pad = (tMax - tMin) * 0.05, lo = tMin - pad, hi = tMax + pad;
+  const span = Math.min(Math.max(b - a, 864e5), hi - lo);
+  a = Math.min(Math.max((a + b - span) / 2, lo), hi - span);
+  x0 = a; x1 = a + span;
+  draw();
+}
+
+svg.addEventListener("wheel", ev => {
+  ev.preventDefault();
+  const r = svg.getBoundingClientRect();
+  const c = x0 + (ev.clientX - r.left - M.l) / (r.width - M.l - M.r) * (x1 - x0);
+  const f = ev.deltaY < 0 ? 0.8 : 1.25;
+  zoomTo(c - (c - x0) * f, c + (x1 - c) * f);
+}, {passive: false});
+svg.addEventListener("mousedown", ev => { drag = {x: ev.clientX, x0, x1}; });
+window.addEventListener("mouseup", () => { drag = null; });
+svg.addEventListener("mousemove", ev => {
+  if (drag) {
+    const r = svg.getBoundingClientRect();
+    const dt = (ev.clientX - drag.x) / (r.width - M.l - M.r) * (drag.x1 - drag.x0);
+    zoomTo(drag.x0 - dt, drag.x1 - dt);
+  }
+  hover(ev);
+});
+svg.addEventListener("mouseleave", () => { tip.style.display = "none"; });
+svg.addEventListener("dblclick", () => zoomTo(tMin, tMax));
+document.getElementById("reset").addEventListener("click", () => zoomTo(tMin, tMax));
+showModel.addEventListener("change", draw);
+window.addEventListener("resize", draw);
+draw();
+</script>
+</body></html>
+`))
@@ -0,0 +1,141 @@
+package plot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Meta opisuje szereg na wykresie; z niej powstają tytuły, opisy osi i legenda
+type Meta struct {
+	Symbol   string
+	Source   string
+	Currency string
+	// Zakres dat okna przedstawionego na wykresie
+	Start, End time.Time
+	// Język opisów (klucz Languages); pusty oznacza polski
+	Lang string
+	// Okres, w którego pieniądzu wyrażone są ceny realne; zerowy dla cen nominalnych
+	RealBase time.Time
+}
+
+const defaultLang = "pl"
+
+// Languages zawiera opisy wykresów w dostępnych językach
+var Languages = map[string]map[string]string{
+	"pl": {
+		"model":              "Model LPPL",
+		"compare":            "Porównanie konfiguracji LPPL",
+		"stability":          "Stabilność parametru %s",
+		"spectrumTime":       "Periodogram reszt - czas liniowy",
+		"spectrumLogTime":    "Periodogram reszt - czas ln(tc-t)",
+		"metric":             "Metryka sieci: %s",
+		"days":               "Dni od początku",
+		"date":               "Data",
+		"windowStart":        "Początek okna (dni od %s)",
+		"windowEnd":          "Koniec okna (dni od %s)",
+		"price":              "Cena",
+		"realPrice":          "Cena realna",
+		"residual":           "Reszta ln(ceny)",
+		"frequency":          "Częstość (cykle/dzień)",
+		"angularFrequency":   "Częstość kątowa omega",
+		"power":              "Moc znormalizowana",
+		"data":               "Dane",
+		"rawData":            "Dane surowe",
+		"smoothedData":       "Dane wygładzone",
+		"funding":            "Finansowanie (%)",
+		"openInterest":       "Otwarte pozycje (mld USD)",
+		"positiveConfidence": "Pewność bańki dodatniej",
+		"negativeConfidence": "Pewność bańki ujemnej",
+		"diagnostics":        "Diagnostyka reszt",
+		"density":            "Gęstość",
+		"normal":             "Rozkład normalny",
+		"normalQuantile":     "Kwantyl rozkładu normalnego",
+		"standardResidual":   "Reszta standaryzowana",
+	},
+	"en": {
+		"model":              "LPPL model",
+		"compare":            "LPPL configuration comparison",
+		"stability":          "Stability of parameter %s",
+		"spectrumTime":       "Residual periodogram - linear time",
+		"spectrumLogTime":    "Residual periodogram - ln(tc-t) time",
+		"metric":             "Network metric: %s",
+		"days":               "Days from start",
+		"date":               "Date",
+		"windowStart":        "Window start (days since %s)",
+		"windowEnd":          "Window end (days since %s)",
+		"price":              "Price",
+		"realPrice":          "Real price",
+		"residual":           "ln(price) residual",
+		"frequency":          "Frequency (cycles/day)",
+		"angularFrequency":   "Angular frequency omega",
+		"power":              "Normalized power",
+		"data":               "Data",
+		"rawData":            "Raw data",
+		"smoothedData":       "Smoothed data",
+		"funding":            "Funding rate (%)",
+		"openInterest":       "Open interest (USD bn)",
+		"positiveConfidence": "Positive bubble confidence",
+		"negativeConfidence": "Negative bubble confidence",
+		"diagnostics":        "Residual diagnostics",
+		"density":            "Density",
+		"normal":             "Normal distribution",
+		"normalQuantile":     "Normal quantile",
+		"standardResidual":   "Standardized residual",
+	},
+}
+
+// LanguageNames zwraca posortowane kody dostępnych języków, np. do opisu flagi
+func LanguageNames() string {
+	var names []string
+	for name := range Languages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// Text zwraca opis o danym kluczu w języku m.Lang
+func (m Meta) Text(key string) string {
+	lang := m.Lang
+	if _, ok := Languages[lang]; !ok {
+		lang = defaultLang
+	}
+	return Languages[lang][key]
+}
+
+// title dopisuje do nazwy wykresu symbol, źródło i zakres dat, o ile są znane
+func (m Meta) title(name string) string {
+	var parts []string
+	if m.Source != "" && m.Source != m.Symbol {
+		parts = append(parts, m.Source)
+	}
+	if !m.Start.IsZero() {
+		parts = append(parts, m.Start.Format(time.DateOnly)+" - "+m.End.Format(time.DateOnly))
+	}
+	if m.Symbol != "" {
+		name += " - " + m.Symbol
+	}
+	if len(parts) > 0 {
+		name += " (" + strings.Join(parts, ", ") + ")"
+	}
+	return name
+}
+
+func (m Meta) daysLabel(key string) string {
+	if m.Start.IsZero() {
+		return m.Text("days")
+	}
+	return fmt.Sprintf(m.Text(key), m.Start.Format(time.DateOnly))
+}
+
+func (m Meta) priceLabel() string {
+	if !m.RealBase.IsZero() {
+		return m.Text("realPrice") + " (" + strings.TrimSpace(m.RealBase.Format("2006-01")+" "+m.Currency) + ")"
+	}
+	if m.Currency == "" {
+		return m.Text("price")
+	}
+	return m.Text("price") + " (" + m.Currency + ")"
+}
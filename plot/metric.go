@@ -0,0 +1,38 @@
+package plot
+
+import (
+	"fmt"
+	"image/color"
+
+	gplot "gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+
+	"cw3/data"
+	"cw3/internal/safe"
+)
+
+// Metric rysuje metrykę w dniach od początku okna dopasowania, w tej samej skali
+// osi X co wykres modelu
+func Metric(points []data.Point, metric []data.Metric, name string, meta Meta, footer, path string) error {
+	return safe.Guard("wykres metryki", func() error {
+		p := gplot.New()
+		p.Title.Text = meta.title(fmt.Sprintf(meta.Text("metric"), name))
+		p.Y.Label.Text = name
+
+		start := points[0].Date
+		pts := make(plotter.XYs, len(metric))
+		for i, m := range metric {
+			pts[i].X = m.Date.Sub(start).Hours() / 24
+			pts[i].Y = m.Value
+		}
+		line, err := plotter.NewLine(pts)
+		if err != nil {
+			return err
+		}
+		line.Color = color.RGBA{G: 128, A: 255}
+		p.Add(line)
+		dateAxis(p, meta, start)
+		return savePlots([]*gplot.Plot{p}, 10*vg.Inch, 3*vg.Inch, footer, path)
+	})
+}
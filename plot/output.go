@@ -0,0 +1,100 @@
+package plot
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	gplot "gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+)
+
+// OutputOptions to ustawienia zapisu wszystkich wykresów
+type OutputOptions struct {
+	// Format pliku (png, svg, pdf, ...); pusty oznacza format z rozszerzenia ścieżki
+	Format string
+	// Wymiary wykresu w calach; zero oznacza wymiar domyślny danego wykresu
+	Width, Height float64
+	// Rozdzielczość formatów rastrowych (png, jpg, tif); zero oznacza 96 DPI
+	DPI int
+	// Logarytmiczna oś cen na wykresach dopasowania i porównania; model jest dopasowywany
+	// do ln(ceny), więc w tej skali oscylacje mają niezniekształconą amplitudę
+	LogScale bool
+}
+
+// Output to ustawienia zapisu używane przez wszystkie funkcje rysujące
+var Output OutputOptions
+
+// rasterFormats to formaty zapisywane jako obraz, dla których ma znaczenie DPI
+var rasterFormats = map[string]bool{"png": true, "jpg": true, "jpeg": true, "tif": true, "tiff": true}
+
+// CheckFormat sprawdza, czy format jest obsługiwany
+func CheckFormat(format string) error {
+	if _, err := draw.NewFormattedCanvas(vg.Inch, vg.Inch, format); err != nil {
+		return fmt.Errorf("nieobsługiwany format wykresu %q (dostępne: png, jpg, tif, svg, pdf, eps, tex)", format)
+	}
+	return nil
+}
+
+// Path zwraca ścieżkę, pod którą trafi wykres: z Output.Format rozszerzenie jest zmieniane
+// na nazwę formatu
+func Path(path string) string {
+	if Output.Format == "" || path == "" {
+		return path
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path)) + "." + Output.Format
+}
+
+// newCanvas tworzy płótno w formacie wynikającym z Output albo z rozszerzenia path
+func newCanvas(width, height vg.Length, path string) (vg.CanvasWriterTo, error) {
+	format := Output.Format
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	if Output.DPI > 0 && rasterFormats[format] {
+		c := vgimg.NewWith(vgimg.UseWH(width, height), vgimg.UseDPI(Output.DPI))
+		switch format {
+		case "png":
+			return vgimg.PngCanvas{Canvas: c}, nil
+		case "jpg", "jpeg":
+			return vgimg.JpegCanvas{Canvas: c}, nil
+		default:
+			return vgimg.TiffCanvas{Canvas: c}, nil
+		}
+	}
+	return draw.NewFormattedCanvas(width, height, format)
+}
+
+// priceAxis przełącza oś cen na skalę logarytmiczną, gdy ustawiono Output.LogScale
+func priceAxis(p *gplot.Plot) {
+	if Output.LogScale {
+		p.Y.Scale = gplot.LogScale{}
+		p.Y.Tick.Marker = priceTicks{}
+	}
+}
+
+// priceTicks to podziałki logarytmicznej osi cen: przy wąskim zakresie zwykłe podziałki
+// liniowe, przy szerszym potęgi dziesięciu, do trzech rzędów wielkości opisane także
+// wielokrotności 2 i 5
+type priceTicks struct{}
+
+func (priceTicks) Ticks(min, max float64) []gplot.Tick {
+	ratio := max / min
+	if ratio < 10 {
+		return gplot.DefaultTicks{}.Ticks(min, max)
+	}
+	ticks := gplot.LogTicks{Prec: -1}.Ticks(min, max)
+	if ratio < 1000 {
+		for i, t := range ticks {
+			switch math.Round(t.Value / math.Pow10(int(math.Floor(math.Log10(t.Value))))) {
+			case 2, 5:
+				ticks[i].Label = strconv.FormatFloat(t.Value, 'g', -1, 64)
+			}
+		}
+	}
+	return ticks
+}
@@ -0,0 +1,115 @@
+package plot
+
+import (
+	"image/color"
+	"time"
+
+	gplot "gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+
+	"cw3/data"
+	"cw3/internal/atomicfile"
+)
+
+// Panel to dodatkowy wykres pod wykresem modelu, ze wspólną osią czasu
+type Panel struct {
+	Title  string
+	Points []data.Metric
+	// Stały zakres osi Y (np. [0, 1] dla udziałów); równe wartości oznaczają zakres automatyczny
+	YMin, YMax float64
+}
+
+// Wymiary wykresu modelu, każdego dodatkowego panelu i stopki z pochodzeniem wyniku
+const (
+	plotWidth    = 10 * vg.Inch
+	plotHeight   = 6 * vg.Inch
+	panelHeight  = 2.5 * vg.Inch
+	footerHeight = 0.25 * vg.Inch
+)
+
+// panelPlots buduje wykresy paneli w dniach od start, ze wspólnym zakresem i opisem osi X z p
+func panelPlots(p *gplot.Plot, start time.Time, panels []Panel) ([]*gplot.Plot, error) {
+	var plots []*gplot.Plot
+	for _, pn := range panels {
+		pp := gplot.New()
+		pp.Y.Label.Text = pn.Title
+		pts := make(plotter.XYs, len(pn.Points))
+		for i, m := range pn.Points {
+			pts[i].X = m.Date.Sub(start).Hours() / 24
+			pts[i].Y = m.Value
+		}
+		line, err := plotter.NewLine(pts)
+		if err != nil {
+			return nil, err
+		}
+		line.Color = color.RGBA{G: 128, A: 255}
+		pp.Add(line)
+		pp.X.Min, pp.X.Max = p.X.Min, p.X.Max
+		pp.X.Label.Text, pp.X.Tick.Marker = p.X.Label.Text, p.X.Tick.Marker
+		if pn.YMin < pn.YMax {
+			pp.Y.Min, pp.Y.Max = pn.YMin, pn.YMax
+		}
+		plots = append(plots, pp)
+	}
+	return plots, nil
+}
+
+// savePlots zapisuje wykresy ułożone jeden pod drugim (z wyrównanymi osiami) w formacie
+// z Output lub z rozszerzenia pliku; niepusta stopka jest dopisywana pod nimi
+func savePlots(plots []*gplot.Plot, width, height vg.Length, footer, path string) error {
+	if Output.Width > 0 {
+		width = vg.Length(Output.Width) * vg.Inch
+	}
+	if Output.Height > 0 {
+		height = vg.Length(Output.Height) * vg.Inch
+	}
+	total := height
+	if footer != "" {
+		total += footerHeight
+	}
+	path = Path(path)
+	c, err := newCanvas(width, total, path)
+	if err != nil {
+		return err
+	}
+	dc := draw.New(c)
+
+	area := dc
+	if footer != "" {
+		area = draw.Crop(dc, 0, 0, footerHeight, 0)
+		sty := draw.TextStyle{
+			Color:   color.Gray{Y: 96},
+			Font:    font.From(gplot.DefaultFont, 8),
+			Handler: gplot.DefaultTextHandler,
+			XAlign:  draw.XLeft,
+			YAlign:  draw.YCenter,
+		}
+		dc.FillText(sty, vg.Point{X: dc.Min.X + vg.Millimeter, Y: dc.Min.Y + footerHeight/2}, footer)
+	}
+
+	if len(plots) == 1 {
+		plots[0].Draw(area)
+	} else {
+		grid := make([][]*gplot.Plot, len(plots))
+		for i, p := range plots {
+			grid[i] = []*gplot.Plot{p}
+		}
+		canvases := gplot.Align(grid, draw.Tiles{Rows: len(plots), Cols: 1}, area)
+		for i := range grid {
+			grid[i][0].Draw(canvases[i][0])
+		}
+	}
+
+	f, err := atomicfile.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+	if _, err := c.WriteTo(f); err != nil {
+		return err
+	}
+	return f.Commit()
+}
@@ -0,0 +1,123 @@
+// Package plot rysuje wykresy dopasowań, map stabilności, widm reszt i metryk pomocniczych.
+package plot
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	gplot "gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+
+	"cw3/data"
+	"cw3/internal/safe"
+	"cw3/lppl"
+)
+
+// Extras to opcjonalne dodatki wykresu modelu
+type Extras struct {
+	// Ceny przed wygładzeniem lub usunięciem sezonowości (nil dla surowych danych)
+	Raw []data.Point
+	// Panele dokładane pod wykresem modelu
+	Panels []Panel
+	// Stopka z pochodzeniem wyniku
+	Footer string
+	// Zewnętrzne wydarzenia zaznaczane na wykresie
+	Events []Event
+	// Opis szeregu do tytułu, osi i legendy
+	Meta Meta
+}
+
+// Results rysuje dane i krzywą modelu
+func Results(points []data.Point, params []float64, extras Extras, path string) error {
+	return safe.Guard("wykres", func() error {
+		return drawResults(points, params, extras, path)
+	})
+}
+
+func drawResults(points []data.Point, params []float64, extras Extras, path string) error {
+	raw, meta := extras.Raw, extras.Meta
+	p := gplot.New()
+	p.Title.Text = meta.title(meta.Text("model"))
+	p.Y.Label.Text = meta.priceLabel()
+	priceAxis(p)
+
+	// Dane rzeczywiste
+	pts := make(plotter.XYs, len(points))
+	timeIndex := data.TimeIndex(points)
+	for i := range points {
+		pts[i].X = timeIndex[i]
+		pts[i].Y = points[i].Price
+	}
+
+	scatter, err := plotter.NewScatter(pts)
+	if err != nil {
+		return err
+	}
+	scatter.GlyphStyle.Color = color.RGBA{B: 255, A: 255}
+
+	// Krzywa modelu
+	tc := params[0]
+	modelFunc := func(x float64) float64 {
+		return math.Exp(lppl.Model(x, tc, params[1], params[2], params[3], params[4], params[5], params[6]))
+	}
+	line := plotter.NewFunction(modelFunc)
+	line.Samples = 1000
+
+	line.Color = color.RGBA{R: 255, A: 255}
+
+	if raw != nil {
+		rawPts := make(plotter.XYs, len(raw))
+		for i := range raw {
+			rawPts[i].X = timeIndex[i]
+			rawPts[i].Y = raw[i].Price
+		}
+		rawScatter, err := plotter.NewScatter(rawPts)
+		if err != nil {
+			return err
+		}
+		rawScatter.GlyphStyle.Color = color.Gray{Y: 160}
+		p.Add(rawScatter)
+		p.Legend.Add(meta.Text("rawData"), rawScatter)
+	}
+
+	if len(extras.Events) > 0 {
+		p.Add(eventMarkers{start: points[0].Date, events: extras.Events})
+	}
+
+	label := meta.Text("data")
+	if raw != nil {
+		label = meta.Text("smoothedData")
+	}
+	p.Add(scatter, line)
+	p.Legend.Add(label, scatter)
+	p.Legend.Add(meta.Text("model"), line)
+	// Oś czasu sięga do przewidywanej daty krytycznej, żeby widać było ekstrapolację modelu
+	// (z zapasem na opis tc); oś cen obejmuje ekstrapolację, ale najwyżej podwaja swój zakres,
+	// żeby przy stromym końcu krzywej dane nie zlały się w linię
+	if last := timeIndex[len(timeIndex)-1]; tc > last {
+		line.XMin, line.XMax = p.X.Min, tc
+		p.X.Max = math.Max(p.X.Max, tc+0.03*(tc-timeIndex[0]))
+		lo, hi := p.Y.Min, p.Y.Max
+		floor, ceil := lo-(hi-lo), hi+(hi-lo)
+		if Output.LogScale {
+			floor, ceil = lo*lo/hi, hi*hi/lo
+		}
+		for i := 0; i <= 200; i++ {
+			y := modelFunc(last + (tc-last)*float64(i)/200)
+			p.Y.Min = math.Max(math.Min(p.Y.Min, y), floor)
+			p.Y.Max = math.Min(math.Max(p.Y.Max, y), ceil)
+		}
+		p.Legend.Top, p.Legend.Left = true, true
+	}
+	p.Add(eventMarkers{start: points[0].Date, events: []Event{{Date: dayTime(points[0].Date, tc), Label: "tc " + dayTime(points[0].Date, tc).Format(time.DateOnly)}}})
+	dateAxis(p, meta, points[0].Date)
+
+	panels, err := panelPlots(p, points[0].Date, extras.Panels)
+	if err != nil {
+		return err
+	}
+	height := plotHeight + vg.Length(len(panels))*panelHeight
+	return savePlots(append([]*gplot.Plot{p}, panels...), plotWidth, height, extras.Footer, path)
+}
@@ -0,0 +1,35 @@
+package plot
+
+import (
+	gplot "gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+
+	"cw3/internal/safe"
+	"cw3/lppl"
+)
+
+// Spectrum rysuje periodogram reszt w czasie liniowym albo, gdy logTime, w ln(tc-t)
+func Spectrum(s lppl.Spectrum, logTime bool, meta Meta, footer, path string) error {
+	return safe.Guard("widmo reszt", func() error {
+		p := gplot.New()
+		p.Title.Text = meta.title(meta.Text("spectrumTime"))
+		p.X.Label.Text = meta.Text("frequency")
+		if logTime {
+			p.Title.Text = meta.title(meta.Text("spectrumLogTime"))
+			p.X.Label.Text = meta.Text("angularFrequency")
+		}
+		p.Y.Label.Text = meta.Text("power")
+
+		pts := make(plotter.XYs, len(s.Freq))
+		for i := range s.Freq {
+			pts[i].X, pts[i].Y = s.Freq[i], s.Power[i]
+		}
+		line, err := plotter.NewLine(pts)
+		if err != nil {
+			return err
+		}
+		p.Add(line)
+		return savePlots([]*gplot.Plot{p}, 8*vg.Inch, 4*vg.Inch, footer, path)
+	})
+}
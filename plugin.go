@@ -0,0 +1,33 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"sort"
+
+	"cw3/data"
+	"cw3/datasource"
+)
+
+// loadPlugin pobiera szereg z zewnętrznej wtyczki źródła danych
+func loadPlugin(path string, req datasource.Request) ([]data.Point, error) {
+	source, closePlugin, err := datasource.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closePlugin()
+
+	fetched, err := source.Fetch(req)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]data.Point, len(fetched))
+	for i, p := range fetched {
+		points[i] = data.Point{Date: p.Date, Price: p.Price}
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Date.Before(points[j].Date)
+	})
+	return points, nil
+}
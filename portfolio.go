@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// holding to pozycja w portfelu użytkownika: symbol aktywa i jego wartość
+type holding struct {
+	Symbol string  `json:"symbol"`
+	Value  float64 `json:"value"`
+}
+
+// portfolio przechowuje udziały aktywów w wartości portfela według symbolu (wielkimi literami)
+type portfolio map[string]float64
+
+// loadPortfolio wczytuje pozycje z pliku JSON (tablica obiektów symbol, value) albo CSV
+// z kolumnami symbol,value (nagłówek jest opcjonalny)
+func loadPortfolio(path string) (portfolio, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var holdings []holding
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.NewDecoder(file).Decode(&holdings); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	} else {
+		r := csv.NewReader(file)
+		r.FieldsPerRecord = 2
+		for line := 1; ; line++ {
+			record, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			value, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+			if err != nil {
+				if line == 1 {
+					continue // nagłówek
+				}
+				return nil, fmt.Errorf("%s:%d: nieprawidłowa wartość %q", path, line, record[1])
+			}
+			holdings = append(holdings, holding{Symbol: record[0], Value: value})
+		}
+	}
+
+	var total float64
+	for _, h := range holdings {
+		if h.Value < 0 {
+			return nil, fmt.Errorf("%s: ujemna wartość pozycji %s", path, h.Symbol)
+		}
+		total += h.Value
+	}
+	if total == 0 {
+		return nil, errors.New(path + ": portfel jest pusty")
+	}
+	p := make(portfolio, len(holdings))
+	for _, h := range holdings {
+		p[strings.ToUpper(strings.TrimSpace(h.Symbol))] += h.Value / total
+	}
+	return p, nil
+}
+
+// Weight zwraca udział aktywa w portfelu (0, gdy go nie ma)
+func (p portfolio) Weight(symbol string) float64 {
+	return p[strings.ToUpper(symbol)]
+}
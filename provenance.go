@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"cw3/data"
+)
+
+// provenance opisuje, jak powstał wynik: wersja programu, rewizja kodu, skrót
+// konfiguracji, źródło i skrót danych
+type provenance struct {
+	Version    string `json:"version"`
+	Revision   string `json:"revision,omitempty"`
+	Modified   bool   `json:"modified,omitempty"`
+	ConfigHash string `json:"config_hash"`
+	Source     string `json:"source"`
+	DataHash   string `json:"data_hash"`
+	// Pipeline to kolejne przekształcenia szeregu od wczytania do dopasowania
+	Pipeline []transformStep `json:"pipeline,omitempty"`
+}
+
+// transformStep to jedno przekształcenie szeregu i jego wynik
+type transformStep struct {
+	Step   string    `json:"step"`
+	Detail string    `json:"detail,omitempty"`
+	Rows   int       `json:"rows"`
+	From   time.Time `json:"from,omitzero"`
+	To     time.Time `json:"to,omitzero"`
+}
+
+func newStep(step, detail string, points []data.Point) transformStep {
+	s := transformStep{Step: step, Detail: detail, Rows: len(points)}
+	if len(points) > 0 {
+		s.From, s.To = points[0].Date, points[len(points)-1].Date
+	}
+	return s
+}
+
+// pipelineString zwraca przekształcenia w jednym wierszu do dziennika
+func pipelineString(steps []transformStep) string {
+	parts := make([]string, len(steps))
+	for i, s := range steps {
+		parts[i] = fmt.Sprintf("%s (%d obs.)", s.Step, s.Rows)
+		if s.Detail != "" {
+			parts[i] = fmt.Sprintf("%s: %s (%d obs.)", s.Step, s.Detail, s.Rows)
+		}
+	}
+	return strings.Join(parts, " -> ")
+}
+
+func newProvenance(source string, points []data.Point, cfg any) provenance {
+	p := provenance{Version: "(devel)", ConfigHash: configHash(cfg), Source: source, DataHash: dataHash(points)}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Version != "" {
+			p.Version = info.Main.Version
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				p.Revision = s.Value
+			case "vcs.modified":
+				p.Modified = s.Value == "true"
+			}
+		}
+	}
+	return p
+}
+
+// String zwraca jednowierszowy opis do stopek wykresów i raportu; skróty są skracane do 12 znaków
+func (p provenance) String() string {
+	rev := short(p.Revision)
+	if rev == "" {
+		rev = "brak rewizji"
+	}
+	if p.Modified {
+		rev += "+zmiany"
+	}
+	return fmt.Sprintf("lppl %s (%s) | źródło: %s | dane: %s | konfiguracja: %s",
+		p.Version, rev, p.Source, short(p.DataHash), short(p.ConfigHash))
+}
+
+func short(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
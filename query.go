@@ -0,0 +1,157 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// slackSigningSecretEnv to zmienna z kluczem podpisu aplikacji Slack, używana, gdy flaga jest pusta
+const slackSigningSecretEnv = "SLACK_SIGNING_SECRET"
+
+// slackMaxSkew to największa różnica między znacznikiem czasu zapytania Slack a zegarem
+// serwera; starsze zapytania są odrzucane jako możliwe powtórzenia
+const slackMaxSkew = 5 * time.Minute
+
+// maxCommandBody ogranicza rozmiar treści polecenia Slack
+const maxCommandBody = 64 << 10
+
+// findSymbol wybiera symbol z pytania: pierwsze słowo równe (bez względu na wielkość liter)
+// symbolowi z historii, a przy jednym symbolu w historii - ten symbol
+func (s *apiServer) findSymbol(question string) (string, error) {
+	names, err := s.history.names()
+	if err != nil {
+		return "", err
+	}
+	words := strings.FieldsFunc(question, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' && r != '.'
+	})
+	for _, word := range words {
+		word = strings.TrimRight(word, ".")
+		for _, name := range names {
+			if strings.EqualFold(word, name) {
+				return name, nil
+			}
+		}
+	}
+	switch len(names) {
+	case 0:
+		return "", errors.New("brak analiz w rejestrze")
+	case 1:
+		return names[0], nil
+	}
+	return "", fmt.Errorf("nie rozpoznano symbolu; dostępne: %s", strings.Join(names, ", "))
+}
+
+// answer odpowiada na pytanie o symbol krótkim podsumowaniem ostatniej analizy z odnośnikiem
+// do wykresu
+func (s *apiServer) answer(question, base string) (string, error) {
+	symbol, err := s.findSymbol(question)
+	if err != nil {
+		return "", err
+	}
+	history, _, err := s.history.symbol(symbol)
+	if err != nil {
+		return "", err
+	}
+	rec := history[0]
+	filters := "filtry niespełnione"
+	if rec.Qualified {
+		filters = "filtry spełnione"
+	}
+	return fmt.Sprintf("%s: tc %s (%+.0f dni od danych do %s), pewność bańki %.2f, %s. Analiza z %s. Wykres: %s/chart/%s.png",
+		symbol, rec.Tc.Format(time.DateOnly), metricValue(rec, "tc_days"), rec.End.Format(time.DateOnly),
+		rec.Confidence, filters, rec.Time.UTC().Format("2006-01-02 15:04 UTC"), base, url.PathEscape(symbol)), nil
+}
+
+// publicBase zwraca adres serwera do odnośników: -base-url albo adres z zapytania
+func (s *apiServer) publicBase(r *http.Request) string {
+	if s.baseURL != "" {
+		return s.baseURL
+	}
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// handleQuery odpowiada tekstem na pytanie ?q= (np. "jakie jest tc i pewność dla BTC?")
+// albo ?symbol=
+func (s *apiServer) handleQuery(w http.ResponseWriter, r *http.Request) {
+	question := r.URL.Query().Get("symbol")
+	if question == "" {
+		question = r.URL.Query().Get("q")
+	}
+	text, err := s.answer(question, s.publicBase(r))
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintln(w, err)
+		return
+	}
+	fmt.Fprintln(w, text)
+}
+
+// handleSlackCommand obsługuje polecenie z ukośnikiem aplikacji Slack (np. /lppl BTC):
+// sprawdza podpis zapytania kluczem -slack-signing-secret i odpowiada na kanale
+func (s *apiServer) handleSlackCommand(w http.ResponseWriter, r *http.Request) {
+	if s.slackSecret == "" {
+		writeError(w, http.StatusServiceUnavailable, fmt.Errorf("polecenia Slack wymagają -slack-signing-secret lub %s", slackSigningSecretEnv))
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCommandBody))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := verifySlack(s.slackSecret, r.Header, body, time.Now()); err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	reply := map[string]string{"response_type": "in_channel"}
+	text, err := s.answer(form.Get("text"), s.publicBase(r))
+	if err != nil {
+		// Błąd widzi tylko autor polecenia
+		reply["response_type"], text = "ephemeral", err.Error()
+	}
+	reply["text"] = text
+	writeJSON(w, http.StatusOK, reply)
+}
+
+// verifySlack sprawdza podpis v0 zapytania Slack (HMAC-SHA256 z "v0:<czas>:<treść>")
+// i świeżość jego znacznika czasu
+func verifySlack(secret string, h http.Header, body []byte, now time.Time) error {
+	ts := h.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errors.New("brak znacznika czasu zapytania Slack")
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > slackMaxSkew || d < -slackMaxSkew {
+		return errors.New("przeterminowane zapytanie Slack")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:", ts)
+	mac.Write(body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(want), []byte(h.Get("X-Slack-Signature"))) {
+		return errors.New("nieprawidłowy podpis zapytania Slack")
+	}
+	return nil
+}
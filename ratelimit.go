@@ -0,0 +1,217 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimits to ograniczenia zapytań do jednego dostawcy danych
+type rateLimits struct {
+	// Największa liczba zapytań w toku
+	Concurrency int
+	// Największa liczba zapytań na minutę (0 - bez limitu)
+	PerMinute float64
+	// Liczba ponowień po błędzie sieci, kodzie 429 lub 5xx i pierwsza przerwa przed
+	// ponowieniem, podwajana przy kolejnych (chyba że serwer poda Retry-After)
+	Retries int
+	Backoff time.Duration
+}
+
+// providerHosts przypisuje hosty API dostawcom, których dotyczą limity
+var providerHosts = map[string]string{
+	"api.binance.com":        "binance",
+	"fapi.binance.com":       "binance",
+	"testnet.binance.vision": "binance",
+	"api.coingecko.com":      "coingecko",
+	"api.blockchain.info":    "blockchain",
+	"api.frankfurter.app":    "frankfurter",
+	"fred.stlouisfed.org":    "fred",
+}
+
+// providerLimits to limity dostawców; domyślne wartości leżą poniżej publicznych limitów
+// (np. około 30 zapytań na minutę w darmowym planie CoinGecko)
+var providerLimits = map[string]rateLimits{
+	"binance":     {Concurrency: 4, PerMinute: 600, Retries: 3, Backoff: time.Second},
+	"coingecko":   {Concurrency: 1, PerMinute: 20, Retries: 4, Backoff: 5 * time.Second},
+	"blockchain":  {Concurrency: 2, PerMinute: 60, Retries: 3, Backoff: 2 * time.Second},
+	"frankfurter": {Concurrency: 2, PerMinute: 60, Retries: 3, Backoff: time.Second},
+	"fred":        {Concurrency: 2, PerMinute: 60, Retries: 3, Backoff: 2 * time.Second},
+}
+
+// parseRateLimits nakłada na providerLimits ustawienia w postaci
+// dostawca.klucz=wartość rozdzielone przecinkami, np. coingecko.rpm=10,binance.retries=5;
+// klucze to concurrency, rpm, retries i backoff
+func parseRateLimits(v string) error {
+	for _, item := range strings.Split(v, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(item, "=")
+		provider, setting, ok2 := strings.Cut(key, ".")
+		if !ok || !ok2 {
+			return fmt.Errorf("%q: oczekiwano dostawca.klucz=wartość", item)
+		}
+		limits, known := providerLimits[provider]
+		if !known {
+			return fmt.Errorf("nieznany dostawca %q (znani: %s)", provider, strings.Join(slices.Sorted(maps.Keys(providerLimits)), ", "))
+		}
+		var err error
+		switch setting {
+		case "concurrency":
+			limits.Concurrency, err = strconv.Atoi(value)
+			if err == nil && limits.Concurrency < 1 {
+				err = errors.New("musi być dodatnia")
+			}
+		case "rpm":
+			limits.PerMinute, err = strconv.ParseFloat(value, 64)
+			if err == nil && limits.PerMinute < 0 {
+				err = errors.New("nie może być ujemna")
+			}
+		case "retries":
+			limits.Retries, err = strconv.Atoi(value)
+			if err == nil && limits.Retries < 0 {
+				err = errors.New("nie może być ujemna")
+			}
+		case "backoff":
+			limits.Backoff, err = time.ParseDuration(value)
+		default:
+			return fmt.Errorf("%q: nieznany klucz %q (concurrency, rpm, retries, backoff)", item, setting)
+		}
+		if err != nil {
+			return fmt.Errorf("%q: %w", item, err)
+		}
+		providerLimits[provider] = limits
+	}
+	return nil
+}
+
+// limitedTransport stosuje limity dostawcy, do którego należy host zapytania; zapytania do
+// innych hostów przechodzą bez zmian. Stan limitów powstaje przy pierwszym zapytaniu do
+// dostawcy, już po wczytaniu flag.
+type limitedTransport struct {
+	base http.RoundTripper
+
+	mu        sync.Mutex
+	providers map[string]*provider
+}
+
+// provider to stan limitów jednego dostawcy
+type provider struct {
+	name    string
+	limits  rateLimits
+	slots   chan struct{}
+	mu      sync.Mutex
+	nextRun time.Time
+}
+
+func (t *limitedTransport) provider(host string) *provider {
+	name, ok := providerHosts[host]
+	if !ok {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if p, ok := t.providers[name]; ok {
+		return p
+	}
+	limits := providerLimits[name]
+	p := &provider{name: name, limits: limits, slots: make(chan struct{}, max(limits.Concurrency, 1))}
+	if t.providers == nil {
+		t.providers = map[string]*provider{}
+	}
+	t.providers[name] = p
+	return p
+}
+
+// wait rezerwuje najbliższy termin zapytania zgodny z limitem na minutę i zwraca czas
+// oczekiwania na niego
+func (p *provider) wait() time.Duration {
+	if p.limits.PerMinute <= 0 {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	at := now
+	if p.nextRun.After(now) {
+		at = p.nextRun
+	}
+	p.nextRun = at.Add(time.Duration(float64(time.Minute) / p.limits.PerMinute))
+	return at.Sub(now)
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := t.provider(req.URL.Hostname())
+	if p == nil {
+		return t.base.RoundTrip(req)
+	}
+	ctx := req.Context()
+	backoff := p.limits.Backoff
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+		select {
+		case p.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if err := sleepCtx(ctx, p.wait()); err != nil {
+			<-p.slots
+			return nil, err
+		}
+		resp, err := t.base.RoundTrip(req)
+		<-p.slots
+
+		retry := err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if !retry || attempt >= p.limits.Retries || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+		delay, reason := backoff, ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			if s, perr := strconv.Atoi(resp.Header.Get("Retry-After")); perr == nil && s >= 0 {
+				delay = time.Duration(s) * time.Second
+			}
+			resp.Body.Close()
+		}
+		log.Printf("%s: %s, ponowienie %d z %d za %s", p.name, reason, attempt+1, p.limits.Retries, delay)
+		if err := sleepCtx(ctx, delay); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
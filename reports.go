@@ -0,0 +1,49 @@
+package main
+
+import (
+	"log"
+
+	"gonum.org/v1/gonum/stat"
+
+	"cw3/data"
+	"cw3/fit"
+	"cw3/lppl"
+	"cw3/plot"
+)
+
+// writeSpectra zapisuje wykresy periodogramów reszt jako <prefiks>_time.png
+// i <prefiks>_logtime.png oraz wypisuje dominujące piki
+func writeSpectra(points []data.Point, params []float64, meta plot.Meta, prefix, footer string) error {
+	linear, logTime, err := lppl.ResidualSpectra(points, params)
+	if err != nil {
+		return err
+	}
+	f, p := linear.Peak()
+	log.Printf("Widmo reszt (czas liniowy): pik %.4f cykli/dzień (okres %.1f dni), moc %.2f", f, 1/f, p)
+	w, p := logTime.Peak()
+	log.Printf("Widmo reszt (czas ln(tc-t)): pik omega=%.2f, moc %.2f", w, p)
+
+	if err := plot.Spectrum(linear, false, meta, footer, prefix+"_time.png"); err != nil {
+		return err
+	}
+	return plot.Spectrum(logTime, true, meta, footer, prefix+"_logtime.png")
+}
+
+// writeDiagnostics zapisuje wykres diagnostyczny reszt i wypisuje ich podstawowe statystyki;
+// wyraźna autokorelacja rzędu 1 oznacza strukturę, której model nie wyjaśnił
+func writeDiagnostics(points []data.Point, params []float64, meta plot.Meta, path, footer string) error {
+	_, r := lppl.Residuals(points, params)
+	if len(r) > 2 {
+		log.Printf("Reszty: odchylenie %.4f, skośność %.3f, kurtoza nadwyżkowa %.3f, autokorelacja rzędu 1 %.3f",
+			stat.StdDev(r, nil), stat.Skew(r, nil), stat.ExKurtosis(r, nil), stat.Correlation(r[:len(r)-1], r[1:], nil))
+	}
+	return plot.Diagnostics(points, params, meta, footer, path)
+}
+
+func plotStability(points []data.Point, opts lppl.FitOptions, minPoints int, meta plot.Meta, prefix, footer string) error {
+	mGrid, omegaGrid := fit.StabilityGrids(points, opts, minPoints)
+	if err := plot.Heatmap(mGrid, "m", meta, footer, prefix+"_m.png"); err != nil {
+		return err
+	}
+	return plot.Heatmap(omegaGrid, "omega", meta, footer, prefix+"_omega.png")
+}
@@ -0,0 +1,134 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"cw3/data"
+	"cw3/fit"
+	"cw3/internal/atomicfile"
+)
+
+// runBacktest obsługuje polecenie backtest: dopasowuje model w przesuwanym oknie i
+// sprawdza, na ile wcześniejsze dopasowania zapowiadały rzeczywiste spadki
+func runBacktest(args []string) error {
+	fs := flag.NewFlagSet("backtest", flag.ExitOnError)
+	configPath := fs.String("fit", "", "plik ustawień dopasowania: YAML jak -config albo JSON z polami lppl.FitOptions (pusty - ustawienia domyślne)")
+	inputPath := fs.String("input", defaultInput, "plik CSV z notowaniami")
+	output := fs.String("output", "", "zapisz tabelę dopasowań do pliku CSV (domyślnie wypisywana na standardowe wyjście)")
+	fs.BoolVar(&atomicfile.Overwrite, "overwrite", false, "zastąp istniejący plik tabeli")
+	window := fs.Int("window", 120, "długość okna w obserwacjach")
+	step := fs.Int("step", 5, "przesunięcie okna w obserwacjach")
+	threshold := fs.Float64("drawdown", 0.2, "minimalny spadek od szczytu uznawany za krach (ułamek ceny)")
+	tolerance := fs.Float64("tolerance", 30, "maksymalna różnica między tc a szczytem przed spadkiem w dniach, przy której alarm jest trafiony")
+	popts := data.DefaultParseOptions()
+	csvFlags(fs, &popts)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Użycie: %s backtest [flagi] [plik.csv]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		return errors.New("backtest przyjmuje jeden plik wejściowy")
+	}
+	if fs.NArg() == 1 {
+		*inputPath = fs.Arg(0)
+	}
+	if *threshold <= 0 || *threshold >= 1 {
+		return fmt.Errorf("próg spadku -drawdown musi należeć do (0, 1), podano %g", *threshold)
+	}
+
+	opts, err := loadFitOptions(*configPath)
+	if err != nil {
+		return err
+	}
+	points, report, err := data.Load(*inputPath, popts)
+	if err != nil {
+		return err
+	}
+	log.Printf("%s: %s", *inputPath, report)
+	if len(points) < *window {
+		return fmt.Errorf("za mało obserwacji (%d) na okno o długości %d", len(points), *window)
+	}
+
+	fits, sum := fit.RollingBacktest(points, opts, *window, *step, *threshold, *tolerance)
+	if *output == "" {
+		writeBacktestTable(os.Stdout, fits)
+	} else if err := writeBacktestCSV(*output, fits); err != nil {
+		return err
+	}
+
+	log.Printf("Dopasowania: %d (nieudane: %d), kwalifikowane: %d, alarmy bańki dodatniej: %d",
+		sum.Fits, sum.Failed, sum.Qualified, sum.Alarms)
+	log.Printf("Spadki o co najmniej %.0f%%: %d, zapowiedziane: %d", 100**threshold, sum.Crashes, sum.Anticipated)
+	if sum.Alarms > 0 {
+		log.Printf("Trafione alarmy (|tc - szczyt| <= %.0f dni): %d z %d (%.0f%%), mediana |tc - szczyt|: %.1f dni",
+			*tolerance, sum.Hits, sum.Alarms, 100*float64(sum.Hits)/float64(sum.Alarms), sum.MedianAbsError)
+	}
+	return nil
+}
+
+var backtestHeader = []string{"end", "tc", "cost", "qualified", "positive", "crash_peak", "crash_depth", "error_days", "hit"}
+
+func backtestRow(f fit.BacktestFit) []string {
+	var peak, depth, errDays string
+	if f.Crash != nil {
+		peak = f.Crash.Peak.Format(time.DateOnly)
+		depth = strconv.FormatFloat(f.Crash.Depth, 'f', 4, 64)
+		errDays = strconv.FormatFloat(f.ErrorDays, 'f', 1, 64)
+	}
+	return []string{
+		f.End.Format(time.DateOnly),
+		f.Tc.Format(time.DateOnly),
+		strconv.FormatFloat(f.Cost, 'g', 6, 64),
+		strconv.FormatBool(f.Qualified),
+		strconv.FormatBool(f.Positive),
+		peak, depth, errDays,
+		strconv.FormatBool(f.Hit),
+	}
+}
+
+func writeBacktestTable(out io.Writer, fits []fit.BacktestFit) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+	for _, row := range append([][]string{backtestHeader}, backtestRows(fits)...) {
+		for _, cell := range row {
+			fmt.Fprintf(w, "%s\t", cell)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func backtestRows(fits []fit.BacktestFit) [][]string {
+	rows := make([][]string, len(fits))
+	for i, f := range fits {
+		rows[i] = backtestRow(f)
+	}
+	return rows
+}
+
+func writeBacktestCSV(path string, fits []fit.BacktestFit) error {
+	file, err := atomicfile.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+
+	w := csv.NewWriter(file)
+	w.Write(backtestHeader)
+	w.WriteAll(backtestRows(fits))
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return file.Commit()
+}
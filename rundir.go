@@ -0,0 +1,68 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// runDirLayout to format nazwy katalogu przebiegu (bez dwukropków, które nie są
+// dozwolone w nazwach plików w Windows)
+const runDirLayout = "2006-01-02T150405Z"
+
+// latestLink to nazwa dowiązania do katalogu ostatniego udanego przebiegu
+const latestLink = "latest"
+
+// runName zwraca nazwę katalogu wejścia in pod -out-dir: symbol albo nazwę pliku bez rozszerzenia
+func (c cliConfig) runName(in input) string {
+	switch {
+	case c.asset != "":
+		return c.asset
+	case in.symbol != "":
+		return in.symbol
+	}
+	return strings.TrimSuffix(filepath.Base(in.name), filepath.Ext(in.name))
+}
+
+// newRunDir tworzy katalog root/name/<czas UTC>; gdy w tej samej sekundzie istnieje
+// już katalog innego przebiegu, nazwa dostaje przyrostek -2, -3...
+func newRunDir(root, name string, now time.Time) (string, error) {
+	parent := filepath.Join(root, name)
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return "", err
+	}
+	base := filepath.Join(parent, now.UTC().Format(runDirLayout))
+	dir := base
+	for i := 2; ; i++ {
+		err := os.Mkdir(dir, 0o755)
+		if err == nil {
+			return dir, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", err
+		}
+		dir = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// linkLatest ustawia dowiązanie latest obok dir tak, by wskazywało dir. Nowe dowiązanie
+// powstaje pod tymczasową nazwą i zastępuje stare przez rename, więc latest zawsze
+// wskazuje kompletny przebieg.
+func linkLatest(dir string) error {
+	link := filepath.Join(filepath.Dir(dir), latestLink)
+	tmp := link + ".tmp"
+	os.Remove(tmp)
+	if err := os.Symlink(filepath.Base(dir), tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
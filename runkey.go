@@ -0,0 +1,90 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"time"
+
+	"cw3/data"
+	"cw3/lppl"
+	"cw3/plot"
+)
+
+// runKey to ustawienia wpływające na dopasowanie i pliki wynikowe, z których liczone są
+// skróty konfiguracji analizy. Pomija rejestr, -force, wysyłkę, alerty, powiadomienia
+// i połączenie z giełdą: zależą od sposobu wywołania, a zawarte w nich wskaźniki dawałyby
+// inny skrót przy każdym uruchomieniu.
+type runKey struct {
+	Opts        lppl.FitOptions
+	Parse       data.ParseOptions
+	Pipeline    []data.StageSpec
+	MinWindow   int
+	MaxWindow   int
+	WindowStep  int
+	ClusterEps  float64
+	ClusterMin  int
+	Smooth      string
+	Deseason    []int
+	HQ          bool
+	Regimes     bool
+	Prescreen   bool
+	Drawups     bool
+	Onchain     []string
+	Derivatives string
+	Crash       lppl.CrashCalibration
+	Bootstrap   lppl.BootstrapOptions
+	SignalRules signalRules
+	Backtest    bool
+	Fee         float64
+	Sizing      sizingRule
+	Portfolio   portfolio
+	Asset       string
+	Currency    string
+	Dividends   string
+	ConvertTo   string
+	FXSource    string
+	Deflate     string
+	CPISeries   string
+	RealBase    time.Time
+	LPPLSIn     string
+
+	Output          plot.OutputOptions
+	Events          []plot.Event
+	Lang            string
+	Format          string
+	Multi           bool
+	OutDir          string
+	ConfidencePanel bool
+	PlotOut         string
+	LagrangeOut     string
+	HeatmapPrefix   string
+	FractionsOut    string
+	LPPLSOut        string
+	ArrowOut        string
+	CurveOut        string
+	HTMLOut         string
+	ICSOut          string
+	SpectrumOut     string
+	DiagOut         string
+	OnchainPlot     string
+	SignalsOut      string
+}
+
+func (c cliConfig) hashKey() runKey {
+	return runKey{
+		Opts: c.opts, Parse: c.parse, Pipeline: c.pipeline,
+		MinWindow: c.minWindow, MaxWindow: c.maxWindow, WindowStep: c.windowStep,
+		ClusterEps: c.clusterEps, ClusterMin: c.clusterMin,
+		Smooth: c.smooth, Deseason: c.deseason, HQ: c.hq, Regimes: c.regimes, Prescreen: c.prescreen, Drawups: c.drawups,
+		Onchain: c.onchain, Derivatives: c.derivatives, Crash: c.crash, Bootstrap: c.bootstrap,
+		SignalRules: c.signalRules, Backtest: c.backtest, Fee: c.fee, Sizing: c.sizing, Portfolio: c.portfolio,
+		Asset: c.asset, Currency: c.currency, Dividends: c.dividends, ConvertTo: c.convertTo, FXSource: c.fxSource,
+		Deflate: c.deflate, CPISeries: c.cpiSeries, RealBase: c.realBase, LPPLSIn: c.lpplsIn,
+
+		Output: plot.Output, Events: c.events, Lang: c.lang, Format: c.format, Multi: c.multi, OutDir: c.outDir,
+		ConfidencePanel: c.confidencePanel, PlotOut: c.plotOut, LagrangeOut: c.lagrangeOut, HeatmapPrefix: c.heatmapPrefix,
+		FractionsOut: c.fractionsOut, LPPLSOut: c.lpplsOut, ArrowOut: c.arrowOut, CurveOut: c.curveOut,
+		HTMLOut: c.htmlOut, ICSOut: c.icsOut, SpectrumOut: c.spectrumOut, DiagOut: c.diagOut,
+		OnchainPlot: c.onchainPlot, SignalsOut: c.signalsOut,
+	}
+}
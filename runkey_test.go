@@ -0,0 +1,172 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+	"unsafe"
+
+	"cw3/lppl"
+)
+
+func TestConfigHashIgnoresInvocationSettings(t *testing.T) {
+	base := cliConfig{opts: lppl.DefaultFitOptions(), minWindow: 60, maxWindow: 120, windowStep: 5, plotOut: "wykres.png"}
+	want := configHash(base.hashKey())
+
+	// Alerty, wysyłka i rejestr są wskaźnikami, więc ich adres zmienia się przy każdym uruchomieniu
+	for i := 0; i < 3; i++ {
+		c := base
+		c.alerts = &webhookAlerter{url: "https://example.com/hook", cooldown: time.Hour, Alerts: map[string]alertState{}}
+		c.upload = &uploader{bucket: "wyniki", template: "{symbol}/{date}"}
+		c.registry = &runRegistry{}
+		c.force = i%2 == 0
+		if got := configHash(c.hashKey()); got != want {
+			t.Fatalf("przebieg %d: skrót %s, oczekiwano %s", i, got, want)
+		}
+	}
+}
+
+func TestConfigHashChangesWithFitSettings(t *testing.T) {
+	base := cliConfig{opts: lppl.DefaultFitOptions(), minWindow: 60, maxWindow: 120}
+	tests := []struct {
+		name   string
+		change func(c *cliConfig)
+	}{
+		{"maksymalne okno", func(c *cliConfig) { c.maxWindow = 121 }},
+		{"metoda", func(c *cliConfig) { c.opts.Method = lppl.MethodCMAES }},
+		{"zakres tc", func(c *cliConfig) { c.opts.TcMaxFrac = 0.4 }},
+		{"wykres", func(c *cliConfig) { c.plotOut = "inny.png" }},
+		// NaN nie da się zapisać w JSON, więc skrót liczony jest z opisu tekstowego
+		{"NaN", func(c *cliConfig) { c.fee = math.NaN() }},
+	}
+	want := configHash(base.hashKey())
+	for _, tt := range tests {
+		c := base
+		tt.change(&c)
+		if got := configHash(c.hashKey()); got == want {
+			t.Errorf("%s: skrót się nie zmienił", tt.name)
+		}
+	}
+}
+
+// hashExcluded to pola cliConfig, które opisują samo wywołanie, a nie wynik analizy
+var hashExcluded = map[string]bool{
+	"paper":     true, // stan papierowego portfela zmienia się z każdym przebiegiem
+	"runDir":    true, // katalog rejestru, w którym skrót jest zapisywany
+	"upload":    true, // wysyłka gotowych plików
+	"force":     true, // wymusza przebieg mimo zgodnego skrótu
+	"registry":  true, // rejestr przebiegów
+	"alerts":    true, // stan powiadomień webhook
+	"notifiers": true, // kanały powiadomień
+}
+
+// TestConfigHashCoversEveryField zmienia po kolei każde pole cliConfig; nowe pole musi trafić
+// do hashKey albo na listę hashExcluded
+func TestConfigHashCoversEveryField(t *testing.T) {
+	base := cliConfig{opts: lppl.DefaultFitOptions(), minWindow: 60, maxWindow: 120}
+	want := configHash(base.hashKey())
+	typ := reflect.TypeOf(base)
+	for i := 0; i < typ.NumField(); i++ {
+		name := typ.Field(i).Name
+		c := base
+		field := reflect.ValueOf(&c).Elem().Field(i)
+		if !perturb(reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()) {
+			t.Errorf("%s: nie umiem zmienić pola typu %s", name, field.Type())
+			continue
+		}
+		changed := configHash(c.hashKey()) != want
+		switch {
+		case hashExcluded[name] && changed:
+			t.Errorf("%s: pole jest wykluczone, a zmienia skrót", name)
+		case !hashExcluded[name] && !changed:
+			t.Errorf("%s: pole nie zmienia skrótu; dodaj je do hashKey albo do hashExcluded", name)
+		}
+	}
+}
+
+// perturb zmienia wartość v; zwraca false, jeśli typu nie da się zmienić
+func perturb(v reflect.Value) bool {
+	if v.Type() == reflect.TypeOf(time.Time{}) {
+		v.Set(reflect.ValueOf(v.Interface().(time.Time).Add(time.Hour)))
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(!v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(v.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(v.Uint() + 1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(v.Float() + 1.5)
+	case reflect.String:
+		v.SetString(v.String() + "x")
+	case reflect.Slice:
+		v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+	case reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		key := reflect.New(v.Type().Key()).Elem()
+		if !perturb(key) {
+			return false
+		}
+		v.SetMapIndex(key, reflect.Zero(v.Type().Elem()))
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+	case reflect.Struct:
+		// Zmieniane są wszystkie pola, bo część z nich może nie trafiać do JSON
+		ok := false
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if perturb(reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()) {
+				ok = true
+			}
+		}
+		return ok
+	default:
+		return false
+	}
+	return true
+}
+
+func TestParsePriorities(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]float64
+		wantErr bool
+	}{
+		{in: "", want: map[string]float64{}},
+		{in: "BTC=3", want: map[string]float64{"BTC": 3}},
+		{in: "BTC=3, ETH=0.5,", want: map[string]float64{"BTC": 3, "ETH": 0.5}},
+		{in: "BTC", wantErr: true},
+		{in: "BTC=0", wantErr: true},
+		{in: "BTC=-1", wantErr: true},
+		{in: "BTC=x", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parsePriorities(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parsePriorities(%q): oczekiwano błędu, otrzymano %v", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parsePriorities(%q): %v", tt.in, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("parsePriorities(%q) = %v, oczekiwano %v", tt.in, got, tt.want)
+			continue
+		}
+		for k, v := range tt.want {
+			if got[k] != v {
+				t.Errorf("parsePriorities(%q)[%s] = %v, oczekiwano %v", tt.in, k, got[k], v)
+			}
+		}
+	}
+}
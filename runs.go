@@ -0,0 +1,109 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"time"
+
+	"cw3/data"
+	"cw3/internal/atomicfile"
+)
+
+// dataHash to skrót SHA-256 dat i cen szeregu
+func dataHash(points []data.Point) string {
+	h := sha256.New()
+	var buf [16]byte
+	for _, p := range points {
+		binary.LittleEndian.PutUint64(buf[:8], uint64(p.Date.UnixNano()))
+		binary.LittleEndian.PutUint64(buf[8:], math.Float64bits(p.Price))
+		h.Write(buf[:])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// configHash to skrót konfiguracji w postaci JSON (pola i klucze map w stałej kolejności);
+// wartości, których nie da się zapisać w JSON (np. NaN), są opisywane tekstowo
+func configHash(cfg any) string {
+	content, err := json.Marshal(cfg)
+	if err != nil {
+		content = []byte(fmt.Sprintf("%+v", cfg))
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// runHash łączy skróty danych i konfiguracji w identyfikator analizy
+func runHash(points []data.Point, cfg any) string {
+	sum := sha256.Sum256([]byte(dataHash(points) + configHash(cfg)))
+	return hex.EncodeToString(sum[:])
+}
+
+type runRecord struct {
+	Hash  string    `json:"hash"`
+	Input string    `json:"input"`
+	Time  time.Time `json:"time"`
+
+	// Podsumowanie dopasowania, z którego polecenie site buduje stronę monitoringu
+	Symbol     string    `json:"symbol,omitempty"`
+	End        time.Time `json:"end,omitzero"`
+	Tc         time.Time `json:"tc,omitzero"`
+	Cost       float64   `json:"cost,omitempty"`
+	Qualified  bool      `json:"qualified,omitempty"`
+	Confidence float64   `json:"confidence,omitempty"`
+	// Ścieżka wykresu dopasowania w chwili zapisu
+	Chart string `json:"chart,omitempty"`
+}
+
+// runRegistry to plik z listą wykonanych analiz, chroniący przed ich powtórzeniem
+type runRegistry struct {
+	path string
+	Runs []runRecord `json:"runs"`
+}
+
+func openRegistry(path string) (*runRegistry, error) {
+	r := &runRegistry{path: path}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, r); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+func (r *runRegistry) Seen(hash string) (runRecord, bool) {
+	for _, rec := range r.Runs {
+		if rec.Hash == hash {
+			return rec, true
+		}
+	}
+	return runRecord{}, false
+}
+
+func (r *runRegistry) Add(rec runRecord) error {
+	r.Runs = append(r.Runs, rec)
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := atomicfile.Replace(r.path)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+	if _, err := f.Write(content); err != nil {
+		return err
+	}
+	return f.Commit()
+}
@@ -0,0 +1,52 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"log"
+	"strings"
+
+	"cw3/data"
+)
+
+//go:embed Bitcoin_11.03.2025-10.04.2025_historical_data_coinmarketcap.csv
+var btc2025CSV []byte
+
+// sample to wbudowany zbiór danych do pokazów i testów bez sieci i własnych plików
+type sample struct {
+	name, symbol, description string
+	content                   []byte
+	parse                     data.ParseOptions
+}
+
+var samples = []sample{
+	{name: "btc-2025", symbol: "BTC", description: "Bitcoin, 11.03-10.04.2025, dzienne notowania CoinMarketCap", content: btc2025CSV, parse: data.DefaultParseOptions()},
+}
+
+// sampleNames zwraca opis wbudowanych zbiorów do pomocy flag
+func sampleNames() string {
+	names := make([]string, len(samples))
+	for i, s := range samples {
+		names[i] = s.name + " (" + s.description + ")"
+	}
+	return strings.Join(names, ", ")
+}
+
+// sampleInput zwraca wejście wczytujące wbudowany zbiór o podanej nazwie
+func sampleInput(name string) (input, error) {
+	for _, s := range samples {
+		if s.name == name {
+			return input{name: "sample:" + s.name, symbol: s.symbol, load: func() ([]data.Point, error) {
+				points, report, err := data.Parse(bytes.NewReader(s.content), s.parse)
+				if err == nil {
+					log.Printf("%s: %s", s.name, report)
+				}
+				return points, err
+			}}, nil
+		}
+	}
+	return input{}, fmt.Errorf("nieznany zbiór %q (dostępne: %s)", name, sampleNames())
+}
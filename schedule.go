@@ -0,0 +1,194 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Granice mnożnika pracy wejścia względem ustawień z wiersza poleceń
+const (
+	minWorkScale = 0.25
+	maxWorkScale = 8
+)
+
+// budgetScheduler dzieli czas przebiegu (np. codziennego monitoringu uruchamianego z crona)
+// między wejścia według priorytetów. Każde wejście dostaje część pozostałego czasu
+// proporcjonalną do swojego priorytetu wśród wejść jeszcze nieprzetworzonych, a z niej
+// mnożnik pracy: więcej startów optymalizacji i gęstsze okna dla ważnych symboli, rzadsze
+// okna dla pozostałych. Czas jednostki pracy mierzony jest na dotychczasowych wejściach,
+// więc przekroczenia wcześniejszych wejść zmniejszają przydział kolejnych. Jedno wejście
+// nie jest przerywane, więc jego przekroczenie tylko zmniejsza przydział następnych.
+type budgetScheduler struct {
+	budget   time.Duration
+	priority map[string]float64
+
+	deadline time.Time
+	// Łączny czas i łączny mnożnik pracy przetworzonych wejść
+	spent time.Duration
+	work  float64
+}
+
+// parsePriorities czyta priorytety postaci symbol=waga rozdzielone przecinkami
+func parsePriorities(v string) (map[string]float64, error) {
+	out := map[string]float64{}
+	for _, item := range strings.Split(v, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q: oczekiwano symbol=waga", item)
+		}
+		w, err := strconv.ParseFloat(value, 64)
+		if err != nil || w <= 0 {
+			return nil, fmt.Errorf("%q: waga musi być liczbą dodatnią", item)
+		}
+		out[strings.TrimSpace(name)] = w
+	}
+	return out, nil
+}
+
+// inputKeys zwraca nazwy, pod którymi wejście może występować w -priority i -refit-every:
+// symbol, nazwę i nazwę pliku bez rozszerzenia
+func inputKeys(in input) []string {
+	base := filepath.Base(in.name)
+	return []string{in.symbol, in.name, strings.TrimSuffix(base, filepath.Ext(base))}
+}
+
+// weight zwraca priorytet wejścia; wejścia spoza listy mają priorytet 1
+func (s *budgetScheduler) weight(in input) float64 {
+	for _, key := range inputKeys(in) {
+		if w, ok := s.priority[key]; ok && key != "" {
+			return w
+		}
+	}
+	return 1
+}
+
+// order ustawia wejścia od najwyższego priorytetu, żeby przy wyczerpaniu budżetu
+// pominięte zostały najmniej ważne, z wyjątkiem wejścia o najniższym priorytecie, które
+// idzie pierwsze i służy do pomiaru tempa; rozpoczyna też odmierzanie budżetu
+func (s *budgetScheduler) order(inputs []input) {
+	sort.SliceStable(inputs, func(i, j int) bool { return s.weight(inputs[i]) > s.weight(inputs[j]) })
+	if n := len(inputs); n > 1 {
+		last := inputs[n-1]
+		copy(inputs[1:], inputs[:n-1])
+		inputs[0] = last
+	}
+	s.deadline = time.Now().Add(s.budget)
+}
+
+// plan zwraca ustawienia dla wejścia inputs[0] (pozostałe to wejścia jeszcze
+// nieprzetworzone) albo false, gdy budżet jest wyczerpany
+func (s *budgetScheduler) plan(c cliConfig, inputs []input) (cliConfig, float64, bool) {
+	remaining := time.Until(s.deadline)
+	if remaining <= 0 {
+		return c, 0, false
+	}
+	var total float64
+	for _, in := range inputs {
+		total += s.weight(in)
+	}
+	share := remaining.Seconds() * s.weight(inputs[0]) / total
+
+	// Tempo nie jest jeszcze znane, więc pierwsze wejście (o najniższym priorytecie) liczone
+	// jest najtaniej i służy do jego pomiaru; inaczej mogłoby samo przekroczyć cały budżet
+	scale := minWorkScale
+	if s.work > 0 {
+		scale = share / (s.spent.Seconds() / s.work)
+	}
+	scale = math.Max(minWorkScale, math.Min(maxWorkScale, scale))
+
+	// Rzadsze okna przy mniejszym przydziale, przy większym najpierw gęstsze okna (do kroku 1),
+	// a resztę mnożnika zamieniają dodatkowe starty losowe
+	starts := c.opts.GridStarts() + c.opts.RandomStarts
+	if scale < 1 {
+		c.windowStep = max(1, int(math.Round(float64(c.windowStep)/scale)))
+	} else {
+		step := max(1, int(math.Round(float64(c.windowStep)/math.Sqrt(scale))))
+		gain := float64(c.windowStep) / float64(step)
+		c.windowStep = step
+		c.opts.RandomStarts = max(c.opts.RandomStarts, int(math.Round(float64(starts)*scale/gain))-c.opts.GridStarts())
+	}
+	log.Printf("Budżet: %s - priorytet %g, przydział %s z pozostałych %s, mnożnik pracy %.2f (startów %d, krok okna %d)",
+		inputs[0].name, s.weight(inputs[0]), time.Duration(share*float64(time.Second)).Round(time.Second), remaining.Round(time.Second),
+		scale, c.opts.GridStarts()+c.opts.RandomStarts, c.windowStep)
+	return c, scale, true
+}
+
+// done zapisuje czas przetworzenia wejścia z danym mnożnikiem pracy
+func (s *budgetScheduler) done(scale float64, elapsed time.Duration) {
+	s.spent += elapsed
+	s.work += scale
+}
+
+// refitSlack to część odstępu -refit-every, o którą wcześniej wejście uznawane jest za
+// gotowe do ponownej analizy, żeby przebieg z crona co godzinę nie pomijał symbolu
+// przeliczanego co godzinę tylko dlatego, że poprzedni przebieg skończył się kilka sekund później
+const refitSlack = 0.1
+
+// refitPlan to odstępy ponownych dopasowań poszczególnych symboli przy przebiegach
+// uruchamianych częściej niż najkrótszy z nich (np. cron co godzinę: BTC co godzinę,
+// pozostałe raz dziennie); czas ostatniej analizy pochodzi z rejestru -runs-file
+type refitPlan struct {
+	// Klucz * oznacza wejścia spoza listy; bez niego są one analizowane przy każdym przebiegu
+	every map[string]time.Duration
+}
+
+// parseIntervals czyta odstępy postaci symbol=czas rozdzielone przecinkami, np. BTC=1h,*=24h
+func parseIntervals(v string) (map[string]time.Duration, error) {
+	out := map[string]time.Duration{}
+	for _, item := range strings.Split(v, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q: oczekiwano symbol=czas", item)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%q: czas musi być dodatni, np. 1h lub 24h", item)
+		}
+		out[strings.TrimSpace(name)] = d
+	}
+	return out, nil
+}
+
+// interval zwraca odstęp analiz wejścia albo 0, gdy ma być analizowane przy każdym przebiegu
+func (p refitPlan) interval(in input, name string) time.Duration {
+	for _, key := range append([]string{name}, inputKeys(in)...) {
+		if d, ok := p.every[key]; ok && key != "" {
+			return d
+		}
+	}
+	return p.every["*"]
+}
+
+// due sprawdza, czy od ostatniej analizy wejścia w rejestrze minął odstęp symbolu name;
+// zwraca też czas następnej analizy. Wpisy są dopasowywane po nazwie wejścia, bo przebiegi
+// zakończone przed dopasowaniem (np. pominięte przez -prescreen) nie zapisują symbolu.
+func (p refitPlan) due(registry *runRegistry, in input, name string, now time.Time) (bool, time.Time) {
+	every := p.interval(in, name)
+	if every <= 0 {
+		return true, now
+	}
+	var last time.Time
+	for _, rec := range registry.Runs {
+		if rec.Input == in.name && rec.Time.After(last) {
+			last = rec.Time
+		}
+	}
+	next := last.Add(every - time.Duration(refitSlack*float64(every)))
+	return !now.Before(next), next
+}
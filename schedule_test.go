@@ -0,0 +1,74 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseIntervals(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]time.Duration
+		wantErr bool
+	}{
+		{in: "", want: map[string]time.Duration{}},
+		{in: "BTC=1h, *=24h", want: map[string]time.Duration{"BTC": time.Hour, "*": 24 * time.Hour}},
+		{in: "BTC", wantErr: true},
+		{in: "BTC=0s", wantErr: true},
+		{in: "BTC=dzień", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseIntervals(tt.in)
+		if tt.wantErr != (err != nil) {
+			t.Errorf("parseIntervals(%q): błąd %v", tt.in, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("parseIntervals(%q) = %v, oczekiwano %v", tt.in, got, tt.want)
+		}
+		for k, v := range tt.want {
+			if got[k] != v {
+				t.Errorf("parseIntervals(%q)[%s] = %v, oczekiwano %v", tt.in, k, got[k], v)
+			}
+		}
+	}
+}
+
+func TestRefitPlanDue(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	btc := input{name: "binance:BTCUSDT", symbol: "BTCUSDT"}
+	eth := input{name: "data/eth.csv"}
+	sol := input{name: "binance:SOLUSDT", symbol: "SOLUSDT"}
+	registry := &runRegistry{Runs: []runRecord{
+		{Input: btc.name, Symbol: "BTCUSDT", Time: now.Add(-55 * time.Minute)},
+		{Input: btc.name, Symbol: "BTCUSDT", Time: now.Add(-3 * time.Hour)},
+		{Input: eth.name, Symbol: "eth", Time: now.Add(-20 * time.Hour)},
+		// Przebieg pominięty przez -prescreen nie ma symbolu, ale liczy się jako analiza
+		{Input: sol.name, Time: now.Add(-2 * time.Hour)},
+	}}
+	plan := refitPlan{every: map[string]time.Duration{"BTCUSDT": time.Hour, "eth": 24 * time.Hour, "*": 24 * time.Hour}}
+	tests := []struct {
+		name string
+		in   input
+		run  string
+		want bool
+	}{
+		// 55 minut mieści się w zapasie 10% godzinnego odstępu
+		{"w zapasie odstępu", btc, "BTCUSDT", true},
+		{"przed upływem doby", eth, "eth", false},
+		{"bez symbolu w rejestrze", sol, "SOLUSDT", false},
+		{"bez wpisu w rejestrze", input{name: "data/doge.csv"}, "doge", true},
+	}
+	for _, tt := range tests {
+		if got, next := plan.due(registry, tt.in, tt.run, now); got != tt.want {
+			t.Errorf("%s: due = %t (następna analiza %s), oczekiwano %t", tt.name, got, next, tt.want)
+		}
+	}
+
+	// Wejścia spoza listy bez * są analizowane przy każdym przebiegu
+	if ok, _ := (refitPlan{every: map[string]time.Duration{"BTCUSDT": time.Hour}}).due(registry, eth, "eth", now); !ok {
+		t.Error("wejście spoza listy bez * powinno być zawsze gotowe")
+	}
+}
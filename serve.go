@@ -0,0 +1,414 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	ossignal "os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cw3/data"
+	"cw3/internal/safe"
+	"cw3/lppl"
+)
+
+// maxFitBody ogranicza rozmiar treści zapytania POST /fit
+const maxFitBody = 32 << 20
+
+// xlsxMediaType to typ treści skoroszytu Excel
+const xlsxMediaType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// fitLimits to górne granice ustawień i danych przesyłanych przez klientów API; chronią
+// serwer przed zapytaniem, które zajęłoby go na godziny
+type fitLimits struct {
+	Starts     int
+	Workers    int
+	Iterations int
+	Points     int
+}
+
+// check sprawdza ustawienia i dane zapytania
+func (l fitLimits) check(points []data.Point, opts lppl.FitOptions) error {
+	if err := checkFitOptions(opts); err != nil {
+		return err
+	}
+	if opts.RandomStarts < 0 || opts.Workers < 0 || opts.MaxIterations < 0 {
+		return errors.New("RandomStarts, Workers i MaxIterations nie mogą być ujemne")
+	}
+	if starts := opts.GridStarts() + opts.RandomStarts; starts > l.Starts {
+		return fmt.Errorf("za dużo startów optymalizacji: %d (limit serwera %d)", starts, l.Starts)
+	}
+	if opts.Workers > l.Workers {
+		return fmt.Errorf("za dużo wątków Workers: %d (limit serwera %d)", opts.Workers, l.Workers)
+	}
+	if opts.MaxIterations > l.Iterations {
+		return fmt.Errorf("za duże MaxIterations: %d (limit serwera %d)", opts.MaxIterations, l.Iterations)
+	}
+	if len(points) > l.Points {
+		return fmt.Errorf("za dużo notowań: %d (limit serwera %d)", len(points), l.Points)
+	}
+	return nil
+}
+
+// apiServer obsługuje API HTTP: dopasowania przesłanych danych i stan skonfigurowanego aktywa
+type apiServer struct {
+	opts    lppl.FitOptions
+	limits  fitLimits
+	parse   data.ParseOptions
+	asset   string
+	jobs    *jobQueue
+	history *runHistory
+	charts  chartCache
+	// Wolne miejsca na wykresy GET /chart rysowane jednocześnie
+	renders chan struct{}
+	conf    confidenceConfig
+	metrics *fitMetrics
+	// Publiczny adres serwera w odnośnikach odpowiedzi i klucz podpisu poleceń Slack
+	baseURL     string
+	slackSecret string
+
+	mu     sync.Mutex
+	status *assetStatus
+}
+
+// assetStatus to ostatnie dopasowanie skonfigurowanego aktywa zwracane przez GET /status
+type assetStatus struct {
+	Asset      string    `json:"asset"`
+	Input      string    `json:"input"`
+	FittedAt   time.Time `json:"fitted_at"`
+	Error      string    `json:"error,omitempty"`
+	Fit        *fitReply `json:"fit,omitempty"`
+	Confidence float64   `json:"confidence"`
+}
+
+// fitReply to wynik dopasowania z diagnostyką zwracany przez API
+type fitReply struct {
+	fitSummary
+	Tc           time.Time `json:"tc"`
+	Conditioning float64   `json:"conditioning"`
+	Warnings     []string  `json:"warnings"`
+	Notes        []string  `json:"notes"`
+
+	best lppl.Result
+}
+
+// fitRequest to treść POST /fit w formacie JSON: dane jako CSV albo lista punktów oraz
+// opcjonalne pola lppl.FitOptions nakładane na ustawienia serwera
+type fitRequest struct {
+	CSV    string `json:"csv"`
+	Points []struct {
+		Date  time.Time `json:"date"`
+		Price float64   `json:"price"`
+	} `json:"points"`
+	Options json.RawMessage `json:"options"`
+}
+
+// runServe obsługuje polecenie serve: serwer HTTP z API JSON do dopasowań
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "adres nasłuchiwania serwera HTTP")
+	configPath := fs.String("fit", "", "plik ustawień dopasowania: YAML jak -config albo JSON z polami lppl.FitOptions (pusty - ustawienia domyślne)")
+	inputPath := fs.String("input", "", "plik CSV aktywa, którego ostatnie dopasowanie zwraca GET /status (pusty wyłącza)")
+	asset := fs.String("asset", "", "nazwa aktywa w GET /status (domyślnie nazwa pliku -input)")
+	refresh := fs.Duration("refresh", time.Hour, "odstęp między ponownymi dopasowaniami pliku -input (0 - tylko przy starcie)")
+	runsFile := fs.String("runs-file", ".lppl_runs.json", "rejestr analiz zaplanowanych przebiegów, z którego serwer podaje historię symboli (np. dla Grafany)")
+	baseURL := fs.String("base-url", "", "publiczny adres serwera w odnośnikach do wykresów w odpowiedziach /query i Slack (domyślnie z nagłówka Host)")
+	slackSecret := fs.String("slack-signing-secret", "", "klucz podpisu aplikacji Slack dla POST /slack/command (domyślnie ze zmiennej "+slackSigningSecretEnv+")")
+	workers := fs.Int("jobs", 1, "liczba zadań POST /jobs dopasowywanych jednocześnie")
+	jobTTL := fs.Duration("job-ttl", time.Hour, "czas przechowywania wyniku zakończonego zadania")
+	var limits fitLimits
+	fs.IntVar(&limits.Starts, "max-starts", 64, "największa liczba startów optymalizacji (siatka i RandomStarts) w zapytaniu")
+	fs.IntVar(&limits.Workers, "max-workers", runtime.GOMAXPROCS(0), "największa liczba wątków Workers w zapytaniu")
+	fs.IntVar(&limits.Iterations, "max-iterations", 20000, "największe MaxIterations w zapytaniu")
+	fs.IntVar(&limits.Points, "max-points", 100000, "największa liczba notowań w zapytaniu")
+	var conf confidenceConfig
+	confidenceFlags(fs, &conf)
+	popts := data.DefaultParseOptions()
+	csvFlags(fs, &popts)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Użycie: %s serve [flagi]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		return errors.New("serve nie przyjmuje argumentów; plik aktywa podaje się flagą -input")
+	}
+	opts, err := loadFitOptions(*configPath)
+	if err == nil {
+		err = checkFitOptions(opts)
+	}
+	if err != nil {
+		return err
+	}
+	// Ustawienia serwera z pliku -fit nie są ograniczane, więc zapytanie bez pola options
+	// zawsze je przechodzi
+	limits.Starts = max(limits.Starts, opts.GridStarts()+opts.RandomStarts)
+	limits.Workers = max(limits.Workers, opts.Workers)
+	limits.Iterations = max(limits.Iterations, opts.MaxIterations)
+	if *workers < 1 {
+		return fmt.Errorf("liczba zadań -jobs musi być dodatnia, podano %d", *workers)
+	}
+	s := &apiServer{opts: opts, limits: limits, history: newRunHistory(*runsFile), renders: make(chan struct{}, *workers), parse: popts, asset: *asset, conf: conf, metrics: newFitMetrics()}
+	if s.asset == "" {
+		s.asset = configName(*inputPath)
+	}
+	s.baseURL = strings.TrimSuffix(*baseURL, "/")
+	if s.slackSecret = *slackSecret; s.slackSecret == "" {
+		s.slackSecret = os.Getenv(slackSigningSecretEnv)
+	}
+
+	ctx, stop := ossignal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if s.jobs, err = newJobQueue(ctx, *workers, *jobTTL, s.metrics); err != nil {
+		return err
+	}
+	defer s.jobs.close()
+	if *inputPath != "" {
+		go s.refreshStatus(ctx, *inputPath, *refresh)
+	}
+
+	srv := &http.Server{Addr: *addr, Handler: s.handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	log.Printf("Serwer HTTP nasłuchuje na %s", *addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *apiServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /fit", s.handleFit)
+	mux.Handle("GET /metrics", s.metrics)
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.HandleFunc("POST /jobs", s.handleSubmit)
+	mux.HandleFunc("GET /jobs/{id}", s.handleJob)
+	mux.HandleFunc("GET /jobs/{id}/plot.png", s.handleJobPlot)
+	mux.HandleFunc("GET /chart/{file}", s.handleChart)
+	mux.HandleFunc("GET /history/{symbol}", s.handleHistory)
+	mux.HandleFunc("GET /badge/{file}", s.handleBadge)
+	mux.HandleFunc("GET /query", s.handleQuery)
+	mux.HandleFunc("POST /slack/command", s.handleSlackCommand)
+	mux.HandleFunc("GET /{$}", s.handleDashboard)
+	mux.HandleFunc("GET /watchlist", s.handleWatchlist)
+	mux.HandleFunc("GET /curve/{symbol}", s.handleCurve)
+	mux.HandleFunc("POST /refit/{symbol}", s.handleRefit)
+	mux.HandleFunc("POST /preview", s.handlePreview)
+	s.grafanaHandlers(mux)
+	return mux
+}
+
+// handleFit dopasowuje model do danych z treści zapytania: CSV (text/csv lub inny typ)
+// albo dokumentu JSON fitRequest (application/json)
+func (s *apiServer) handleFit(w http.ResponseWriter, r *http.Request) {
+	points, opts, err := s.readFitInput(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	reply, err := fitReplyFor(points, opts, "http")
+	s.metrics.count(apiAsset, err)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, reply)
+}
+
+// readFitInput odczytuje dane i ustawienia dopasowania z treści zapytania
+func (s *apiServer) readFitInput(w http.ResponseWriter, r *http.Request) ([]data.Point, lppl.FitOptions, error) {
+	body := http.MaxBytesReader(w, r.Body, maxFitBody)
+	opts := s.opts
+	var points []data.Point
+	popts, err := s.parseOptions(r.URL.Query())
+	if err != nil {
+		return nil, opts, err
+	}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		points, opts, err = s.decodeFitRequest(body, popts)
+	} else {
+		points, err = parseUpload(body, mediaType, popts)
+	}
+	if err == nil && len(points) == 0 {
+		err = errors.New("treść nie zawiera poprawnych notowań")
+	}
+	if err == nil {
+		err = s.limits.check(points, opts)
+	}
+	return points, opts, err
+}
+
+// parseOptions nakłada na format CSV serwera parametry zapytania o nazwach flag CSV
+// (date-col, price-col, delimiter, date-formats), np. z mapowania kolumn w panelu
+func (s *apiServer) parseOptions(q url.Values) (data.ParseOptions, error) {
+	popts := s.parse
+	for _, c := range []struct {
+		name string
+		col  *int
+	}{{"date-col", &popts.DateColumn}, {"price-col", &popts.PriceColumn}} {
+		if v := q.Get(c.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return popts, fmt.Errorf("%s: nieprawidłowy numer kolumny %q", c.name, v)
+			}
+			*c.col = n
+		}
+	}
+	if v := q.Get("delimiter"); v != "" {
+		var err error
+		if popts.Delimiter, err = parseDelimiter(v); err != nil {
+			return popts, err
+		}
+	}
+	if v := q.Get("date-formats"); v != "" {
+		popts.DateLayouts = strings.Split(v, ",")
+	}
+	return popts, nil
+}
+
+// parseUpload wczytuje notowania z pliku CSV albo skoroszytu xlsx, rozpoznawanego po typie
+// treści lub sygnaturze archiwum ZIP
+func parseUpload(r io.Reader, mediaType string, popts data.ParseOptions) ([]data.Point, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(4)
+	if mediaType != xlsxMediaType && !data.IsXLSX(head) {
+		points, _, err := data.Parse(br, popts)
+		return points, err
+	}
+	content, err := io.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+	points, _, err := data.ParseXLSX(bytes.NewReader(content), int64(len(content)), popts)
+	return points, err
+}
+
+func (s *apiServer) decodeFitRequest(r io.Reader, popts data.ParseOptions) ([]data.Point, lppl.FitOptions, error) {
+	opts := s.opts
+	var req fitRequest
+	if err := json.NewDecoder(r).Decode(&req); err != nil {
+		return nil, opts, err
+	}
+	if len(req.Options) > 0 {
+		if err := json.Unmarshal(req.Options, &opts); err != nil {
+			return nil, opts, fmt.Errorf("options: %w", err)
+		}
+	}
+	if req.CSV != "" {
+		points, _, err := data.Parse(strings.NewReader(req.CSV), popts)
+		return points, opts, err
+	}
+	points := make([]data.Point, 0, len(req.Points))
+	for i, p := range req.Points {
+		if !data.ValidPrice(p.Price) {
+			return nil, opts, fmt.Errorf("points[%d]: nieprawidłowa cena %g", i, p.Price)
+		}
+		if i > 0 && !p.Date.After(req.Points[i-1].Date) {
+			return nil, opts, fmt.Errorf("points[%d]: daty muszą rosnąć", i)
+		}
+		points = append(points, data.Point{Date: p.Date, Price: p.Price})
+	}
+	return points, opts, nil
+}
+
+func (s *apiServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	status := s.status
+	s.mu.Unlock()
+	if status == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("brak dopasowania skonfigurowanego aktywa"))
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// refreshStatus dopasowuje model do pliku path przy starcie i potem co every
+func (s *apiServer) refreshStatus(ctx context.Context, path string, every time.Duration) {
+	for {
+		status := &assetStatus{Asset: s.asset, Input: path, FittedAt: time.Now().UTC()}
+		points, _, err := data.Load(path, s.parse)
+		if err == nil {
+			status.Fit, err = fitReplyFor(points, s.opts, path)
+			s.metrics.count(s.asset, err)
+		}
+		if err == nil {
+			status.Confidence = s.conf.confidence(points, s.opts, status.Fit.best)
+			s.metrics.observe(s.asset, points, status.Fit.best, status.Confidence)
+		}
+		if err != nil {
+			status.Error = err.Error()
+			log.Printf("%s: dopasowanie nieudane: %v", s.asset, err)
+		} else {
+			log.Printf("%s: tc %s, koszt %.6f, spełnia filtry: %t, pewność bańki %.2f", s.asset,
+				status.Fit.Tc.Format(time.DateOnly), status.Fit.Cost, status.Fit.Qualified, status.Confidence)
+		}
+		s.mu.Lock()
+		s.status = status
+		s.mu.Unlock()
+
+		if every <= 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(every):
+		}
+	}
+}
+
+// fitReplyFor dopasowuje model i zbiera diagnostykę odpowiedzi API
+func fitReplyFor(points []data.Point, opts lppl.FitOptions, source string) (*fitReply, error) {
+	var reply *fitReply
+	err := safe.Guard("dopasowanie", func() error {
+		best, _, err := lppl.FitAll(points, opts)
+		if err != nil {
+			return err
+		}
+		cond := lppl.Conditioning(points, best.Params)
+		reply = &fitReply{
+			fitSummary:   summarizeFit(points, best),
+			Tc:           points[0].Date.Add(time.Duration(best.Params[0] * 24 * float64(time.Hour))).Round(time.Second),
+			Conditioning: cond.Cond,
+			Warnings:     cond.Warnings,
+			Notes:        best.Notes,
+			best:         best,
+		}
+		reply.Provenance = newProvenance(source, points, opts)
+		reply.Provenance.Pipeline = []transformStep{newStep("wczytanie", source, points)}
+		return nil
+	})
+	return reply, err
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"cw3/fit"
+	"cw3/internal/atomicfile"
+)
+
+// Rodzaje sygnałów
+const (
+	signalReduce  = "reduce"
+	signalExit    = "exit"
+	signalReenter = "reenter"
+)
+
+// signalRules to progi udziału kwalifikowanych dopasowań bańki dodatniej: od Reduce
+// ekspozycja jest ograniczana, od Exit pozycja zamykana, a po wyjściu lub ograniczeniu
+// powrót następuje, gdy udział spadnie do Reenter
+type signalRules struct {
+	Reduce  float64
+	Exit    float64
+	Reenter float64
+}
+
+func defaultSignalRules() signalRules {
+	return signalRules{Reduce: 0.3, Exit: 0.6, Reenter: 0.1}
+}
+
+func (r signalRules) validate() error {
+	if !(r.Reenter < r.Reduce && r.Reduce <= r.Exit) {
+		return fmt.Errorf("progi sygnałów muszą spełniać reenter < reduce <= exit (%.2f, %.2f, %.2f)", r.Reenter, r.Reduce, r.Exit)
+	}
+	return nil
+}
+
+type signal struct {
+	Date     time.Time
+	Kind     string
+	Fraction float64
+	// Exposure to docelowa ekspozycja po sygnale: 1, 0.5 albo 0
+	Exposure float64
+}
+
+// reducedExposure to ekspozycja po sygnale reduce
+const reducedExposure = 0.5
+
+// generateSignals przechodzi po szeregu udziałów i emituje sygnał przy każdej zmianie
+// docelowej ekspozycji; start zakłada pełną pozycję
+func generateSignals(series []fit.QualifiedFraction, rules signalRules) []signal {
+	var signals []signal
+	exposure := 1.0
+	for _, f := range series {
+		next := exposure
+		kind := ""
+		switch {
+		case f.Positive >= rules.Exit:
+			next, kind = 0, signalExit
+		case f.Positive >= rules.Reduce && exposure == 1:
+			next, kind = reducedExposure, signalReduce
+		case f.Positive <= rules.Reenter && exposure < 1:
+			next, kind = 1, signalReenter
+		}
+		if next != exposure {
+			signals = append(signals, signal{Date: f.Date, Kind: kind, Fraction: f.Positive, Exposure: next})
+			exposure = next
+		}
+	}
+	return signals
+}
+
+func writeSignals(path string, signals []signal) error {
+	file, err := atomicfile.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"date", "signal", "fraction", "exposure"})
+	for _, s := range signals {
+		w.Write([]string{
+			s.Date.Format("2006-01-02"),
+			s.Kind,
+			strconv.FormatFloat(s.Fraction, 'f', 4, 64),
+			strconv.FormatFloat(s.Exposure, 'f', 2, 64),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return file.Commit()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"cw3/fit"
+)
+
+func TestGenerateSignals(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	series := func(fractions ...float64) []fit.QualifiedFraction {
+		out := make([]fit.QualifiedFraction, len(fractions))
+		for i, f := range fractions {
+			out[i] = fit.QualifiedFraction{Date: start.AddDate(0, 0, i), Positive: f}
+		}
+		return out
+	}
+	type want struct {
+		day      int
+		kind     string
+		exposure float64
+	}
+	rules := defaultSignalRules() // reduce 0.3, exit 0.6, reenter 0.1
+	tests := []struct {
+		name      string
+		fractions []float64
+		want      []want
+	}{
+		{name: "pusty szereg"},
+		{name: "poniżej progów", fractions: []float64{0, 0.2, 0.29, 0.1}},
+		{name: "wyjście na pierwszej obserwacji", fractions: []float64{0.7, 0.7}, want: []want{{0, signalExit, 0}}},
+		{name: "ograniczenie na ostatniej obserwacji", fractions: []float64{0, 0.1, 0.3}, want: []want{{2, signalReduce, 0.5}}},
+		{
+			name:      "ograniczenie, wyjście i powrót",
+			fractions: []float64{0.1, 0.35, 0.5, 0.65, 0.4, 0.1, 0.05},
+			want:      []want{{1, signalReduce, 0.5}, {3, signalExit, 0}, {5, signalReenter, 1}},
+		},
+		{
+			// Między reenter a reduce ekspozycja się nie zmienia w żadną stronę
+			name:      "histereza po ograniczeniu",
+			fractions: []float64{0.4, 0.2, 0.15, 0.29, 0.11, 0.1},
+			want:      []want{{0, signalReduce, 0.5}, {5, signalReenter, 1}},
+		},
+		{
+			// Po wyjściu spadek poniżej reduce nie przywraca częściowej pozycji
+			name:      "histereza po wyjściu",
+			fractions: []float64{0.6, 0.45, 0.25, 0.6, 0.12},
+			want:      []want{{0, signalExit, 0}},
+		},
+		{
+			name:      "ponowne ograniczenie po powrocie",
+			fractions: []float64{0.3, 0.0, 0.3},
+			want:      []want{{0, signalReduce, 0.5}, {1, signalReenter, 1}, {2, signalReduce, 0.5}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := generateSignals(series(tt.fractions...), rules)
+			if len(got) != len(tt.want) {
+				t.Fatalf("otrzymano %d sygnałów %+v, oczekiwano %d", len(got), got, len(tt.want))
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if !g.Date.Equal(start.AddDate(0, 0, w.day)) || g.Kind != w.kind || g.Exposure != w.exposure || g.Fraction != tt.fractions[w.day] {
+					t.Errorf("sygnał %d: %+v, oczekiwano %s z ekspozycją %v w dniu %d", i, g, w.kind, w.exposure, w.day)
+				}
+			}
+		})
+	}
+}
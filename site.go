@@ -0,0 +1,223 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"cw3/internal/atomicfile"
+)
+
+// siteSymbol to strona jednego symbolu: historia analiz od najnowszej
+type siteSymbol struct {
+	Name    string
+	Dir     string
+	History []runRecord
+	// Ścieżka wykresu ostatniej analizy względem katalogu symbolu (pusta, gdy brak pliku)
+	Chart string
+	// Punkty linii historii pewności bańki w układzie współrzędnych SVG
+	Sparkline string
+}
+
+func (s siteSymbol) Latest() runRecord {
+	return s.History[0]
+}
+
+// Wymiary wykresu historii pewności na stronie symbolu
+const (
+	sparkWidth  = 600
+	sparkHeight = 120
+)
+
+var siteFuncs = template.FuncMap{
+	"date": func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Format(time.DateOnly)
+	},
+	"datetime": func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04 UTC") },
+}
+
+var siteIndex = template.Must(template.New("index").Funcs(siteFuncs).Parse(`<!DOCTYPE html>
+<html lang="pl"><head><meta charset="utf-8"><title>Monitoring LPPL</title>
+{{if .Feed}}<link rel="alternate" type="application/atom+xml" title="Sygnały baniek LPPL" href="atom.xml">{{end}}
+<style>body{font-family:sans-serif;margin:2em}table{border-collapse:collapse}td,th{padding:.3em .8em;border-bottom:1px solid #ddd;text-align:left}</style>
+</head><body>
+<h1>Monitoring LPPL</h1>
+<p>Wygenerowano {{datetime .Generated}}</p>
+<table>
+<tr><th>Symbol</th><th>Ostatnia analiza</th><th>Koniec danych</th><th>tc</th><th>Pewność bańki</th><th>Filtry</th></tr>
+{{range .Symbols}}{{$l := .Latest}}<tr><td><a href="{{.Dir}}/index.html">{{.Name}}</a></td><td>{{datetime $l.Time}}</td><td>{{date $l.End}}</td><td>{{date $l.Tc}}</td><td>{{printf "%.2f" $l.Confidence}}</td><td>{{if $l.Qualified}}spełnia{{else}}nie spełnia{{end}}</td></tr>
+{{end}}</table>
+</body></html>
+`))
+
+var sitePage = template.Must(template.New("symbol").Funcs(siteFuncs).Parse(`<!DOCTYPE html>
+<html lang="pl"><head><meta charset="utf-8"><title>{{.Name}} - monitoring LPPL</title>
+<style>body{font-family:sans-serif;margin:2em}table{border-collapse:collapse}td,th{padding:.3em .8em;border-bottom:1px solid #ddd;text-align:left}img{max-width:100%}</style>
+</head><body>
+<p><a href="../index.html">Wszystkie symbole</a></p>
+<h1>{{.Name}} <img src="badge.svg" alt="Pewność bańki {{.Name}}"></h1>
+{{if .Chart}}<img src="{{.Chart}}" alt="Dopasowanie LPPL {{.Name}}">{{end}}
+<h2>Historia pewności bańki</h2>
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" role="img" aria-label="Historia pewności bańki">
+<rect width="100%" height="100%" fill="#f8f8f8"/>
+<polyline fill="none" stroke="#c00" stroke-width="2" points="{{.Sparkline}}"/>
+</svg>
+<table>
+<tr><th>Analiza</th><th>Koniec danych</th><th>tc</th><th>Koszt</th><th>Pewność</th><th>Filtry</th></tr>
+{{range .History}}<tr><td>{{datetime .Time}}</td><td>{{date .End}}</td><td>{{date .Tc}}</td><td>{{printf "%.6f" .Cost}}</td><td>{{printf "%.2f" .Confidence}}</td><td>{{if .Qualified}}spełnia{{else}}nie spełnia{{end}}</td></tr>
+{{end}}</table>
+</body></html>
+`))
+
+// runSite obsługuje polecenie site: buduje statyczną stronę z rejestru analiz
+func runSite(args []string) error {
+	fs := flag.NewFlagSet("site", flag.ExitOnError)
+	runsFile := fs.String("runs-file", ".lppl_runs.json", "rejestr analiz, z którego powstaje strona")
+	outDir := fs.String("site", "site", "katalog strony (pliki są zastępowane przy każdym przebiegu)")
+	feed := fs.Bool("feed", true, "zapisz w katalogu strony kanał Atom (atom.xml) z sygnałami baniek i zmianami tc")
+	tcChange := fs.Float64("feed-tc-change", 30, "najmniejsze przesunięcie tc między kolejnymi analizami (w dniach) zgłaszane w kanale")
+	baseURL := fs.String("base-url", "", "publiczny adres strony, używany w odnośnikach kanału Atom")
+	author := fs.String("feed-author", "lppl", "autor kanału Atom")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Użycie: %s site [-runs-file plik] [-site katalog]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	registry, err := openRegistry(*runsFile)
+	if err != nil {
+		return err
+	}
+	bySymbol := groupBySymbol(registry.Runs)
+	if len(bySymbol) == 0 {
+		return fmt.Errorf("%s: brak analiz z podsumowaniem dopasowania", *runsFile)
+	}
+
+	var symbols []siteSymbol
+	for name, history := range bySymbol {
+		s := siteSymbol{Name: name, Dir: siteDirName(name), History: history, Sparkline: sparkline(history)}
+		dir := filepath.Join(*outDir, s.Dir)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		if chart := history[0].Chart; chart != "" {
+			if err := copyReplacing(chart, filepath.Join(dir, filepath.Base(chart))); err != nil {
+				log.Printf("%s: pomijam wykres: %v", name, err)
+			} else {
+				s.Chart = filepath.Base(chart)
+			}
+		}
+		if err := renderReplacing(badgeTemplate, newBadge(history[0]), filepath.Join(dir, "badge.svg")); err != nil {
+			return err
+		}
+		page := struct {
+			siteSymbol
+			Width, Height int
+		}{s, sparkWidth, sparkHeight}
+		if err := renderReplacing(sitePage, page, filepath.Join(dir, "index.html")); err != nil {
+			return err
+		}
+		symbols = append(symbols, s)
+	}
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].Name < symbols[j].Name })
+
+	now := time.Now()
+	index := struct {
+		Generated time.Time
+		Symbols   []siteSymbol
+		Feed      bool
+	}{now, symbols, *feed}
+	if err := renderReplacing(siteIndex, index, filepath.Join(*outDir, "index.html")); err != nil {
+		return err
+	}
+	if *feed {
+		if err := writeFeed(filepath.Join(*outDir, "atom.xml"), symbols, *tcChange, *baseURL, *author, now); err != nil {
+			return err
+		}
+	}
+	log.Printf("Strona z %d symbolami zapisana w %s", len(symbols), *outDir)
+	return nil
+}
+
+// groupBySymbol dzieli analizy z podsumowaniem dopasowania według symbolu, od najnowszej
+func groupBySymbol(runs []runRecord) map[string][]runRecord {
+	bySymbol := map[string][]runRecord{}
+	for _, rec := range runs {
+		if rec.Symbol == "" {
+			// Wpisy sprzed zapisywania podsumowań nie mają czego pokazać
+			continue
+		}
+		bySymbol[rec.Symbol] = append(bySymbol[rec.Symbol], rec)
+	}
+	for _, history := range bySymbol {
+		sort.SliceStable(history, func(i, j int) bool { return history[i].Time.After(history[j].Time) })
+	}
+	return bySymbol
+}
+
+// siteDirName zamienia symbol na bezpieczną nazwę katalogu strony
+func siteDirName(symbol string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, symbol)
+}
+
+// sparkline zwraca punkty linii pewności (od najstarszej analizy) w prostokącie sparkWidth x sparkHeight
+func sparkline(history []runRecord) string {
+	n := len(history)
+	points := make([]string, n)
+	for i, rec := range history {
+		x := float64(sparkWidth) / 2
+		if n > 1 {
+			x = float64(sparkWidth) * float64(n-1-i) / float64(n-1)
+		}
+		y := float64(sparkHeight) * (1 - rec.Confidence)
+		points[n-1-i] = fmt.Sprintf("%.1f,%.1f", x, y)
+	}
+	return strings.Join(points, " ")
+}
+
+func renderReplacing(t *template.Template, data any, path string) error {
+	f, err := atomicfile.Replace(path)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+	if err := t.Execute(f, data); err != nil {
+		return err
+	}
+	return f.Commit()
+}
+
+func copyReplacing(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	f, err := atomicfile.Replace(dst)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+	if _, err := io.Copy(f, in); err != nil {
+		return err
+	}
+	return f.Commit()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Kształty funkcji przekładającej wskaźnik pewności bańki na ekspozycję
+const (
+	sizingLinear = "linear"
+	sizingExp    = "exp"
+	sizingStep   = "step"
+)
+
+// sizingRule mapuje wskaźnik pewności c z [0, 1] na sugerowaną ekspozycję z [Min, 1]:
+// linear: 1 - K·c, exp: exp(-K·c), step: 1 dla c < K, w przeciwnym razie Min
+type sizingRule struct {
+	Kind string
+	K    float64
+	Min  float64
+}
+
+// parseSizing odczytuje regułę w postaci rodzaj:parametr, np. "linear:1" lub "step:0.3"
+func parseSizing(spec string) (sizingRule, error) {
+	kind, arg, ok := strings.Cut(spec, ":")
+	if !ok {
+		return sizingRule{}, fmt.Errorf("reguła ekspozycji %q nie ma postaci rodzaj:parametr", spec)
+	}
+	k, err := strconv.ParseFloat(arg, 64)
+	if err != nil || k < 0 {
+		return sizingRule{}, fmt.Errorf("nieprawidłowy parametr reguły ekspozycji %q", arg)
+	}
+	switch kind {
+	case sizingLinear, sizingExp, sizingStep:
+	default:
+		return sizingRule{}, fmt.Errorf("nieznany rodzaj reguły ekspozycji %q (dostępne: linear, exp, step)", kind)
+	}
+	return sizingRule{Kind: kind, K: k}, nil
+}
+
+func (r sizingRule) Exposure(confidence float64) float64 {
+	var e float64
+	switch r.Kind {
+	case sizingLinear:
+		e = 1 - r.K*confidence
+	case sizingExp:
+		e = math.Exp(-r.K * confidence)
+	case sizingStep:
+		e = 1
+		if confidence >= r.K {
+			e = r.Min
+		}
+	}
+	return math.Min(1, math.Max(r.Min, e))
+}
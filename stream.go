@@ -0,0 +1,270 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	ossignal "os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"cw3/data"
+	"cw3/lppl"
+)
+
+// binanceStreamURL to adres strumieni WebSocket rynku kasowego Binance
+const binanceStreamURL = "wss://stream.binance.com:9443/ws/"
+
+// Przerwa przed ponownym połączeniem rośnie dwukrotnie po każdym nieudanym połączeniu,
+// do streamMaxBackoff; Binance zamyka każde połączenie po 24 godzinach
+const (
+	streamMinBackoff = time.Second
+	streamMaxBackoff = time.Minute
+)
+
+// runStream obsługuje polecenie stream: pobiera historię świec pary z Binance,
+// dopisuje zamknięte świece ze strumienia WebSocket i co -refit dopasowuje model do
+// ostatnich -window świec, wypisując zmiany tc
+func runStream(args []string) error {
+	fs := flag.NewFlagSet("stream", flag.ExitOnError)
+	symbol := fs.String("symbol", "BTCUSDT", "para Binance")
+	interval := fs.String("interval", "1m", "interwał świec: 1m, 3m, 5m, 15m, 30m, 1h, 2h, 4h, 6h, 8h, 12h lub 1d")
+	window := fs.Int("window", 500, "liczba ostatnich świec, do których dopasowywany jest model")
+	refit := fs.Duration("refit", 5*time.Minute, "odstęp między kolejnymi dopasowaniami")
+	configPath := fs.String("fit", "", "plik ustawień dopasowania: YAML jak -config albo JSON z polami lppl.FitOptions (pusty - ustawienia domyślne)")
+	metricsAddr := fs.String("metrics", "", "udostępniaj metryki Prometheus pod /metrics na podanym adresie, np. :9100")
+	var conf confidenceConfig
+	confidenceFlags(fs, &conf)
+	var network netConfig
+	netFlags(fs, &network)
+	var alerter webhookAlerter
+	alertFlags(fs, &alerter)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Użycie: %s stream [flagi]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		return errors.New("stream nie przyjmuje plików wejściowych")
+	}
+	step, ok := binanceIntervals[*interval]
+	if !ok {
+		return fmt.Errorf("nieobsługiwany interwał Binance: %s", *interval)
+	}
+	if err := network.apply(); err != nil {
+		return err
+	}
+	if alerter.url != "" {
+		if err := alerter.load(); err != nil {
+			return err
+		}
+	}
+	if *refit <= 0 {
+		return fmt.Errorf("odstęp -refit musi być dodatni, podano %s", *refit)
+	}
+	opts, err := loadFitOptions(*configPath)
+	if err != nil {
+		return err
+	}
+	*symbol = strings.ToUpper(*symbol)
+
+	now := time.Now().UTC()
+	history, err := binanceSource{interval: *interval, from: now.Add(-time.Duration(*window) * step), to: now}.load(*symbol)
+	if err != nil {
+		return err
+	}
+	log.Printf("Binance %s: %d świec %s z historii", *symbol, len(history), *interval)
+
+	ctx, stop := ossignal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	metrics := newFitMetrics()
+	if *metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metrics)
+		srv := &http.Server{Addr: *metricsAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			<-ctx.Done()
+			srv.Close()
+		}()
+		go func() {
+			if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("Serwer metryk: %v", err)
+			}
+		}()
+		log.Printf("Metryki pod http://%s/metrics", *metricsAddr)
+	}
+	candles := make(chan data.Point)
+	go streamKlines(ctx, *symbol, *interval, candles)
+
+	s := streamState{points: history, window: *window}
+	f := streamFitter{symbol: *symbol, opts: opts, conf: conf, metrics: metrics}
+	if alerter.url != "" {
+		f.alerts = &alerter
+	}
+	// Dopasowanie trwa w osobnym wątku, żeby świece ze strumienia były odbierane na bieżąco;
+	// czeka w nim co najwyżej jedna migawka szeregu, zastępowana nowszą
+	pending := make(chan []data.Point, 1)
+	go f.run(ctx, pending)
+	submit := func() {
+		select {
+		case <-pending:
+		default:
+		}
+		pending <- slices.Clone(s.points)
+	}
+	submit()
+	ticker := time.NewTicker(*refit)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("Zakończono strumień %s", *symbol)
+			return nil
+		case p := <-candles:
+			s.add(p)
+		case <-ticker.C:
+			submit()
+		}
+	}
+}
+
+// streamState to szereg trybu strumieniowego w pamięci
+type streamState struct {
+	points []data.Point
+	window int
+}
+
+// add dopisuje świecę, pomijając świece starsze lub równe ostatniej (np. po ponownym
+// połączeniu), i przycina szereg do długości okna
+func (s *streamState) add(p data.Point) {
+	if n := len(s.points); n > 0 && !p.Date.After(s.points[n-1].Date) {
+		return
+	}
+	s.points = append(s.points, p)
+	if extra := len(s.points) - s.window; extra > 0 {
+		s.points = append(s.points[:0], s.points[extra:]...)
+	}
+}
+
+// streamFitter dopasowuje model do kolejnych migawek szeregu w jednym wątku
+type streamFitter struct {
+	symbol  string
+	opts    lppl.FitOptions
+	conf    confidenceConfig
+	metrics *fitMetrics
+	alerts  *webhookAlerter
+	// Data ostatniej świecy i tc z ostatniego dopasowania; puste przed pierwszym
+	fitted, tc time.Time
+}
+
+func (f *streamFitter) run(ctx context.Context, pending <-chan []data.Point) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case points := <-pending:
+			f.refit(points)
+		}
+	}
+}
+
+// refit dopasowuje model, jeśli od poprzedniego dopasowania doszły nowe świece
+func (f *streamFitter) refit(points []data.Point) {
+	if len(points) == 0 || points[len(points)-1].Date.Equal(f.fitted) {
+		return
+	}
+	symbol, last := f.symbol, points[len(points)-1]
+	best, _, err := lppl.FitAll(points, f.opts)
+	f.metrics.count(symbol, err)
+	if err != nil {
+		log.Printf("%s: dopasowanie do %s nieudane: %v", symbol, last.Date.Format(time.DateTime), err)
+		return
+	}
+	tc := points[0].Date.Add(time.Duration(best.Params[0] * 24 * float64(time.Hour)))
+	change := ""
+	if !f.tc.IsZero() {
+		change = fmt.Sprintf(" (zmiana %+.1f h)", tc.Sub(f.tc).Hours())
+	}
+	confidence := f.conf.confidence(points, f.opts, best)
+	f.metrics.observe(symbol, points, best, confidence)
+	log.Printf("%s: cena %.2f o %s, tc %s%s, koszt %.6f, spełnia filtry: %t, pewność bańki %.2f",
+		symbol, last.Price, last.Date.Format(time.DateTime), tc.Format(time.DateTime), change, best.Cost, best.Qualified(), confidence)
+	f.fitted, f.tc = last.Date, tc
+	if f.alerts != nil {
+		triage := newAlertTriage(points, best, confidence, nil, symbol, lppl.DefaultCrashCalibration())
+		if err := f.alerts.check(symbol, last.Date, tc, best, confidence, triage); err != nil {
+			log.Printf("%s: alert webhook: %v", symbol, err)
+		}
+	}
+}
+
+// streamKlines przesyła do out zamknięte świece ze strumienia Binance, łącząc się
+// ponownie po zerwaniu połączenia, aż do anulowania ctx
+func streamKlines(ctx context.Context, symbol, interval string, out chan<- data.Point) {
+	url := binanceStreamURL + strings.ToLower(symbol) + "@kline_" + interval
+	backoff := streamMinBackoff
+	for ctx.Err() == nil {
+		start := time.Now()
+		err := readKlines(ctx, url, out)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(start) > streamMaxBackoff {
+			backoff = streamMinBackoff
+		}
+		log.Printf("Strumień %s przerwany: %v; ponowne połączenie za %s", symbol, err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, streamMaxBackoff)
+	}
+}
+
+// readKlines czyta komunikaty jednego połączenia do pierwszego błędu
+func readKlines(ctx context.Context, url string, out chan<- data.Point) error {
+	conn, err := dialWebSocket(url, "https://stream.binance.com")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// Zamyka połączenie przy anulowaniu ctx; stop zwalnia rejestrację po zakończeniu połączenia
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	for {
+		var msg struct {
+			Kline struct {
+				Open   int64  `json:"t"`
+				Close  string `json:"c"`
+				Closed bool   `json:"x"`
+			} `json:"k"`
+		}
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			return err
+		}
+		if !msg.Kline.Closed {
+			continue
+		}
+		price, err := strconv.ParseFloat(msg.Kline.Close, 64)
+		if err != nil || !data.ValidPrice(price) {
+			continue
+		}
+		select {
+		case out <- data.Point{Date: time.UnixMilli(msg.Kline.Open).UTC(), Price: price}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
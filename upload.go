@@ -0,0 +1,189 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultUploadKey to szablon klucza obiektu, gdy adres -upload zawiera tylko zasobnik;
+// szablon zakończony ukośnikiem dostaje na końcu nazwę pliku
+const defaultUploadKey = "{symbol}/{time}/{name}"
+
+// gcsEndpoint to punkt dostępu Cloud Storage zgodny z API S3 (klucze HMAC)
+const gcsEndpoint = "https://storage.googleapis.com"
+
+// uploader wysyła pliki wynikowe do zasobnika S3 lub GCS, podpisując zapytania AWS
+// Signature V4 kluczami ze zmiennych AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY
+// i opcjonalnie AWS_SESSION_TOKEN (dla GCS: klucze HMAC konta usługi)
+type uploader struct {
+	bucket   string
+	template string
+	// Adres punktu dostępu; pusty oznacza S3 w regionie region z adresami wirtualnych hostów
+	endpoint string
+	region   string
+
+	accessKey, secretKey, sessionToken string
+}
+
+// newUploader tworzy uploader z adresu s3://zasobnik/szablon lub gs://zasobnik/szablon.
+// Szablon klucza może zawierać {symbol}, {input}, {date} (ostatnia obserwacja),
+// {time} (czas przebiegu UTC) i {name} (nazwa pliku).
+func newUploader(target, endpoint string) (*uploader, error) {
+	scheme, rest, ok := strings.Cut(target, "://")
+	if !ok || (scheme != "s3" && scheme != "gs") {
+		return nil, fmt.Errorf("adres %q: oczekiwano s3://zasobnik/klucz lub gs://zasobnik/klucz", target)
+	}
+	bucket, template, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("adres %q: brak nazwy zasobnika", target)
+	}
+	switch {
+	case template == "":
+		template = defaultUploadKey
+	case strings.HasSuffix(template, "/"):
+		template += "{name}"
+	}
+	u := &uploader{
+		bucket:       bucket,
+		template:     template,
+		endpoint:     strings.TrimSuffix(endpoint, "/"),
+		region:       os.Getenv("AWS_REGION"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if scheme == "gs" {
+		u.region = "auto"
+		if u.endpoint == "" {
+			u.endpoint = gcsEndpoint
+		}
+	}
+	if u.region == "" {
+		u.region = "us-east-1"
+	}
+	if u.accessKey == "" || u.secretKey == "" {
+		return nil, fmt.Errorf("wysyłka do %s: brak AWS_ACCESS_KEY_ID lub AWS_SECRET_ACCESS_KEY", target)
+	}
+	return u, nil
+}
+
+// key wypełnia szablon klucza dla pliku path
+func (u *uploader) key(path string, vars map[string]string) string {
+	pairs := []string{"{name}", filepath.Base(path)}
+	for k, v := range vars {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(u.template)
+}
+
+// objectURL zwraca adres obiektu key: na S3 w stylu wirtualnego hosta, przy własnym
+// punkcie dostępu (GCS, MinIO) w stylu ścieżki
+func (u *uploader) objectURL(key string) string {
+	if u.endpoint == "" {
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", u.bucket, u.region, s3Escape(key))
+	}
+	return u.endpoint + "/" + s3Escape(u.bucket) + "/" + s3Escape(key)
+}
+
+// s3Escape koduje ścieżkę tak, jak wymaga kanoniczne zapytanie Signature V4: poza
+// znakami niezastrzeżonymi RFC 3986 i ukośnikami każdy bajt jest zapisywany jako %XX
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// upload wysyła plik path pod kluczem z szablonu i zwraca adres obiektu
+func (u *uploader) upload(path string, vars map[string]string) (string, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	target := u.objectURL(u.key(path, vars))
+	req, err := http.NewRequest(http.MethodPut, target, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	if ct := mime.TypeByExtension(filepath.Ext(path)); ct != "" {
+		req.Header.Set("Content-Type", ct)
+	}
+	u.sign(req, body, time.Now().UTC())
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("%s: %s %s", target, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return target, nil
+}
+
+// sign dodaje do req nagłówki podpisu AWS Signature V4 dla usługi s3
+func (u *uploader) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payload := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payload)
+	headers := []string{"host:" + req.URL.Host, "x-amz-content-sha256:" + payload, "x-amz-date:" + amzDate}
+	signed := "host;x-amz-content-sha256;x-amz-date"
+	if u.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", u.sessionToken)
+		headers = append(headers, "x-amz-security-token:"+u.sessionToken)
+		signed += ";x-amz-security-token"
+	}
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		strings.Join(headers, "\n") + "\n",
+		signed,
+		payload,
+	}, "\n")
+	scope := day + "/" + u.region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := []byte("AWS4" + u.secretKey)
+	for _, part := range []string{day, u.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		u.accessKey, scope, signed, hex.EncodeToString(hmacSHA256(key, toSign))))
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, msg string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(msg))
+	return h.Sum(nil)
+}
@@ -0,0 +1,26 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"strings"
+	"syscall/js"
+)
+
+// W przeglądarce moduł rejestruje globalną funkcję lpplFit(csv, opcjeJSON) zwracającą JSON
+func main() {
+	js.Global().Set("lpplFit", js.FuncOf(jsFit))
+	select {}
+}
+
+func jsFit(this js.Value, args []js.Value) any {
+	if len(args) < 1 {
+		return `{"error":"brak danych CSV"}`
+	}
+
+	var options string
+	if len(args) > 1 && args[1].Type() == js.TypeString {
+		options = args[1].String()
+	}
+	return fitCSVJSON(strings.NewReader(args[0].String()), options)
+}
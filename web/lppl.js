@@ -0,0 +1,18 @@
+// Cienka otoczka na moduł WebAssembly z dopasowaniem LPPL.
+// Wymaga wcześniejszego załadowania wasm_exec.js z dystrybucji Go.
+export async function loadLPPL(wasmURL = "lppl.wasm") {
+  const go = new Go();
+  const result = await WebAssembly.instantiateStreaming(fetch(wasmURL), go.importObject);
+  go.run(result.instance);
+
+  return {
+    // fit przyjmuje treść pliku CSV (format CoinMarketCap) i opcjonalne pola fitOptions
+    fit(csvText, options = {}) {
+      const res = JSON.parse(globalThis.lpplFit(csvText, JSON.stringify(options)));
+      if (res.error) {
+        throw new Error(res.error);
+      }
+      return res;
+    },
+  };
+}
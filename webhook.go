@@ -0,0 +1,204 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"time"
+
+	"cw3/data"
+	"cw3/internal/atomicfile"
+	"cw3/lppl"
+)
+
+// Rodzaje alertów wysyłanych do webhooka
+const (
+	alertTc         = "tc"
+	alertConfidence = "confidence"
+)
+
+// webhookAlerter wysyła alert JSON metodą POST, gdy tc kwalifikowanego dopasowania jest
+// bliżej niż tcDays dni od ostatniej obserwacji albo pewność bańki przekracza próg. Alert
+// danego rodzaju jest wysyłany, gdy warunek zaczyna być spełniony, a póki trwa - co
+// najwyżej raz na cooldown; stan jest zapisywany w statePath między przebiegami.
+type webhookAlerter struct {
+	url        string
+	tcDays     float64
+	confidence float64
+	cooldown   time.Duration
+	statePath  string
+
+	Alerts map[string]alertState `json:"alerts"`
+}
+
+// alertState to stan alertu jednego rodzaju dla symbolu
+type alertState struct {
+	Active bool      `json:"active"`
+	Fired  time.Time `json:"fired,omitzero"`
+}
+
+// alertPayload to treść wysyłana do webhooka
+type alertPayload struct {
+	Symbol     string    `json:"symbol"`
+	Kind       string    `json:"kind"`
+	Message    string    `json:"message"`
+	End        time.Time `json:"end"`
+	Tc         time.Time `json:"tc"`
+	TcDays     float64   `json:"tc_days"`
+	Confidence float64   `json:"confidence"`
+	Qualified  bool      `json:"qualified"`
+	Cost       float64   `json:"cost"`
+	Time       time.Time `json:"time"`
+	alertTriage
+}
+
+// alertTriage to pola, po których odbiorca alertu ocenia jego pilność: ważność według
+// portfela, przewidywany sposób zakończenia bańki i niepewność tc oraz spadku po nim
+type alertTriage struct {
+	Severity      string   `json:"severity,omitempty"`
+	SeverityScore float64  `json:"severity_score"`
+	Exposure      *float64 `json:"exposure,omitempty"`
+	Resolution    string   `json:"resolution,omitempty"`
+	// Przedział ufności tc z -bootstrap
+	TcInterval *[2]time.Time `json:"tc_interval,omitempty"`
+	Expected   *float64      `json:"expected_drawdown,omitempty"`
+	Lower      *float64      `json:"drawdown_lower,omitempty"`
+	Upper      *float64      `json:"drawdown_upper,omitempty"`
+}
+
+// newAlertTriage zbiera pola alertTriage dopasowania best symbolu
+func newAlertTriage(points []data.Point, best lppl.Result, confidence float64, p portfolio, symbol string, cal lppl.CrashCalibration) alertTriage {
+	t := alertTriage{Resolution: lppl.ClassifyResolution(best)}
+	t.Severity, t.SeverityScore = alertSeverity(confidence, p, symbol)
+	if p != nil {
+		exposure := p.Weight(symbol)
+		t.Exposure = &exposure
+	}
+	if b := best.Bootstrap; b != nil {
+		day := func(t float64) time.Time {
+			return points[0].Date.Add(time.Duration(t * 24 * float64(time.Hour))).Round(time.Second)
+		}
+		t.TcInterval = &[2]time.Time{day(b.Intervals[0][0]), day(b.Intervals[0][1])}
+	}
+	if crash, err := lppl.EstimateCrash(best, points, cal); err == nil {
+		t.Expected, t.Lower, t.Upper = &crash.Expected, &crash.Lower, &crash.Upper
+	}
+	return t
+}
+
+func alertFlags(fs *flag.FlagSet, a *webhookAlerter) {
+	fs.StringVar(&a.url, "webhook", "", "adres, pod który wysyłany jest metodą POST alert JSON o zbliżającym się tc lub wysokiej pewności bańki")
+	fs.Float64Var(&a.tcDays, "alert-tc-days", 14, "alert, gdy tc kwalifikowanego dopasowania wypada najwyżej tyle dni po ostatniej obserwacji (ujemna wartość wyłącza)")
+	fs.Float64Var(&a.confidence, "alert-confidence", 0.5, "alert, gdy pewność bańki dodatniej osiąga próg (0 wyłącza)")
+	fs.DurationVar(&a.cooldown, "alert-cooldown", 24*time.Hour, "najkrótszy odstęp między powtórzeniami alertu, który nadal obowiązuje")
+	fs.StringVar(&a.statePath, "alert-state", ".lppl_alerts.json", "plik stanu alertów chroniący przed ich powtarzaniem (pusty - stan tylko w pamięci)")
+}
+
+// load wczytuje stan alertów; brak pliku oznacza brak wcześniejszych alertów
+func (a *webhookAlerter) load() error {
+	a.Alerts = map[string]alertState{}
+	if a.statePath == "" {
+		return nil
+	}
+	content, err := os.ReadFile(a.statePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(content, a); err != nil {
+		return fmt.Errorf("%s: %w", a.statePath, err)
+	}
+	return nil
+}
+
+// check ocenia dopasowanie best symbolu i wysyła alerty, których warunki właśnie zaczęły
+// obowiązywać lub minął ich cooldown; triage trafia do treści każdego alertu
+func (a *webhookAlerter) check(symbol string, end, tc time.Time, best lppl.Result, confidence float64, triage alertTriage) error {
+	now := time.Now().UTC()
+	tc = tc.Round(time.Second)
+	tcDays := tc.Sub(end).Hours() / 24
+	conditions := []struct {
+		kind    string
+		active  bool
+		message string
+	}{
+		{alertTc, a.tcDays >= 0 && best.Qualified() && tcDays <= a.tcDays,
+			fmt.Sprintf("%s: tc %s, %.0f dni po ostatniej obserwacji", symbol, tc.Format(time.DateOnly), tcDays)},
+		{alertConfidence, a.confidence > 0 && confidence >= a.confidence,
+			fmt.Sprintf("%s: pewność bańki dodatniej %.2f (próg %.2f)", symbol, confidence, a.confidence)},
+	}
+
+	var errs []error
+	changed := false
+	for _, c := range conditions {
+		key := symbol + "/" + c.kind
+		state := a.Alerts[key]
+		if c.active && (!state.Active || now.Sub(state.Fired) >= a.cooldown) {
+			payload := alertPayload{
+				Symbol: symbol, Kind: c.kind, Message: c.message, End: end, Tc: tc, TcDays: tcDays,
+				Confidence: confidence, Qualified: best.Qualified(), Cost: best.Cost, Time: now,
+				alertTriage: triage,
+			}
+			if err := a.post(payload); err != nil {
+				// Alert zostaje niewysłany, więc następny przebieg spróbuje ponownie
+				errs = append(errs, fmt.Errorf("%s: %w", c.kind, err))
+				continue
+			}
+			log.Printf("Wysłano alert: %s", c.message)
+			state.Fired = now
+		}
+		if state.Active != c.active || state.Fired != a.Alerts[key].Fired {
+			state.Active = c.active
+			a.Alerts[key] = state
+			changed = true
+		}
+	}
+	if changed {
+		if err := a.save(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *webhookAlerter) post(payload alertPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Post(a.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return errors.New(resp.Status)
+	}
+	return nil
+}
+
+func (a *webhookAlerter) save() error {
+	if a.statePath == "" {
+		return nil
+	}
+	f, err := atomicfile.Replace(a.statePath)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(a); err != nil {
+		return err
+	}
+	return f.Commit()
+}